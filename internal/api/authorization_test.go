@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/handlers"
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// These tests run every project, collection and record route on the memory
+// store and check the project → collection → record authorization: callers
+// without access to the project get 404, so other users' projects are not
+// revealed, and viewers get 403 on anything above their role.
+
+const testJWTSecret = "authorization-test"
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	db     store.Store
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{AppName: "test", JWTAccessSecret: testJWTSecret, StorageDriver: store.DriverMemory, WebhookMaxAttempts: 3}
+	db := store.NewMemoryStore()
+
+	projectSvc := services.NewProjectService(db, cfg)
+	collectionSvc := services.NewCollectionService(db, cfg)
+	recordSvc := services.NewRecordService(db, cfg)
+	endpointSvc := services.NewEndpointService(db, cfg)
+	sessionSvc := services.NewSessionService(db, cfg)
+	proxySvc := services.NewProxyService(db, cfg, collectionSvc, recordSvc)
+	mockSvc := services.NewMockService(db, cfg, endpointSvc, collectionSvc, recordSvc, sessionSvc, proxySvc)
+	inspectorSvc := services.NewInspectorService(db, cfg)
+	apiKeySvc := services.NewAPIKeyService(db, cfg)
+	templateSvc := services.NewTemplateService(db, cfg, projectSvc)
+
+	router := gin.New()
+	RegisterRoutes(router, cfg,
+		handlers.NewAuthHandler(services.NewAuthService(db, cfg), cfg),
+		handlers.NewProjectHandler(projectSvc, cfg),
+		handlers.NewCollectionHandler(collectionSvc, apiKeySvc, cfg),
+		handlers.NewRecordHandler(recordSvc, apiKeySvc, cfg),
+		handlers.NewHealthHandler(db),
+		handlers.NewConfigHandler(services.NewConfigService(), cfg),
+		handlers.NewEndpointHandler(endpointSvc, apiKeySvc, cfg),
+		handlers.NewMockHandler(mockSvc, inspectorSvc, cfg),
+		handlers.NewInspectorHandler(inspectorSvc, cfg),
+		handlers.NewSessionHandler(sessionSvc, collectionSvc, recordSvc, cfg),
+		handlers.NewAPIKeyHandler(apiKeySvc, cfg),
+		handlers.NewMemberHandler(services.NewMemberService(db, cfg), cfg),
+		handlers.NewOrganizationHandler(services.NewOrganizationService(db, cfg), cfg),
+		handlers.NewAuditHandler(services.NewAuditService(db, cfg), cfg),
+		handlers.NewWebhookHandler(services.NewWebhookService(db, cfg), cfg),
+		handlers.NewChangeStreamHandler(services.NewChangeStreamService(db, cfg), apiKeySvc, cfg),
+		handlers.NewSnapshotHandler(services.NewSnapshotService(db, cfg), apiKeySvc, cfg),
+		handlers.NewTrashHandler(services.NewTrashService(db, cfg), cfg),
+		handlers.NewTemplateHandler(templateSvc, cfg),
+		handlers.NewBundleHandler(services.NewBundleService(db, cfg, templateSvc), cfg),
+		handlers.NewEnvironmentHandler(services.NewEnvironmentService(db, cfg), apiKeySvc, cfg),
+		handlers.NewProxyHandler(proxySvc, apiKeySvc, cfg),
+		handlers.NewOpenAPIHandler(services.NewOpenAPIService(db, cfg, templateSvc), cfg),
+		handlers.NewHARHandler(services.NewHARService(db, cfg), apiKeySvc, cfg),
+		handlers.NewValidationHandler(services.NewValidationService(db, cfg), apiKeySvc, cfg),
+	)
+
+	return &testServer{t: t, router: router, db: db}
+}
+
+// newUser stores an upgraded user, so plan limits stay out of the way, and
+// returns its id and an access token
+func (s *testServer) newUser(email string) (primitive.ObjectID, string) {
+	s.t.Helper()
+	user := models.User{ID: primitive.NewObjectID(), Email: email, IsVerified: true, IsActive: true, IsUpgraded: true}
+	if err := s.db.Repository(database.Collections.Users).InsertOne(context.Background(), user); err != nil {
+		s.t.Fatal(err)
+	}
+	token, err := utils.GenerateJWT(user.ID.Hex(), testJWTSecret, time.Hour)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return user.ID, token
+}
+
+func (s *testServer) request(method, path, token string, body any) *httptest.ResponseRecorder {
+	return s.requestWithHeaders(method, path, token, body, nil)
+}
+
+func (s *testServer) requestWithHeaders(method, path, token string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	switch b := body.(type) {
+	case nil:
+	case string:
+		buf.WriteString(b)
+	default:
+		if err := json.NewEncoder(&buf).Encode(b); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// create calls a route that must succeed and returns the id in its data
+func (s *testServer) create(method, path, token string, body any) string {
+	s.t.Helper()
+	w := s.request(method, path, token, body)
+	if w.Code >= 300 {
+		s.t.Fatalf("%s %s: %d %s", method, path, w.Code, w.Body.String())
+	}
+	var out struct {
+		Data any `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if data, ok := out.Data.(map[string]any); ok {
+		id, _ := data["id"].(string)
+		return id
+	}
+	return ""
+}
+
+func (s *testServer) addMember(projectID string, userID primitive.ObjectID, role string) {
+	s.t.Helper()
+	pid, _ := primitive.ObjectIDFromHex(projectID)
+	member := models.ProjectMember{UserID: userID, Role: role, AddedAt: time.Now()}
+	if _, err := s.db.Repository(database.Collections.Projects).UpdateOne(context.Background(), bson.M{"_id": pid}, bson.M{"$push": bson.M{"members": member}}); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// firstID reads the id of the first item a list route returns
+func (s *testServer) firstID(path, token string) string {
+	s.t.Helper()
+	w := s.request(http.MethodGet, path, token, nil)
+	var out struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || len(out.Data) == 0 {
+		s.t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+	}
+	return out.Data[0]["id"].(string)
+}
+
+type routeCase struct {
+	method     string
+	route      string // as registered, to check every route is covered
+	path       string
+	body       any
+	minRole    string
+	skipViewer bool // viewer access is not observable: long-lived streams, or the inspector without Redis
+}
+
+func TestProjectRouteAuthorization(t *testing.T) {
+	s := newTestServer(t)
+	ownerID, owner := s.newUser("owner@example.com")
+	viewerID, viewer := s.newUser("viewer@example.com")
+	_, stranger := s.newUser("stranger@example.com")
+
+	// 🧱 A project with one of everything its routes address, shared with a viewer
+	pid := s.create(http.MethodPost, "/api/projects", owner, map[string]any{"name": "Shop"})
+	p := "/api/projects/" + pid
+	cid := s.create(http.MethodPost, p+"/collections", owner, map[string]any{"name": "users", "fields": []any{map[string]any{"name": "n", "type": "string"}}})
+	trashedCid := s.create(http.MethodPost, p+"/collections", owner, map[string]any{"name": "old"})
+	s.create(http.MethodPost, p+"/webhooks", owner, map[string]any{"url": "https://example.com/hook", "events": []string{models.WebhookRecordCreated}})
+	wid := s.firstID(p+"/webhooks", owner)
+	c := "/api/collections/" + cid
+	rid := s.create(http.MethodPost, c+"/records", owner, map[string]any{"n": "a"})
+	s.create(http.MethodPut, c+"/records/"+rid, owner, map[string]any{"n": "b"})
+	trashedRid := s.create(http.MethodPost, c+"/records", owner, map[string]any{"n": "c"})
+	s.create(http.MethodDelete, c+"/records/"+trashedRid, owner, nil)
+	s.create(http.MethodDelete, p+"/collections/"+trashedCid, owner, nil)
+	eid := s.create(http.MethodPost, p+"/endpoints", owner, map[string]any{"method": "GET", "path": "/me", "response": map[string]any{"status": 200}})
+	sid := s.create(http.MethodPost, p+"/snapshots", owner, map[string]any{"name": "before"})
+	s.create(http.MethodPost, p+"/environments", owner, map[string]any{"name": "staging"})
+	s.create(http.MethodPost, p+"/api-keys", owner, map[string]any{"name": "ci"})
+	kid := s.firstID(p+"/api-keys", owner)
+	iid := s.create(http.MethodPost, p+"/members/invitations", owner, map[string]any{"email": "guest@example.com", "role": models.RoleViewer})
+	did := s.firstID(p+"/webhooks/"+wid+"/deliveries", owner)
+	s.addMember(pid, viewerID, models.RoleViewer)
+
+	trashedPid := s.create(http.MethodPost, "/api/projects", owner, map[string]any{"name": "Archive"})
+	s.addMember(trashedPid, viewerID, models.RoleViewer)
+	s.create(http.MethodDelete, "/api/projects/"+trashedPid, owner, nil)
+
+	webhook := map[string]any{"url": "https://example.com/other", "events": []string{models.WebhookRecordUpdated}}
+	endpoint := map[string]any{"method": "POST", "path": "/orders", "response": map[string]any{"status": 201}}
+	har := `{"log":{"entries":[]}}`
+
+	cases := []routeCase{
+		{method: "GET", route: "/api/projects/:pid", path: p, minRole: models.RoleViewer},
+		{method: "PUT", route: "/api/projects/:pid", path: p, body: map[string]any{"name": "Store"}, minRole: models.RoleEditor},
+		{method: "DELETE", route: "/api/projects/:pid", path: p, minRole: models.RoleOwner},
+
+		{method: "GET", route: "/api/projects/:pid/api-keys", path: p + "/api-keys", minRole: models.RoleOwner},
+		{method: "POST", route: "/api/projects/:pid/api-keys", path: p + "/api-keys", body: map[string]any{"name": "x"}, minRole: models.RoleOwner},
+		{method: "DELETE", route: "/api/projects/:pid/api-keys/:kid", path: p + "/api-keys/" + kid, minRole: models.RoleOwner},
+
+		{method: "GET", route: "/api/projects/:pid/audit", path: p + "/audit", minRole: models.RoleOwner},
+		{method: "POST", route: "/api/projects/:pid/changes/ticket", path: p + "/changes/ticket", minRole: models.RoleViewer},
+		{method: "GET", route: "/api/projects/:pid/changes/stream", path: p + "/changes/stream", minRole: models.RoleViewer, skipViewer: true},
+		{method: "GET", route: "/api/projects/:pid/changes/ws", path: p + "/changes/ws", minRole: models.RoleViewer, skipViewer: true},
+
+		{method: "GET", route: "/api/projects/:pid/collections", path: p + "/collections", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/collections", path: p + "/collections", body: map[string]any{"name": "orders"}, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/collections/:cid", path: p + "/collections/" + cid, minRole: models.RoleViewer},
+		{method: "DELETE", route: "/api/projects/:pid/collections/:cid", path: p + "/collections/" + cid, minRole: models.RoleEditor},
+		{method: "PUT", route: "/api/projects/:pid/collections/:cid/revision-limit", path: p + "/collections/" + cid + "/revision-limit", body: map[string]any{"revisionLimit": 5}, minRole: models.RoleEditor},
+		{method: "PUT", route: "/api/projects/:pid/collections/:cid/rules", path: p + "/collections/" + cid + "/rules", body: map[string]any{"rules": []any{}}, minRole: models.RoleEditor},
+
+		{method: "POST", route: "/api/projects/:pid/duplicate", path: p + "/duplicate", body: map[string]any{}, minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/templates", path: p + "/templates", body: map[string]any{"name": "shop"}, minRole: models.RoleOwner},
+		{method: "GET", route: "/api/projects/:pid/export", path: p + "/export", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/import/har", path: p + "/import/har", body: har, minRole: models.RoleEditor},
+
+		{method: "GET", route: "/api/projects/:pid/endpoints", path: p + "/endpoints", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/endpoints", path: p + "/endpoints", body: endpoint, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/endpoints/:eid", path: p + "/endpoints/" + eid, minRole: models.RoleViewer},
+		{method: "PUT", route: "/api/projects/:pid/endpoints/:eid", path: p + "/endpoints/" + eid, body: endpoint, minRole: models.RoleEditor},
+		{method: "DELETE", route: "/api/projects/:pid/endpoints/:eid", path: p + "/endpoints/" + eid, minRole: models.RoleEditor},
+
+		{method: "GET", route: "/api/projects/:pid/environments", path: p + "/environments", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/environments", path: p + "/environments", body: map[string]any{"name": "qa"}, minRole: models.RoleEditor},
+		{method: "DELETE", route: "/api/projects/:pid/environments/:env", path: p + "/environments/staging", minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/environments/promote", path: p + "/environments/promote?from=default&to=staging", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/environments/promote", path: p + "/environments/promote", body: map[string]any{"from": "default", "to": "staging"}, minRole: models.RoleEditor},
+
+		{method: "GET", route: "/api/projects/:pid/members", path: p + "/members", minRole: models.RoleViewer},
+		{method: "PUT", route: "/api/projects/:pid/members/:uid", path: p + "/members/" + ownerID.Hex(), body: map[string]any{"role": models.RoleEditor}, minRole: models.RoleOwner},
+		{method: "DELETE", route: "/api/projects/:pid/members/:uid", path: p + "/members/" + ownerID.Hex(), minRole: models.RoleOwner},
+		{method: "GET", route: "/api/projects/:pid/members/invitations", path: p + "/members/invitations", minRole: models.RoleOwner},
+		{method: "POST", route: "/api/projects/:pid/members/invitations", path: p + "/members/invitations", body: map[string]any{"email": "new@example.com", "role": models.RoleViewer}, minRole: models.RoleOwner},
+		{method: "DELETE", route: "/api/projects/:pid/members/invitations/:iid", path: p + "/members/invitations/" + iid, minRole: models.RoleOwner},
+
+		{method: "GET", route: "/api/projects/:pid/proxy", path: p + "/proxy", minRole: models.RoleViewer},
+		{method: "PUT", route: "/api/projects/:pid/proxy", path: p + "/proxy", body: map[string]any{"mode": models.ProxyModeOff}, minRole: models.RoleEditor},
+
+		{method: "GET", route: "/api/projects/:pid/requests", path: p + "/requests", minRole: models.RoleViewer},
+		{method: "DELETE", route: "/api/projects/:pid/requests", path: p + "/requests", minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/requests/:reqid", path: p + "/requests/unknown", minRole: models.RoleViewer, skipViewer: true},
+		{method: "GET", route: "/api/projects/:pid/requests/stream", path: p + "/requests/stream", minRole: models.RoleViewer, skipViewer: true},
+
+		{method: "GET", route: "/api/projects/:pid/snapshots", path: p + "/snapshots", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/snapshots", path: p + "/snapshots", body: map[string]any{"name": "after"}, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/snapshots/:sid", path: p + "/snapshots/" + sid, minRole: models.RoleViewer},
+		{method: "DELETE", route: "/api/projects/:pid/snapshots/:sid", path: p + "/snapshots/" + sid, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/snapshots/:sid/diff", path: p + "/snapshots/" + sid + "/diff", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/snapshots/:sid/restore", path: p + "/snapshots/" + sid + "/restore", minRole: models.RoleEditor},
+
+		{method: "GET", route: "/api/projects/:pid/trash", path: p + "/trash", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/projects/:pid/trash/collections/:cid/restore", path: p + "/trash/collections/" + trashedCid + "/restore", minRole: models.RoleEditor},
+		{method: "POST", route: "/api/projects/:pid/trash/records/:rid/restore", path: p + "/trash/records/" + trashedRid + "/restore", minRole: models.RoleEditor},
+		{method: "POST", route: "/api/trash/projects/:pid/restore", path: "/api/trash/projects/" + trashedPid + "/restore", minRole: models.RoleOwner},
+
+		{method: "GET", route: "/api/projects/:pid/validation", path: p + "/validation", minRole: models.RoleViewer},
+		{method: "PUT", route: "/api/projects/:pid/validation", path: p + "/validation", body: map[string]any{"mode": models.ValidationObserve}, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/projects/:pid/violations", path: p + "/violations", minRole: models.RoleViewer},
+		{method: "GET", route: "/api/projects/:pid/violations/summary", path: p + "/violations/summary", minRole: models.RoleViewer},
+		{method: "DELETE", route: "/api/projects/:pid/violations", path: p + "/violations", minRole: models.RoleEditor},
+
+		{method: "GET", route: "/api/projects/:pid/webhooks", path: p + "/webhooks", minRole: models.RoleOwner},
+		{method: "POST", route: "/api/projects/:pid/webhooks", path: p + "/webhooks", body: webhook, minRole: models.RoleOwner},
+		{method: "PUT", route: "/api/projects/:pid/webhooks/:wid", path: p + "/webhooks/" + wid, body: webhook, minRole: models.RoleOwner},
+		{method: "DELETE", route: "/api/projects/:pid/webhooks/:wid", path: p + "/webhooks/" + wid, minRole: models.RoleOwner},
+		{method: "GET", route: "/api/projects/:pid/webhooks/:wid/deliveries", path: p + "/webhooks/" + wid + "/deliveries", minRole: models.RoleOwner},
+		{method: "POST", route: "/api/projects/:pid/webhooks/:wid/deliveries/:did/redeliver", path: p + "/webhooks/" + wid + "/deliveries/" + did + "/redeliver", minRole: models.RoleOwner},
+
+		{method: "POST", route: "/api/collections/:collectionId/changes/ticket", path: c + "/changes/ticket", minRole: models.RoleViewer},
+		{method: "GET", route: "/api/collections/:collectionId/changes/stream", path: c + "/changes/stream", minRole: models.RoleViewer, skipViewer: true},
+		{method: "GET", route: "/api/collections/:collectionId/changes/ws", path: c + "/changes/ws", minRole: models.RoleViewer, skipViewer: true},
+		{method: "GET", route: "/api/collections/:collectionId/records", path: c + "/records", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/collections/:collectionId/records", path: c + "/records", body: map[string]any{"n": "d"}, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/collections/:collectionId/records/:rid", path: c + "/records/" + rid, minRole: models.RoleViewer},
+		{method: "PUT", route: "/api/collections/:collectionId/records/:rid", path: c + "/records/" + rid, body: map[string]any{"n": "e"}, minRole: models.RoleEditor},
+		{method: "PATCH", route: "/api/collections/:collectionId/records/:rid", path: c + "/records/" + rid, body: map[string]any{"n": "f"}, minRole: models.RoleEditor},
+		{method: "DELETE", route: "/api/collections/:collectionId/records/:rid", path: c + "/records/" + rid, minRole: models.RoleEditor},
+		{method: "GET", route: "/api/collections/:collectionId/records/:rid/revisions", path: c + "/records/" + rid + "/revisions", minRole: models.RoleViewer},
+		{method: "GET", route: "/api/collections/:collectionId/records/:rid/revisions/:rev", path: c + "/records/" + rid + "/revisions/1", minRole: models.RoleViewer},
+		{method: "GET", route: "/api/collections/:collectionId/records/:rid/revisions/diff", path: c + "/records/" + rid + "/revisions/diff?from=1", minRole: models.RoleViewer},
+		{method: "POST", route: "/api/collections/:collectionId/records/:rid/revisions/:rev/revert", path: c + "/records/" + rid + "/revisions/1/revert", minRole: models.RoleEditor},
+	}
+
+	// 🧭 Every route addressing a project, collection or record must be listed
+	covered := map[string]bool{}
+	for _, tc := range cases {
+		covered[tc.method+" "+tc.route] = true
+	}
+	for _, route := range s.router.Routes() {
+		scoped := strings.Contains(route.Path, ":pid") || strings.Contains(route.Path, ":collectionId")
+		if scoped && !strings.HasPrefix(route.Path, "/mock/") && !covered[route.Method+" "+route.Path] {
+			t.Errorf("%s %s is not covered by the authorization cases", route.Method, route.Path)
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.route, func(t *testing.T) {
+			if w := s.request(tc.method, tc.path, stranger, tc.body); w.Code != http.StatusNotFound {
+				t.Errorf("non-member: got %d, want 404: %s", w.Code, w.Body.String())
+			}
+
+			if tc.minRole == models.RoleViewer {
+				if tc.skipViewer {
+					return
+				}
+				if w := s.request(tc.method, tc.path, viewer, tc.body); w.Code == http.StatusForbidden || w.Code == http.StatusNotFound {
+					t.Errorf("viewer: got %d, want access: %s", w.Code, w.Body.String())
+				}
+				return
+			}
+			if w := s.request(tc.method, tc.path, viewer, tc.body); w.Code != http.StatusForbidden {
+				t.Errorf("viewer: got %d, want 403: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The project is untouched by the rejected calls
+	if w := s.request(http.MethodGet, c+"/records/"+rid, owner, nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"n":"b"`) {
+		t.Errorf("record changed by rejected calls: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/models"
+)
+
+// Browsers cannot set headers on EventSource and WebSocket, so streams accept
+// a ticket in the query string. It works only on the project it was issued
+// for and is no access token.
+func TestChangeStreamTicket(t *testing.T) {
+	s := newTestServer(t)
+	_, owner := s.newUser("owner@example.com")
+	viewerID, viewer := s.newUser("viewer@example.com")
+
+	pid := s.create(http.MethodPost, "/api/projects", owner, map[string]any{"name": "Shop"})
+	other := s.create(http.MethodPost, "/api/projects", owner, map[string]any{"name": "Other"})
+	cid := s.create(http.MethodPost, "/api/projects/"+pid+"/collections", owner, map[string]any{"name": "orders"})
+	s.addMember(pid, viewerID, models.RoleViewer)
+
+	w := s.request(http.MethodPost, "/api/collections/"+cid+"/changes/ticket", viewer, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("issue ticket: %d %s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Data struct {
+			Ticket    string    `json:"ticket"`
+			ExpiresAt time.Time `json:"expiresAt"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	ticket := out.Data.Ticket
+
+	refused := []struct {
+		name  string
+		path  string
+		token string
+	}{
+		{"ticket of another project", "/api/projects/" + other + "/changes/stream?ticket=" + ticket, ""},
+		{"access token as ticket", "/api/projects/" + pid + "/changes/stream?ticket=" + viewer, ""},
+		{"ticket as access token", "/api/projects/" + pid, ticket},
+	}
+	for _, tt := range refused {
+		if w := s.request(http.MethodGet, tt.path, tt.token, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: %d, want 401", tt.name, w.Code)
+		}
+	}
+
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/projects/"+pid+"/changes/stream?ticket="+ticket, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("stream with ticket: %d", resp.StatusCode)
+	}
+
+	rid := s.create(http.MethodPost, "/api/collections/"+cid+"/records", owner, map[string]any{"total": 3})
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		if data, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			if !strings.Contains(data, rid) {
+				t.Errorf("event = %s, want record %s", data, rid)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without an event: %v", lines.Err())
+}
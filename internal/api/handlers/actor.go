@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/dtos"
+)
+
+// actorFrom describes the caller of a request for the audit log. The user and
+// API key are set by the auth middlewares; both are empty on public routes.
+func actorFrom(c *gin.Context) dtos.Actor {
+	return dtos.Actor{
+		UserID:    c.GetString("userId"),
+		APIKeyID:  c.GetString("apiKeyId"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// APIKeyHandler lets project owners manage API keys. Keys cannot manage
+// other keys, so these routes only accept a JWT.
+type APIKeyHandler struct {
+	service *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService, cfg *config.Config) *APIKeyHandler {
+	return &APIKeyHandler{service: service, cfg: cfg}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	keyRoutes := r.Group("/api/projects/:pid/api-keys")
+	keyRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	{
+		keyRoutes.POST("", h.CreateKey)
+		keyRoutes.GET("", h.ListKeys)
+		keyRoutes.DELETE("/:kid", h.RevokeKey)
+	}
+}
+
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req dtos.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	result, err := h.service.CreateKey(c.Param("pid"), userID.(string), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "API key created — store it now, it will not be shown again", result)
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	keys, err := h.service.ListKeys(c.Param("pid"), userID.(string))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "API keys fetched", keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.service.RevokeKey(c.Param("pid"), c.Param("kid"), userID.(string)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "API key revoked", nil)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// AuditHandler exposes the audit log per project and per user
+type AuditHandler struct {
+	service *services.AuditService
+	cfg     *config.Config
+}
+
+func NewAuditHandler(service *services.AuditService, cfg *config.Config) *AuditHandler {
+	return &AuditHandler{service: service, cfg: cfg}
+}
+
+func (h *AuditHandler) RegisterRoutes(r *gin.RouterGroup) {
+	auth := middlewares.AuthMiddleware(h.cfg)
+
+	r.GET("/api/projects/:pid/audit", auth, h.ProjectEvents)
+	r.GET("/api/audit", auth, h.UserEvents)
+}
+
+func (h *AuditHandler) ProjectEvents(c *gin.Context) {
+	var query dtos.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	events, err := h.service.ProjectEvents(c.Param("pid"), c.GetString("userId"), &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Audit events fetched", events)
+}
+
+func (h *AuditHandler) UserEvents(c *gin.Context) {
+	var query dtos.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	events, err := h.service.UserEvents(c.GetString("userId"), &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Audit events fetched", events)
+}
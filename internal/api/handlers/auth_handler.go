@@ -105,7 +105,7 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("userId")
 	if !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -160,7 +160,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.service.Login(&req)
+	tokens, err := h.service.Login(&req, actorFrom(c))
 	if err != nil {
 		responses.JSONError(c, http.StatusUnauthorized, err.Error())
 		return
@@ -198,7 +198,7 @@ func (h *AuthHandler) ResetForgotPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ResetForgotPassword(payload.Token, payload.NewPassword); err != nil {
+	if err := h.service.ResetForgotPassword(payload.Token, payload.NewPassword, actorFrom(c)); err != nil {
 		responses.JSONError(c, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -219,15 +219,14 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// 🔹 Get userID from JWT (middleware must set this)
-	userID, exists := c.Get("userID")
-	if !exists {
+	// 🔹 Make sure the JWT middleware identified the user
+	if _, exists := c.Get("userId"); !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// 🔹 Call service with the authenticated userID
-	if err := h.service.ResetPassword(userID.(string), payload.OldPwd, payload.NewPwd); err != nil {
+	// 🔹 Call service with the authenticated user
+	if err := h.service.ResetPassword(actorFrom(c), payload.OldPwd, payload.NewPwd); err != nil {
 		responses.JSONError(c, http.StatusBadRequest, err.Error())
 		return
 	}
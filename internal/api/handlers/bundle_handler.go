@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// BundleHandler exports projects as bundle files and imports them
+type BundleHandler struct {
+	service *services.BundleService
+	cfg     *config.Config
+}
+
+func NewBundleHandler(service *services.BundleService, cfg *config.Config) *BundleHandler {
+	return &BundleHandler{service: service, cfg: cfg}
+}
+
+func (h *BundleHandler) RegisterRoutes(r *gin.RouterGroup) {
+	auth := middlewares.AuthMiddleware(h.cfg)
+
+	r.GET("/api/projects/:pid/export", auth, h.ExportProject)
+	r.POST("/api/projects/import", auth, h.ImportProject)
+}
+
+// ExportProject sends the bundle as an indented JSON file rather than in the
+// usual envelope, so it can be imported or committed as is
+func (h *BundleHandler) ExportProject(c *gin.Context) {
+	bundle, err := h.service.ExportProject(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(bundle.Project.Name, "-"), "-")
+	if name == "" {
+		name = "project"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.mocknode.json"`, name))
+	c.IndentedJSON(http.StatusOK, bundle)
+}
+
+// ImportProject takes a bundle as the request body. ?onConflict picks what
+// happens when the target context has a project with the same name.
+func (h *BundleHandler) ImportProject(c *gin.Context) {
+	var bundle dtos.ProjectBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid bundle: "+err.Error())
+		return
+	}
+
+	result, err := h.service.ImportProject(actorFrom(c), c.GetHeader(orgHeader), c.Query("onConflict"), &bundle)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Project imported", result)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+	"golang.org/x/net/websocket"
+)
+
+// sseKeepAlive keeps idle Server-Sent Events connections open through proxies
+const sseKeepAlive = 25 * time.Second
+
+// ChangeStreamHandler pushes record changes of a project or a collection over
+// Server-Sent Events or WebSocket.
+type ChangeStreamHandler struct {
+	service *services.ChangeStreamService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewChangeStreamHandler(service *services.ChangeStreamService, apiKeys *services.APIKeyService, cfg *config.Config) *ChangeStreamHandler {
+	return &ChangeStreamHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *ChangeStreamHandler) RegisterRoutes(r *gin.RouterGroup) {
+	auth := middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeReadOnly)
+	streamAuth := h.ticketAuth(auth)
+
+	r.POST("/api/projects/:pid/changes/ticket", auth, h.IssueTicket)
+	r.GET("/api/projects/:pid/changes/stream", streamAuth, h.StreamEvents)
+	r.GET("/api/projects/:pid/changes/ws", streamAuth, h.StreamWebSocket)
+	r.POST("/api/collections/:collectionId/changes/ticket", auth, h.IssueTicket)
+	r.GET("/api/collections/:collectionId/changes/stream", streamAuth, h.StreamEvents)
+	r.GET("/api/collections/:collectionId/changes/ws", streamAuth, h.StreamWebSocket)
+}
+
+// ticketAuth lets browsers, whose EventSource and WebSocket cannot send
+// headers, authenticate with the ticket query parameter. Requests without a
+// ticket go through the usual header authentication.
+func (h *ChangeStreamHandler) ticketAuth(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			auth(c)
+			return
+		}
+
+		userID, err := h.service.TicketUser(ticket, c.Param("pid"), c.Param("collectionId"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userId", userID)
+		c.Next()
+	}
+}
+
+// IssueTicket returns a short-lived ticket for the streams of the project or
+// collection in the route
+func (h *ChangeStreamHandler) IssueTicket(c *gin.Context) {
+	ticket, err := h.service.IssueTicket(c.Param("pid"), c.Param("collectionId"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Ticket issued", ticket)
+}
+
+// StreamEvents sends changes as Server-Sent Events, with the event id set so
+// EventSource clients resume through Last-Event-ID on reconnect
+func (h *ChangeStreamHandler) StreamEvents(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	events, ok := h.subscribe(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return false
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+			return true
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		}
+	})
+}
+
+// StreamWebSocket sends each change as a JSON text message. Clients resume
+// with the lastEventId query parameter.
+func (h *ChangeStreamHandler) StreamWebSocket(c *gin.Context) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	events, ok := h.subscribe(ctx, c)
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		// Messages from the client are ignored; reading only notices it leaving
+		go func() {
+			_, _ = io.Copy(io.Discard, ws)
+			cancel()
+		}()
+
+		for event := range events {
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// subscribe authorizes the caller and opens the stream of the project or
+// collection in the route, writing the error response when it fails
+func (h *ChangeStreamHandler) subscribe(ctx context.Context, c *gin.Context) (<-chan dtos.ChangeEvent, bool) {
+	var filter dtos.ChangeFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid filter")
+		return nil, false
+	}
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil {
+			responses.JSONError(c, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return nil, false
+		}
+		filter.LastEventID = id
+	}
+
+	var events <-chan dtos.ChangeEvent
+	var err error
+	if cid := c.Param("collectionId"); cid != "" {
+		events, err = h.service.SubscribeCollection(ctx, cid, c.GetString("userId"), &filter)
+	} else {
+		events, err = h.service.SubscribeProject(ctx, c.Param("pid"), c.GetString("userId"), &filter)
+	}
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return nil, false
+	}
+
+	return events, true
+}
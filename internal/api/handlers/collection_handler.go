@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/saifwork/mock-service/internal/api/responses"
 	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
 	"github.com/saifwork/mock-service/internal/middlewares"
 	"github.com/saifwork/mock-service/internal/models"
 	"github.com/saifwork/mock-service/internal/services"
@@ -13,26 +14,29 @@ import (
 
 type CollectionHandler struct {
 	service *services.CollectionService
+	apiKeys *services.APIKeyService
 	cfg     *config.Config
 }
 
-func NewCollectionHandler(service *services.CollectionService, cfg *config.Config) *CollectionHandler {
-	return &CollectionHandler{service: service, cfg: cfg}
+func NewCollectionHandler(service *services.CollectionService, apiKeys *services.APIKeyService, cfg *config.Config) *CollectionHandler {
+	return &CollectionHandler{service: service, apiKeys: apiKeys, cfg: cfg}
 }
 
 func (h *CollectionHandler) RegisterRoutes(r *gin.RouterGroup) {
 	collectionRoutes := r.Group("/api/projects/:pid/collections")
-	collectionRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	collectionRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin))
 	{
 		collectionRoutes.POST("", h.CreateCollection)
 		collectionRoutes.GET("", h.GetCollectionsByProject)
 		collectionRoutes.GET("/:cid", h.GetCollectionByID)
 		collectionRoutes.DELETE("/:cid", h.DeleteCollection)
+		collectionRoutes.PUT("/:cid/rules", h.UpdateCollectionRules)
+		collectionRoutes.PUT("/:cid/revision-limit", h.UpdateRevisionLimit)
 	}
 }
 
 func (h *CollectionHandler) CreateCollection(c *gin.Context) {
-	projectID := c.Param("projectId")
+	projectID := c.Param("pid")
 
 	var body struct {
 		Name   string                   `json:"name" binding:"required"`
@@ -44,9 +48,9 @@ func (h *CollectionHandler) CreateCollection(c *gin.Context) {
 		return
 	}
 
-	collection, err := h.service.CreateCollection(projectID, body.Name, body.Fields)
+	collection, err := h.service.CreateCollection(projectID, actorFrom(c), body.Name, body.Fields)
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -54,11 +58,11 @@ func (h *CollectionHandler) CreateCollection(c *gin.Context) {
 }
 
 func (h *CollectionHandler) GetCollectionsByProject(c *gin.Context) {
-	projectID := c.Param("projectId")
+	projectID := c.Param("pid")
 
-	collections, err := h.service.GetCollectionsByProject(projectID)
+	collections, err := h.service.GetCollectionsByProject(projectID, c.GetString("userId"))
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -68,9 +72,9 @@ func (h *CollectionHandler) GetCollectionsByProject(c *gin.Context) {
 func (h *CollectionHandler) GetCollectionByID(c *gin.Context) {
 	cid := c.Param("cid")
 
-	collection, err := h.service.GetCollectionByID(cid)
+	collection, err := h.service.GetCollectionByID(c.Param("pid"), cid, c.GetString("userId"))
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -80,10 +84,46 @@ func (h *CollectionHandler) GetCollectionByID(c *gin.Context) {
 func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
 	cid := c.Param("cid")
 
-	if err := h.service.DeleteCollection(cid); err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+	if err := h.service.DeleteCollection(c.Param("pid"), cid, actorFrom(c)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
 	responses.JSONSuccess(c, http.StatusOK, "Collection deleted", nil)
 }
+
+func (h *CollectionHandler) UpdateCollectionRules(c *gin.Context) {
+	cid := c.Param("cid")
+
+	var body dtos.CollectionRulesRequestDto
+	if err := c.ShouldBindJSON(&body); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	collection, err := h.service.UpdateCollectionRules(c.Param("pid"), cid, actorFrom(c), body.Rules)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collection rules updated", collection)
+}
+
+func (h *CollectionHandler) UpdateRevisionLimit(c *gin.Context) {
+	cid := c.Param("cid")
+
+	var body dtos.RevisionLimitRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	collection, err := h.service.UpdateRevisionLimit(c.Param("pid"), cid, actorFrom(c), body.RevisionLimit)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collection revision limit updated", collection)
+}
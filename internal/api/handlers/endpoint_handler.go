@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+type EndpointHandler struct {
+	service *services.EndpointService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewEndpointHandler(service *services.EndpointService, apiKeys *services.APIKeyService, cfg *config.Config) *EndpointHandler {
+	return &EndpointHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *EndpointHandler) RegisterRoutes(r *gin.RouterGroup) {
+	endpointRoutes := r.Group("/api/projects/:pid/endpoints")
+	endpointRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin))
+	{
+		endpointRoutes.POST("", h.CreateEndpoint)
+		endpointRoutes.GET("", h.GetEndpoints)
+		endpointRoutes.GET("/:eid", h.GetEndpointByID)
+		endpointRoutes.PUT("/:eid", h.UpdateEndpoint)
+		endpointRoutes.DELETE("/:eid", h.DeleteEndpoint)
+	}
+}
+
+func (h *EndpointHandler) CreateEndpoint(c *gin.Context) {
+	var req dtos.EndpointRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	endpoint, err := h.service.CreateEndpoint(c.Param("pid"), userID.(string), req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Endpoint created", endpoint)
+}
+
+func (h *EndpointHandler) GetEndpoints(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	endpoints, err := h.service.GetEndpointsByProject(c.Param("pid"), userID.(string))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Endpoints fetched", endpoints)
+}
+
+func (h *EndpointHandler) GetEndpointByID(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	endpoint, err := h.service.GetEndpointByID(c.Param("pid"), c.Param("eid"), userID.(string))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusNotFound), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Endpoint fetched", endpoint)
+}
+
+func (h *EndpointHandler) UpdateEndpoint(c *gin.Context) {
+	var req dtos.EndpointRequestDto
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(c.Param("pid"), c.Param("eid"), userID.(string), req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Endpoint updated", endpoint)
+}
+
+func (h *EndpointHandler) DeleteEndpoint(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.service.DeleteEndpoint(c.Param("pid"), c.Param("eid"), userID.(string)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Endpoint deleted", nil)
+}
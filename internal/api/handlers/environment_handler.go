@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// EnvironmentHandler manages the environments of a project and promotes
+// data between them. Admin API keys can use it from CI.
+type EnvironmentHandler struct {
+	service *services.EnvironmentService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewEnvironmentHandler(service *services.EnvironmentService, apiKeys *services.APIKeyService, cfg *config.Config) *EnvironmentHandler {
+	return &EnvironmentHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *EnvironmentHandler) RegisterRoutes(r *gin.RouterGroup) {
+	environmentRoutes := r.Group("/api/projects/:pid/environments")
+	environmentRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin))
+	{
+		environmentRoutes.GET("", h.ListEnvironments)
+		environmentRoutes.POST("", h.CreateEnvironment)
+		environmentRoutes.DELETE("/:env", h.DeleteEnvironment)
+		environmentRoutes.GET("/promote", h.PreviewPromotion)
+		environmentRoutes.POST("/promote", h.Promote)
+	}
+}
+
+func (h *EnvironmentHandler) ListEnvironments(c *gin.Context) {
+	environments, err := h.service.ListEnvironments(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Environments fetched", environments)
+}
+
+func (h *EnvironmentHandler) CreateEnvironment(c *gin.Context) {
+	var req dtos.CreateEnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	environments, err := h.service.CreateEnvironment(c.Param("pid"), actorFrom(c), req.Name)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Environment created", environments)
+}
+
+func (h *EnvironmentHandler) DeleteEnvironment(c *gin.Context) {
+	if err := h.service.DeleteEnvironment(c.Param("pid"), actorFrom(c), c.Param("env")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Environment deleted", nil)
+}
+
+func (h *EnvironmentHandler) PreviewPromotion(c *gin.Context) {
+	var req dtos.PromoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	diff, err := h.service.PreviewPromotion(c.Param("pid"), c.GetString("userId"), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Promotion preview", diff)
+}
+
+func (h *EnvironmentHandler) Promote(c *gin.Context) {
+	var req dtos.PromoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	diff, err := h.service.Promote(c.Param("pid"), actorFrom(c), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Environment promoted", diff)
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// errorStatus picks the HTTP status for a service error: the status carried
+// by an AccessError (404, 403 or 409), otherwise fallback.
+func errorStatus(err error, fallback int) int {
+	var accessErr *services.AccessError
+	if errors.As(err, &accessErr) {
+		return accessErr.Status
+	}
+	return fallback
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// HARHandler imports browser DevTools recordings into the custom endpoints of a project
+type HARHandler struct {
+	service *services.HARService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewHARHandler(service *services.HARService, apiKeys *services.APIKeyService, cfg *config.Config) *HARHandler {
+	return &HARHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *HARHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/api/projects/:pid/import/har", middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin), h.ImportHAR)
+}
+
+// ImportHAR takes the HAR file as the raw request body
+func (h *HARHandler) ImportHAR(c *gin.Context) {
+	var query dtos.HARImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	raw, err := c.GetRawData()
+	if err != nil || len(raw) == 0 {
+		responses.JSONError(c, http.StatusBadRequest, "The HAR file must be sent as the request body")
+		return
+	}
+
+	result, err := h.service.ImportHAR(c.Param("pid"), actorFrom(c), raw, &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "HAR imported", result)
+}
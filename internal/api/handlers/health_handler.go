@@ -7,14 +7,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/saifwork/mock-service/internal/api/responses"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
 )
 
 type HealthHandler struct {
-	db *mongo.Client
+	db store.Store
 }
 
-func NewHealthHandler(db *mongo.Client) *HealthHandler {
+func NewHealthHandler(db store.Store) *HealthHandler {
 	return &HealthHandler{db: db}
 }
 
@@ -27,13 +27,14 @@ func (h *HealthHandler) CheckHealth(c *gin.Context) {
 	defer cancel()
 
 	dbStatus := "ok"
-	if err := h.db.Ping(ctx, nil); err != nil {
+	if err := h.db.Ping(ctx); err != nil {
 		dbStatus = "down"
 	}
 
 	responses.JSONSuccess(c, http.StatusOK, "Service health status", gin.H{
 		"service": "up",
 		"db":      dbStatus,
+		"driver":  h.db.Driver(),
 		"time":    time.Now().Format(time.RFC3339),
 	})
 }
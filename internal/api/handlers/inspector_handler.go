@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// InspectorHandler exposes the captured mock requests of a project.
+type InspectorHandler struct {
+	service *services.InspectorService
+	cfg     *config.Config
+}
+
+func NewInspectorHandler(service *services.InspectorService, cfg *config.Config) *InspectorHandler {
+	return &InspectorHandler{service: service, cfg: cfg}
+}
+
+func (h *InspectorHandler) RegisterRoutes(r *gin.RouterGroup) {
+	requestRoutes := r.Group("/api/projects/:pid/requests")
+	requestRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	{
+		requestRoutes.GET("", h.ListRequests)
+		requestRoutes.GET("/stream", h.StreamRequests)
+		requestRoutes.GET("/:reqid", h.GetRequest)
+		requestRoutes.DELETE("", h.ClearRequests)
+	}
+}
+
+func (h *InspectorHandler) ListRequests(c *gin.Context) {
+	var filter dtos.RequestLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid filter")
+		return
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	result, err := h.service.ListRequests(c.Param("pid"), userID.(string), filter)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Requests fetched", result)
+}
+
+func (h *InspectorHandler) GetRequest(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	entry, err := h.service.GetRequest(c.Param("pid"), c.Param("reqid"), userID.(string))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusNotFound), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Request fetched", entry)
+}
+
+func (h *InspectorHandler) ClearRequests(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := h.service.ClearRequests(c.Param("pid"), userID.(string)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Requests cleared", nil)
+}
+
+// StreamRequests tails captured requests as Server-Sent Events
+func (h *InspectorHandler) StreamRequests(c *gin.Context) {
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	entries, err := h.service.TailRequests(c.Request.Context(), c.Param("pid"), userID.(string))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Stream(func(w io.Writer) bool {
+		entry, ok := <-entries
+		if !ok {
+			return false
+		}
+		c.SSEvent("request", entry)
+		return true
+	})
+}
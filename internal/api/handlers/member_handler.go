@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// MemberHandler serves project collaborators and invitations
+type MemberHandler struct {
+	service *services.MemberService
+	cfg     *config.Config
+}
+
+func NewMemberHandler(service *services.MemberService, cfg *config.Config) *MemberHandler {
+	return &MemberHandler{service: service, cfg: cfg}
+}
+
+func (h *MemberHandler) RegisterRoutes(r *gin.RouterGroup) {
+	memberRoutes := r.Group("/api/projects/:pid/members")
+	memberRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	{
+		memberRoutes.GET("", h.ListMembers)
+		memberRoutes.POST("/invitations", h.InviteMember)
+		memberRoutes.GET("/invitations", h.ListInvitations)
+		memberRoutes.DELETE("/invitations/:iid", h.CancelInvitation)
+		memberRoutes.PUT("/:uid", h.UpdateMemberRole)
+		memberRoutes.DELETE("/:uid", h.RemoveMember)
+	}
+
+	invitationRoutes := r.Group("/api/invitations")
+	invitationRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	{
+		invitationRoutes.GET("", h.MyInvitations)
+		invitationRoutes.POST("/:token/accept", h.AcceptInvitation)
+		invitationRoutes.POST("/:token/decline", h.DeclineInvitation)
+	}
+}
+
+func (h *MemberHandler) ListMembers(c *gin.Context) {
+	members, err := h.service.ListMembers(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Members fetched", members)
+}
+
+func (h *MemberHandler) InviteMember(c *gin.Context) {
+	var req dtos.InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	invitation, err := h.service.InviteMember(c.Param("pid"), c.GetString("userId"), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Invitation sent", invitation)
+}
+
+func (h *MemberHandler) ListInvitations(c *gin.Context) {
+	invitations, err := h.service.ListInvitations(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Invitations fetched", invitations)
+}
+
+func (h *MemberHandler) CancelInvitation(c *gin.Context) {
+	if err := h.service.CancelInvitation(c.Param("pid"), c.Param("iid"), c.GetString("userId")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Invitation cancelled", nil)
+}
+
+func (h *MemberHandler) UpdateMemberRole(c *gin.Context) {
+	var req dtos.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(c.Param("pid"), c.Param("uid"), c.GetString("userId"), req.Role); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Member role updated", nil)
+}
+
+func (h *MemberHandler) RemoveMember(c *gin.Context) {
+	if err := h.service.RemoveMember(c.Param("pid"), c.Param("uid"), c.GetString("userId")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Member removed", nil)
+}
+
+func (h *MemberHandler) MyInvitations(c *gin.Context) {
+	invitations, err := h.service.MyInvitations(c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Invitations fetched", invitations)
+}
+
+func (h *MemberHandler) AcceptInvitation(c *gin.Context) {
+	project, err := h.service.AcceptInvitation(c.Param("token"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Invitation accepted", project)
+}
+
+func (h *MemberHandler) DeclineInvitation(c *gin.Context) {
+	if err := h.service.DeclineInvitation(c.Param("token"), c.GetString("userId")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Invitation declined", nil)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+	"github.com/saifwork/mock-service/internal/utils"
+)
+
+// MockHandler serves the public mock API of a project under /mock/:pid.
+type MockHandler struct {
+	service   *services.MockService
+	inspector *services.InspectorService
+	cfg       *config.Config
+}
+
+func NewMockHandler(service *services.MockService, inspector *services.InspectorService, cfg *config.Config) *MockHandler {
+	return &MockHandler{service: service, inspector: inspector, cfg: cfg}
+}
+
+func (h *MockHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.Any("/mock/:pid/*path", h.Serve)
+}
+
+func (h *MockHandler) Serve(c *gin.Context) {
+	start := time.Now()
+
+	rawBody, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Unable to read request body")
+		return
+	}
+	var body any
+	if len(rawBody) > 0 {
+		_ = json.Unmarshal(rawBody, &body)
+	}
+
+	req := &services.MockRequest{
+		Method:   c.Request.Method,
+		Path:     c.Param("path"),
+		Query:    c.Request.URL.Query(),
+		Headers:  c.Request.Header,
+		Body:     body,
+		RawBody:  rawBody,
+		ClientIP: c.ClientIP(),
+		Context:  c.Request.Context(),
+	}
+	defer h.capture(c, req, start)
+
+	resp, err := h.service.Handle(c.Param("pid"), req)
+	if err != nil {
+		var mockErr *services.MockError
+		if errors.As(err, &mockErr) {
+			responses.JSONError(c, mockErr.Status, mockErr.Message)
+			return
+		}
+		responses.JSONError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeMockResponse(c, resp)
+}
+
+// capture hands the finished request over to the inspector log. Requests to
+// unknown projects are not kept, so arbitrary ids cannot fill the log store.
+func (h *MockHandler) capture(c *gin.Context, req *services.MockRequest, start time.Time) {
+	if !req.Resolved {
+		return
+	}
+
+	headers := make(map[string]string, len(c.Request.Header))
+	for k, v := range c.Request.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+
+	id, _ := utils.GenerateRandomID(8)
+	entry := &dtos.RequestLogEntry{
+		ID:        id,
+		ProjectID: c.Param("pid"),
+		Method:    c.Request.Method,
+		Path:      c.Param("path"),
+		Query:     c.Request.URL.RawQuery,
+		Headers:   headers,
+		Body:      string(req.RawBody),
+		Status:    c.Writer.Status(),
+		LatencyMs: time.Since(start).Milliseconds(),
+		ClientIP:  c.ClientIP(),
+		Timestamp: start.UTC().Format(time.RFC3339Nano),
+	}
+
+	go h.inspector.Record(entry)
+}
+
+// writeMockResponse applies delay and headers, then writes the configured body.
+// String bodies with a non-JSON Content-Type are sent verbatim.
+func writeMockResponse(c *gin.Context, resp *models.MockResponse) {
+	if resp.DelayMs > 0 {
+		select {
+		case <-time.After(time.Duration(resp.DelayMs) * time.Millisecond):
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+
+	for k, v := range resp.Headers {
+		c.Header(k, v)
+	}
+
+	if resp.Body == nil {
+		c.Status(resp.Status)
+		return
+	}
+
+	contentType := c.Writer.Header().Get("Content-Type")
+	if str, ok := resp.Body.(string); ok && contentType != "" && !strings.Contains(contentType, "json") {
+		c.Data(resp.Status, contentType, []byte(str))
+		return
+	}
+
+	c.JSON(resp.Status, resp.Body)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// OpenAPIHandler generates projects from OpenAPI specifications
+type OpenAPIHandler struct {
+	service *services.OpenAPIService
+	cfg     *config.Config
+}
+
+func NewOpenAPIHandler(service *services.OpenAPIService, cfg *config.Config) *OpenAPIHandler {
+	return &OpenAPIHandler{service: service, cfg: cfg}
+}
+
+func (h *OpenAPIHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/api/projects/import/openapi", middlewares.AuthMiddleware(h.cfg), h.ImportOpenAPI)
+}
+
+// ImportOpenAPI takes the spec, YAML or JSON, as the raw request body
+func (h *OpenAPIHandler) ImportOpenAPI(c *gin.Context) {
+	var query dtos.OpenAPIImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	raw, err := c.GetRawData()
+	if err != nil || len(raw) == 0 {
+		responses.JSONError(c, http.StatusBadRequest, "The spec must be sent as the request body")
+		return
+	}
+
+	result, err := h.service.ImportOpenAPI(actorFrom(c), c.GetHeader(orgHeader), raw, &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Project generated", result)
+}
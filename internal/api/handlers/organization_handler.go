@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// OrganizationHandler serves team workspaces and their members
+type OrganizationHandler struct {
+	service *services.OrganizationService
+	cfg     *config.Config
+}
+
+func NewOrganizationHandler(service *services.OrganizationService, cfg *config.Config) *OrganizationHandler {
+	return &OrganizationHandler{service: service, cfg: cfg}
+}
+
+func (h *OrganizationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	orgRoutes := r.Group("/api/orgs")
+	orgRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	{
+		orgRoutes.POST("", h.CreateOrganization)
+		orgRoutes.GET("", h.GetOrganizations)
+		orgRoutes.GET("/:oid", h.GetOrganization)
+		orgRoutes.PUT("/:oid", h.RenameOrganization)
+		orgRoutes.DELETE("/:oid", h.DeleteOrganization)
+		orgRoutes.PUT("/:oid/plan", h.UpdatePlan)
+
+		orgRoutes.GET("/:oid/members", h.ListMembers)
+		orgRoutes.POST("/:oid/members", h.AddMember)
+		orgRoutes.PUT("/:oid/members/:uid", h.UpdateMemberRole)
+		orgRoutes.DELETE("/:oid/members/:uid", h.RemoveMember)
+	}
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req dtos.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	org, err := h.service.CreateOrganization(c.GetString("userId"), req.Name)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Organization created", org)
+}
+
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	orgs, err := h.service.GetUserOrganizations(c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Organizations fetched", orgs)
+}
+
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	org, err := h.service.GetOrganization(c.Param("oid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Organization fetched", org)
+}
+
+func (h *OrganizationHandler) RenameOrganization(c *gin.Context) {
+	var req dtos.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	if err := h.service.RenameOrganization(c.Param("oid"), c.GetString("userId"), req.Name); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Organization updated", nil)
+}
+
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	if err := h.service.DeleteOrganization(c.Param("oid"), c.GetString("userId")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Organization deleted", nil)
+}
+
+func (h *OrganizationHandler) UpdatePlan(c *gin.Context) {
+	var req dtos.UpdateOrgPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	if err := h.service.UpdatePlan(c.Param("oid"), c.GetString("userId"), req.Plan); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Organization plan updated", nil)
+}
+
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	members, err := h.service.ListMembers(c.Param("oid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Members fetched", members)
+}
+
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	var req dtos.AddOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	member, err := h.service.AddMember(c.Param("oid"), c.GetString("userId"), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Member added", member)
+}
+
+func (h *OrganizationHandler) UpdateMemberRole(c *gin.Context) {
+	var req dtos.UpdateOrgMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(c.Param("oid"), c.Param("uid"), c.GetString("userId"), req.Role); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Member role updated", nil)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	if err := h.service.RemoveMember(c.Param("oid"), c.Param("uid"), c.GetString("userId")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Member removed", nil)
+}
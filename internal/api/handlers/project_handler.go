@@ -10,6 +10,10 @@ import (
 	"github.com/saifwork/mock-service/internal/services"
 )
 
+// orgHeader selects the organization context of project listing and creation;
+// without it requests act in the caller's personal context.
+const orgHeader = "X-Org-ID"
+
 type ProjectHandler struct {
 	service *services.ProjectService
 	cfg     *config.Config
@@ -42,15 +46,15 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("userID")
+	_, exists := c.Get("userId")
 	if !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	project, err := h.service.CreateProject(userID.(string), payload.Name, payload.Description)
+	project, err := h.service.CreateProject(actorFrom(c), c.GetHeader(orgHeader), payload.Name, payload.Description)
 	if err != nil {
-		responses.JSONError(c, http.StatusInternalServerError, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -59,7 +63,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 
 func (h *ProjectHandler) GetProjectByID(c *gin.Context) {
 	projectID := c.Param("pid")
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("userId")
 	if !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -67,7 +71,7 @@ func (h *ProjectHandler) GetProjectByID(c *gin.Context) {
 
 	project, err := h.service.GetProjectByID(projectID, userID.(string))
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -75,15 +79,15 @@ func (h *ProjectHandler) GetProjectByID(c *gin.Context) {
 }
 
 func (h *ProjectHandler) GetProjects(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := c.Get("userId")
 	if !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	projects, err := h.service.GetUserProjects(userID.(string))
+	projects, err := h.service.GetUserProjects(userID.(string), c.GetHeader(orgHeader))
 	if err != nil {
-		responses.JSONError(c, http.StatusInternalServerError, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -103,14 +107,14 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("userID")
+	_, exists := c.Get("userId")
 	if !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	if err := h.service.UpdateProject(projectID, userID.(string), payload.Name, payload.Description); err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+	if err := h.service.UpdateProject(projectID, actorFrom(c), payload.Name, payload.Description); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -119,14 +123,14 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 
 func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	projectID := c.Param("pid")
-	userID, exists := c.Get("userID")
+	_, exists := c.Get("userId")
 	if !exists {
 		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	if err := h.service.DeleteProject(projectID, userID.(string)); err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+	if err := h.service.DeleteProject(projectID, actorFrom(c)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
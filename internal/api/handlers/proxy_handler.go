@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// ProxyHandler switches a project between serving its mocks, recording an
+// upstream API and replaying the recordings. Admin API keys can flip modes from CI.
+type ProxyHandler struct {
+	service *services.ProxyService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewProxyHandler(service *services.ProxyService, apiKeys *services.APIKeyService, cfg *config.Config) *ProxyHandler {
+	return &ProxyHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *ProxyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	proxyRoutes := r.Group("/api/projects/:pid/proxy")
+	proxyRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin))
+	{
+		proxyRoutes.GET("", h.GetProxy)
+		proxyRoutes.PUT("", h.ConfigureProxy)
+	}
+}
+
+func (h *ProxyHandler) GetProxy(c *gin.Context) {
+	proxy, err := h.service.GetProxy(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Proxy fetched", proxy)
+}
+
+func (h *ProxyHandler) ConfigureProxy(c *gin.Context) {
+	var req dtos.ProxyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	proxy, err := h.service.ConfigureProxy(c.Param("pid"), actorFrom(c), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Proxy updated", proxy)
+}
@@ -6,28 +6,37 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/saifwork/mock-service/internal/api/responses"
 	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
 	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
 	"github.com/saifwork/mock-service/internal/services"
+	"github.com/saifwork/mock-service/internal/utils"
 )
 
 type RecordHandler struct {
 	service *services.RecordService
+	apiKeys *services.APIKeyService
 	cfg     *config.Config
 }
 
-func NewRecordHandler(service *services.RecordService, cfg *config.Config) *RecordHandler {
-	return &RecordHandler{service: service, cfg: cfg}
+func NewRecordHandler(service *services.RecordService, apiKeys *services.APIKeyService, cfg *config.Config) *RecordHandler {
+	return &RecordHandler{service: service, apiKeys: apiKeys, cfg: cfg}
 }
 
 func (h *RecordHandler) RegisterRoutes(r *gin.RouterGroup) {
 	recordRoutes := r.Group("api/collections/:collectionId/records")
-	recordRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	recordRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeReadWrite))
 	{
 		recordRoutes.POST("", h.CreateRecord)
 		recordRoutes.GET("", h.GetRecordsByCollection)
 		recordRoutes.GET("/:rid", h.GetRecordByID)
 		recordRoutes.PUT("/:rid", h.UpdateRecord)
+		recordRoutes.PATCH("/:rid", h.PatchRecord)
 		recordRoutes.DELETE("/:rid", h.DeleteRecord)
+		recordRoutes.GET("/:rid/revisions", h.ListRevisions)
+		recordRoutes.GET("/:rid/revisions/diff", h.DiffRevisions)
+		recordRoutes.GET("/:rid/revisions/:rev", h.GetRevision)
+		recordRoutes.POST("/:rid/revisions/:rev/revert", h.RevertRecord)
 	}
 }
 
@@ -40,21 +49,22 @@ func (h *RecordHandler) CreateRecord(c *gin.Context) {
 		return
 	}
 
-	record, err := h.service.CreateRecord(collectionID, data)
+	record, err := h.service.CreateRecord(collectionID, c.Query("env"), actorFrom(c), data)
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
+	setRecordETag(c, record)
 	responses.JSONSuccess(c, http.StatusCreated, "Record created", record)
 }
 
 func (h *RecordHandler) GetRecordsByCollection(c *gin.Context) {
 	collectionID := c.Param("collectionId")
 
-	records, err := h.service.GetRecordsByCollection(collectionID)
+	records, err := h.service.GetRecordsByCollection(collectionID, c.Query("env"), c.GetString("userId"))
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
@@ -64,12 +74,16 @@ func (h *RecordHandler) GetRecordsByCollection(c *gin.Context) {
 func (h *RecordHandler) GetRecordByID(c *gin.Context) {
 	rid := c.Param("rid")
 
-	record, err := h.service.GetRecordByID(rid)
+	record, err := h.service.GetRecordByID(c.Param("collectionId"), rid, c.GetString("userId"))
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+	if notModified(c, record) {
 		return
 	}
 
+	setRecordETag(c, record)
 	responses.JSONSuccess(c, http.StatusOK, "Record fetched", record)
 }
 
@@ -82,22 +96,114 @@ func (h *RecordHandler) UpdateRecord(c *gin.Context) {
 		return
 	}
 
-	record, err := h.service.UpdateRecord(rid, data)
+	record, err := h.service.UpdateRecord(c.Param("collectionId"), rid, actorFrom(c), data, c.GetHeader("If-Match"))
 	if err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
+	setRecordETag(c, record)
+	responses.JSONSuccess(c, http.StatusOK, "Record updated", record)
+}
+
+func (h *RecordHandler) PatchRecord(c *gin.Context) {
+	rid := c.Param("rid")
+
+	var data map[string]interface{}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	record, err := h.service.PatchRecord(c.Param("collectionId"), rid, actorFrom(c), data, c.GetHeader("If-Match"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	setRecordETag(c, record)
 	responses.JSONSuccess(c, http.StatusOK, "Record updated", record)
 }
 
 func (h *RecordHandler) DeleteRecord(c *gin.Context) {
 	rid := c.Param("rid")
 
-	if err := h.service.DeleteRecord(rid); err != nil {
-		responses.JSONError(c, http.StatusBadRequest, err.Error())
+	if err := h.service.DeleteRecord(c.Param("collectionId"), rid, actorFrom(c), c.GetHeader("If-Match")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
 		return
 	}
 
 	responses.JSONSuccess(c, http.StatusOK, "Record deleted", nil)
 }
+
+func (h *RecordHandler) ListRevisions(c *gin.Context) {
+	rid := c.Param("rid")
+
+	revisions, err := h.service.ListRevisions(c.Param("collectionId"), rid, c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Revisions fetched", revisions)
+}
+
+func (h *RecordHandler) GetRevision(c *gin.Context) {
+	rid := c.Param("rid")
+
+	revision, err := h.service.GetRevision(c.Param("collectionId"), rid, c.Param("rev"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Revision fetched", revision)
+}
+
+func (h *RecordHandler) DiffRevisions(c *gin.Context) {
+	rid := c.Param("rid")
+
+	var query dtos.RevisionDiffQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	diff, err := h.service.DiffRevisions(c.Param("collectionId"), rid, c.GetString("userId"), &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Revision diff fetched", diff)
+}
+
+func (h *RecordHandler) RevertRecord(c *gin.Context) {
+	rid := c.Param("rid")
+
+	record, err := h.service.RevertRecord(c.Param("collectionId"), rid, c.Param("rev"), actorFrom(c))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	setRecordETag(c, record)
+	responses.JSONSuccess(c, http.StatusOK, "Record reverted", record)
+}
+
+// setRecordETag sends the version of a record as its ETag, for clients to
+// send back in If-Match or If-None-Match
+func setRecordETag(c *gin.Context, record *models.Record) {
+	c.Header("ETag", utils.VersionETag(record.Version))
+}
+
+// notModified answers 304 when If-None-Match lists the current version of the record
+func notModified(c *gin.Context, record *models.Record) bool {
+	etag := utils.VersionETag(record.Version)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch == "" || !utils.ETagMatches(ifNoneMatch, etag, true) {
+		return false
+	}
+	c.Header("ETag", etag)
+	c.Status(http.StatusNotModified)
+	return true
+}
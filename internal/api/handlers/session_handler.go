@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// sandboxUser is passed as the acting user on session routes; services only
+// accept it for sandbox projects.
+const sandboxUser = ""
+
+// sandboxActor is the anonymous caller of a session route, for the audit log
+func sandboxActor(c *gin.Context) dtos.Actor {
+	actor := actorFrom(c)
+	actor.UserID = sandboxUser
+	return actor
+}
+
+// SessionHandler exposes anonymous sandbox sessions. The session id in the
+// URL is the only credential needed to manage the sandbox project.
+type SessionHandler struct {
+	service       *services.SessionService
+	collectionSvc *services.CollectionService
+	recordSvc     *services.RecordService
+	cfg           *config.Config
+}
+
+func NewSessionHandler(service *services.SessionService, collectionSvc *services.CollectionService, recordSvc *services.RecordService, cfg *config.Config) *SessionHandler {
+	return &SessionHandler{service: service, collectionSvc: collectionSvc, recordSvc: recordSvc, cfg: cfg}
+}
+
+func (h *SessionHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/sessions", h.CreateSession)
+
+	sessionRoutes := r.Group("/sessions/:sid")
+	sessionRoutes.Use(h.resolveSession)
+	{
+		sessionRoutes.GET("", h.GetSession)
+		sessionRoutes.DELETE("", h.DeleteSession)
+		sessionRoutes.POST("/convert", middlewares.AuthMiddleware(h.cfg), h.ConvertSession)
+
+		sessionRoutes.POST("/collections", h.CreateCollection)
+		sessionRoutes.GET("/collections", h.GetCollections)
+		sessionRoutes.GET("/collections/:cid", h.GetCollection)
+		sessionRoutes.DELETE("/collections/:cid", h.DeleteCollection)
+		sessionRoutes.PUT("/collections/:cid/rules", h.UpdateCollectionRules)
+
+		sessionRoutes.POST("/collections/:cid/records", h.CreateRecord)
+		sessionRoutes.GET("/collections/:cid/records", h.GetRecords)
+		sessionRoutes.GET("/collections/:cid/records/:rid", h.GetRecord)
+		sessionRoutes.PUT("/collections/:cid/records/:rid", h.UpdateRecord)
+		sessionRoutes.DELETE("/collections/:cid/records/:rid", h.DeleteRecord)
+	}
+}
+
+// resolveSession maps :sid to its sandbox project and stores it as "projectId"
+func (h *SessionHandler) resolveSession(c *gin.Context) {
+	projectID, err := h.service.ResolveProject(c.Param("sid"))
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, services.ErrSessionsUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		responses.JSONError(c, status, err.Error())
+		c.Abort()
+		return
+	}
+
+	c.Set("projectId", projectID)
+	c.Next()
+}
+
+func (h *SessionHandler) CreateSession(c *gin.Context) {
+	var req dtos.CreateSessionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+			return
+		}
+	}
+
+	session, err := h.service.CreateSession(&req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrSessionsUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		responses.JSONError(c, status, err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Session created", session)
+}
+
+func (h *SessionHandler) GetSession(c *gin.Context) {
+	meta, err := h.service.GetSession(c.Param("sid"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusNotFound), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Session fetched", meta)
+}
+
+func (h *SessionHandler) DeleteSession(c *gin.Context) {
+	if err := h.service.DeleteSession(c.Param("sid")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Session deleted", nil)
+}
+
+func (h *SessionHandler) ConvertSession(c *gin.Context) {
+	var req dtos.ConvertSessionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+			return
+		}
+	}
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		responses.JSONError(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	project, err := h.service.ConvertSession(c.Param("sid"), userID.(string), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Session converted into project", project)
+}
+
+func (h *SessionHandler) CreateCollection(c *gin.Context) {
+	var body struct {
+		Name   string                   `json:"name" binding:"required"`
+		Fields []models.FieldDefinition `json:"fields"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	collection, err := h.collectionSvc.CreateCollection(c.GetString("projectId"), sandboxActor(c), body.Name, body.Fields)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Collection created", collection)
+}
+
+func (h *SessionHandler) GetCollections(c *gin.Context) {
+	collections, err := h.collectionSvc.GetCollectionsByProject(c.GetString("projectId"), sandboxUser)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collections fetched", collections)
+}
+
+func (h *SessionHandler) GetCollection(c *gin.Context) {
+	collection, ok := h.sessionCollection(c)
+	if !ok {
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collection fetched", collection)
+}
+
+func (h *SessionHandler) DeleteCollection(c *gin.Context) {
+	collection, ok := h.sessionCollection(c)
+	if !ok {
+		return
+	}
+
+	if err := h.collectionSvc.DeleteCollection(c.GetString("projectId"), collection.ID.Hex(), sandboxActor(c)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collection deleted", nil)
+}
+
+func (h *SessionHandler) UpdateCollectionRules(c *gin.Context) {
+	collection, ok := h.sessionCollection(c)
+	if !ok {
+		return
+	}
+
+	var body dtos.CollectionRulesRequestDto
+	if err := c.ShouldBindJSON(&body); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	updated, err := h.collectionSvc.UpdateCollectionRules(c.GetString("projectId"), collection.ID.Hex(), sandboxActor(c), body.Rules)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collection rules updated", updated)
+}
+
+func (h *SessionHandler) CreateRecord(c *gin.Context) {
+	collection, ok := h.sessionCollection(c)
+	if !ok {
+		return
+	}
+
+	var data map[string]interface{}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	record, err := h.recordSvc.CreateRecord(collection.ID.Hex(), "", sandboxActor(c), data)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	setRecordETag(c, record)
+	responses.JSONSuccess(c, http.StatusCreated, "Record created", record)
+}
+
+func (h *SessionHandler) GetRecords(c *gin.Context) {
+	collection, ok := h.sessionCollection(c)
+	if !ok {
+		return
+	}
+
+	records, err := h.recordSvc.GetRecordsByCollection(collection.ID.Hex(), "", sandboxUser)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Records fetched", records)
+}
+
+func (h *SessionHandler) GetRecord(c *gin.Context) {
+	record, ok := h.sessionRecord(c)
+	if !ok || notModified(c, record) {
+		return
+	}
+
+	setRecordETag(c, record)
+	responses.JSONSuccess(c, http.StatusOK, "Record fetched", record)
+}
+
+func (h *SessionHandler) UpdateRecord(c *gin.Context) {
+	record, ok := h.sessionRecord(c)
+	if !ok {
+		return
+	}
+
+	var data map[string]interface{}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	updated, err := h.recordSvc.UpdateRecord(record.CollectionID.Hex(), record.ID.Hex(), sandboxActor(c), data, c.GetHeader("If-Match"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	setRecordETag(c, updated)
+	responses.JSONSuccess(c, http.StatusOK, "Record updated", updated)
+}
+
+func (h *SessionHandler) DeleteRecord(c *gin.Context) {
+	record, ok := h.sessionRecord(c)
+	if !ok {
+		return
+	}
+
+	if err := h.recordSvc.DeleteRecord(record.CollectionID.Hex(), record.ID.Hex(), sandboxActor(c), c.GetHeader("If-Match")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Record deleted", nil)
+}
+
+// sessionCollection loads :cid and makes sure it belongs to the session project
+func (h *SessionHandler) sessionCollection(c *gin.Context) (*models.Collection, bool) {
+	collection, err := h.collectionSvc.GetCollectionByID(c.GetString("projectId"), c.Param("cid"), sandboxUser)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return nil, false
+	}
+	return collection, true
+}
+
+// sessionRecord loads :rid and makes sure it belongs to :cid in the session project
+func (h *SessionHandler) sessionRecord(c *gin.Context) (*models.Record, bool) {
+	collection, ok := h.sessionCollection(c)
+	if !ok {
+		return nil, false
+	}
+
+	record, err := h.recordSvc.GetRecordByID(collection.ID.Hex(), c.Param("rid"), sandboxUser)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return nil, false
+	}
+	return record, true
+}
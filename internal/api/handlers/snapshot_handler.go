@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// SnapshotHandler captures and restores the data of a project, e.g. around a
+// destructive test run. Admin API keys can use it from CI.
+type SnapshotHandler struct {
+	service *services.SnapshotService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewSnapshotHandler(service *services.SnapshotService, apiKeys *services.APIKeyService, cfg *config.Config) *SnapshotHandler {
+	return &SnapshotHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *SnapshotHandler) RegisterRoutes(r *gin.RouterGroup) {
+	snapshotRoutes := r.Group("/api/projects/:pid/snapshots")
+	snapshotRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin))
+	{
+		snapshotRoutes.POST("", h.CreateSnapshot)
+		snapshotRoutes.GET("", h.ListSnapshots)
+		snapshotRoutes.GET("/:sid", h.GetSnapshot)
+		snapshotRoutes.GET("/:sid/diff", h.DiffSnapshot)
+		snapshotRoutes.POST("/:sid/restore", h.RestoreSnapshot)
+		snapshotRoutes.DELETE("/:sid", h.DeleteSnapshot)
+	}
+}
+
+func (h *SnapshotHandler) CreateSnapshot(c *gin.Context) {
+	var req dtos.CreateSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	snapshot, err := h.service.CreateSnapshot(c.Param("pid"), actorFrom(c), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Snapshot created", snapshot)
+}
+
+func (h *SnapshotHandler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.service.ListSnapshots(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Snapshots fetched", snapshots)
+}
+
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	snapshot, err := h.service.GetSnapshot(c.Param("pid"), c.Param("sid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Snapshot fetched", snapshot)
+}
+
+func (h *SnapshotHandler) DiffSnapshot(c *gin.Context) {
+	diff, err := h.service.DiffSnapshot(c.Param("pid"), c.Param("sid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Snapshot diff computed", diff)
+}
+
+func (h *SnapshotHandler) RestoreSnapshot(c *gin.Context) {
+	// The body is optional: no collections restores the whole project
+	var req dtos.RestoreSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	result, err := h.service.RestoreSnapshot(c.Param("pid"), c.Param("sid"), actorFrom(c), req.Collections)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Snapshot restored", result)
+}
+
+func (h *SnapshotHandler) DeleteSnapshot(c *gin.Context) {
+	if err := h.service.DeleteSnapshot(c.Param("pid"), c.Param("sid"), actorFrom(c)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Snapshot deleted", nil)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// TemplateHandler duplicates projects and manages the templates projects can
+// be created from. New projects go to the organization named by X-Org-ID.
+type TemplateHandler struct {
+	service *services.TemplateService
+	cfg     *config.Config
+}
+
+func NewTemplateHandler(service *services.TemplateService, cfg *config.Config) *TemplateHandler {
+	return &TemplateHandler{service: service, cfg: cfg}
+}
+
+func (h *TemplateHandler) RegisterRoutes(r *gin.RouterGroup) {
+	auth := middlewares.AuthMiddleware(h.cfg)
+
+	r.POST("/api/projects/:pid/duplicate", auth, h.DuplicateProject)
+	r.POST("/api/projects/:pid/templates", auth, h.PublishTemplate)
+
+	templateRoutes := r.Group("/api/templates")
+	templateRoutes.Use(auth)
+	{
+		templateRoutes.GET("", h.ListTemplates)
+		templateRoutes.GET("/:tid", h.GetTemplate)
+		templateRoutes.DELETE("/:tid", h.DeleteTemplate)
+		templateRoutes.POST("/:tid/instantiate", h.InstantiateTemplate)
+	}
+}
+
+func (h *TemplateHandler) DuplicateProject(c *gin.Context) {
+	var req dtos.DuplicateProjectRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+			return
+		}
+	}
+
+	project, err := h.service.DuplicateProject(c.Param("pid"), actorFrom(c), c.GetHeader(orgHeader), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Project duplicated", project)
+}
+
+func (h *TemplateHandler) PublishTemplate(c *gin.Context) {
+	var req dtos.PublishTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	template, err := h.service.PublishTemplate(c.Param("pid"), actorFrom(c), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Template published", template)
+}
+
+func (h *TemplateHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Templates fetched", templates)
+}
+
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	template, err := h.service.GetTemplate(c.Param("tid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Template fetched", template)
+}
+
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	if err := h.service.DeleteTemplate(c.Param("tid"), actorFrom(c)); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Template deleted", nil)
+}
+
+func (h *TemplateHandler) InstantiateTemplate(c *gin.Context) {
+	var req dtos.InstantiateTemplateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+			return
+		}
+	}
+
+	project, err := h.service.InstantiateTemplate(c.Param("tid"), actorFrom(c), c.GetHeader(orgHeader), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Project created from template", project)
+}
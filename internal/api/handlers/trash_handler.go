@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// TrashHandler lists and restores deleted projects, collections and records
+type TrashHandler struct {
+	service *services.TrashService
+	cfg     *config.Config
+}
+
+func NewTrashHandler(service *services.TrashService, cfg *config.Config) *TrashHandler {
+	return &TrashHandler{service: service, cfg: cfg}
+}
+
+func (h *TrashHandler) RegisterRoutes(r *gin.RouterGroup) {
+	auth := middlewares.AuthMiddleware(h.cfg)
+
+	r.GET("/api/trash/projects", auth, h.ListProjects)
+	r.POST("/api/trash/projects/:pid/restore", auth, h.RestoreProject)
+
+	projectTrash := r.Group("/api/projects/:pid/trash")
+	projectTrash.Use(auth)
+	{
+		projectTrash.GET("", h.ProjectTrash)
+		projectTrash.POST("/collections/:cid/restore", h.RestoreCollection)
+		projectTrash.POST("/records/:rid/restore", h.RestoreRecord)
+	}
+}
+
+func (h *TrashHandler) ListProjects(c *gin.Context) {
+	projects, err := h.service.ListProjects(c.GetString("userId"), c.GetHeader(orgHeader))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Deleted projects fetched", projects)
+}
+
+func (h *TrashHandler) RestoreProject(c *gin.Context) {
+	project, err := h.service.RestoreProject(c.Param("pid"), actorFrom(c))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Project restored", project)
+}
+
+func (h *TrashHandler) ProjectTrash(c *gin.Context) {
+	trash, err := h.service.ProjectTrash(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Trash fetched", trash)
+}
+
+func (h *TrashHandler) RestoreCollection(c *gin.Context) {
+	collection, err := h.service.RestoreCollection(c.Param("pid"), c.Param("cid"), actorFrom(c))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Collection restored", collection)
+}
+
+func (h *TrashHandler) RestoreRecord(c *gin.Context) {
+	record, err := h.service.RestoreRecord(c.Param("pid"), c.Param("rid"), actorFrom(c))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Record restored", record)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// ValidationHandler switches projects between enforcing and observing their
+// schemas and serves the violations observed
+type ValidationHandler struct {
+	service *services.ValidationService
+	apiKeys *services.APIKeyService
+	cfg     *config.Config
+}
+
+func NewValidationHandler(service *services.ValidationService, apiKeys *services.APIKeyService, cfg *config.Config) *ValidationHandler {
+	return &ValidationHandler{service: service, apiKeys: apiKeys, cfg: cfg}
+}
+
+func (h *ValidationHandler) RegisterRoutes(r *gin.RouterGroup) {
+	projectRoutes := r.Group("/api/projects/:pid")
+	projectRoutes.Use(middlewares.APIKeyMiddleware(h.cfg, h.apiKeys, models.APIKeyScopeAdmin))
+	{
+		projectRoutes.GET("/validation", h.GetValidation)
+		projectRoutes.PUT("/validation", h.SetValidation)
+		projectRoutes.GET("/violations", h.ListViolations)
+		projectRoutes.GET("/violations/summary", h.SummarizeViolations)
+		projectRoutes.DELETE("/violations", h.ClearViolations)
+	}
+}
+
+func (h *ValidationHandler) GetValidation(c *gin.Context) {
+	settings, err := h.service.GetValidation(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Validation mode fetched", settings)
+}
+
+func (h *ValidationHandler) SetValidation(c *gin.Context) {
+	var req dtos.ValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	settings, err := h.service.SetValidation(c.Param("pid"), actorFrom(c), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Validation mode updated", settings)
+}
+
+func (h *ValidationHandler) ListViolations(c *gin.Context) {
+	var query dtos.ViolationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	violations, err := h.service.ListViolations(c.Param("pid"), c.GetString("userId"), &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Violations fetched", violations)
+}
+
+func (h *ValidationHandler) SummarizeViolations(c *gin.Context) {
+	var query dtos.ViolationSummaryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid query: "+err.Error())
+		return
+	}
+
+	summary, err := h.service.SummarizeViolations(c.Param("pid"), c.GetString("userId"), &query)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Violation summary fetched", summary)
+}
+
+func (h *ValidationHandler) ClearViolations(c *gin.Context) {
+	deleted, err := h.service.ClearViolations(c.Param("pid"), c.Query("collection"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Violations cleared", gin.H{"deleted": deleted})
+}
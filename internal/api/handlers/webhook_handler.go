@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/api/responses"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/middlewares"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// WebhookHandler lets project owners manage webhooks and inspect their
+// deliveries. Like API keys, webhooks hold a secret, so only a JWT is accepted.
+type WebhookHandler struct {
+	service *services.WebhookService
+	cfg     *config.Config
+}
+
+func NewWebhookHandler(service *services.WebhookService, cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{service: service, cfg: cfg}
+}
+
+func (h *WebhookHandler) RegisterRoutes(r *gin.RouterGroup) {
+	webhookRoutes := r.Group("/api/projects/:pid/webhooks")
+	webhookRoutes.Use(middlewares.AuthMiddleware(h.cfg))
+	{
+		webhookRoutes.POST("", h.CreateWebhook)
+		webhookRoutes.GET("", h.ListWebhooks)
+		webhookRoutes.PUT("/:wid", h.UpdateWebhook)
+		webhookRoutes.DELETE("/:wid", h.DeleteWebhook)
+		webhookRoutes.GET("/:wid/deliveries", h.ListDeliveries)
+		webhookRoutes.POST("/:wid/deliveries/:did/redeliver", h.Redeliver)
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req dtos.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	result, err := h.service.CreateWebhook(c.Param("pid"), c.GetString("userId"), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusCreated, "Webhook created — store the secret now, it will not be shown again", result)
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	hooks, err := h.service.ListWebhooks(c.Param("pid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Webhooks fetched", hooks)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req dtos.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.JSONError(c, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	hook, err := h.service.UpdateWebhook(c.Param("pid"), c.Param("wid"), c.GetString("userId"), &req)
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Webhook updated", hook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.service.DeleteWebhook(c.Param("pid"), c.Param("wid"), c.GetString("userId")); err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Webhook deleted", nil)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	deliveries, err := h.service.ListDeliveries(c.Param("pid"), c.Param("wid"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusOK, "Deliveries fetched", deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	delivery, err := h.service.Redeliver(c.Param("pid"), c.Param("wid"), c.Param("did"), c.GetString("userId"))
+	if err != nil {
+		responses.JSONError(c, errorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	responses.JSONSuccess(c, http.StatusAccepted, "Redelivery queued", delivery)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// PATCH merges into data read back from the store, where nested arrays and
+// objects come out BSON-decoded; the merged document must still validate
+func TestPatchRecordKeepsNestedFields(t *testing.T) {
+	s := newTestServer(t)
+	_, owner := s.newUser("owner@example.com")
+
+	pid := s.create(http.MethodPost, "/api/projects", owner, map[string]any{"name": "Shop"})
+	cid := s.create(http.MethodPost, "/api/projects/"+pid+"/collections", owner, map[string]any{"name": "posts", "fields": []any{
+		map[string]any{"name": "title", "type": "string"},
+		map[string]any{"name": "tags", "type": "array"},
+		map[string]any{"name": "meta", "type": "object"},
+	}})
+	post := map[string]any{"title": "a", "tags": []any{"x", "y"}, "meta": map[string]any{"views": 1.0, "refs": []any{"r"}}}
+
+	tests := []struct {
+		name string
+		path func(rid string) string
+	}{
+		{"record API", func(rid string) string { return "/api/collections/" + cid + "/records/" + rid }},
+		{"mock route", func(rid string) string { return "/mock/" + pid + "/posts/" + rid }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rid := s.create(http.MethodPost, "/api/collections/"+cid+"/records", owner, post)
+
+			w := s.request(http.MethodPatch, tt.path(rid), owner, map[string]any{"title": "b"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("PATCH: %d %s", w.Code, w.Body.String())
+			}
+
+			w = s.request(http.MethodGet, "/api/collections/"+cid+"/records/"+rid, owner, nil)
+			var out struct {
+				Data struct {
+					Data map[string]any `json:"data"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]any{"title": "b", "tags": post["tags"], "meta": post["meta"]}
+			if !reflect.DeepEqual(out.Data.Data, want) {
+				t.Errorf("data = %v, want %v", out.Data.Data, want)
+			}
+		})
+	}
+}
+
+func TestPatchRecordIfMatch(t *testing.T) {
+	s := newTestServer(t)
+	_, owner := s.newUser("owner@example.com")
+
+	pid := s.create(http.MethodPost, "/api/projects", owner, map[string]any{"name": "Shop"})
+	cid := s.create(http.MethodPost, "/api/projects/"+pid+"/collections", owner, map[string]any{"name": "posts"})
+	rid := s.create(http.MethodPost, "/api/collections/"+cid+"/records", owner, map[string]any{"title": "a"})
+
+	paths := map[string]string{
+		"record API": "/api/collections/" + cid + "/records/" + rid,
+		"mock route": "/mock/" + pid + "/posts/" + rid,
+	}
+	for name, path := range paths {
+		t.Run(name, func(t *testing.T) {
+			w := s.requestWithHeaders(http.MethodPatch, path, owner, map[string]any{"title": "stale"}, map[string]string{"If-Match": `"99"`})
+			if w.Code != http.StatusPreconditionFailed {
+				t.Fatalf("stale If-Match: got %d, want 412: %s", w.Code, w.Body.String())
+			}
+
+			etag := s.request(http.MethodGet, "/api/collections/"+cid+"/records/"+rid, owner, nil).Header().Get("ETag")
+			w = s.requestWithHeaders(http.MethodPatch, path, owner, map[string]any{"title": name}, map[string]string{"If-Match": etag})
+			if w.Code != http.StatusOK {
+				t.Fatalf("current If-Match: got %d, want 200: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("ETag"); got == "" || got == etag {
+				t.Errorf("ETag after PATCH = %q, want a new version after %q", got, etag)
+			}
+		})
+	}
+}
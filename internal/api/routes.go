@@ -17,6 +17,25 @@ func RegisterRoutes(
 	recordHandler *handlers.RecordHandler,
 	healthHandler *handlers.HealthHandler,
 	configHandler *handlers.ConfigHandler,
+	endpointHandler *handlers.EndpointHandler,
+	mockHandler *handlers.MockHandler,
+	inspectorHandler *handlers.InspectorHandler,
+	sessionHandler *handlers.SessionHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	memberHandler *handlers.MemberHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	auditHandler *handlers.AuditHandler,
+	webhookHandler *handlers.WebhookHandler,
+	changeStreamHandler *handlers.ChangeStreamHandler,
+	snapshotHandler *handlers.SnapshotHandler,
+	trashHandler *handlers.TrashHandler,
+	templateHandler *handlers.TemplateHandler,
+	bundleHandler *handlers.BundleHandler,
+	environmentHandler *handlers.EnvironmentHandler,
+	proxyHandler *handlers.ProxyHandler,
+	openAPIHandler *handlers.OpenAPIHandler,
+	harHandler *handlers.HARHandler,
+	validationHandler *handlers.ValidationHandler,
 ) {
 	// Handlers
 
@@ -26,4 +45,23 @@ func RegisterRoutes(
 	recordHandler.RegisterRoutes(&r.RouterGroup)
 	healthHandler.RegisterRoutes(&r.RouterGroup)
 	configHandler.RegisterRoutes(&r.RouterGroup)
+	endpointHandler.RegisterRoutes(&r.RouterGroup)
+	mockHandler.RegisterRoutes(&r.RouterGroup)
+	inspectorHandler.RegisterRoutes(&r.RouterGroup)
+	sessionHandler.RegisterRoutes(&r.RouterGroup)
+	apiKeyHandler.RegisterRoutes(&r.RouterGroup)
+	memberHandler.RegisterRoutes(&r.RouterGroup)
+	organizationHandler.RegisterRoutes(&r.RouterGroup)
+	auditHandler.RegisterRoutes(&r.RouterGroup)
+	webhookHandler.RegisterRoutes(&r.RouterGroup)
+	changeStreamHandler.RegisterRoutes(&r.RouterGroup)
+	snapshotHandler.RegisterRoutes(&r.RouterGroup)
+	trashHandler.RegisterRoutes(&r.RouterGroup)
+	templateHandler.RegisterRoutes(&r.RouterGroup)
+	bundleHandler.RegisterRoutes(&r.RouterGroup)
+	environmentHandler.RegisterRoutes(&r.RouterGroup)
+	proxyHandler.RegisterRoutes(&r.RouterGroup)
+	openAPIHandler.RegisterRoutes(&r.RouterGroup)
+	harHandler.RegisterRoutes(&r.RouterGroup)
+	validationHandler.RegisterRoutes(&r.RouterGroup)
 }
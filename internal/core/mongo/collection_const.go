@@ -5,17 +5,56 @@ const (
 	collectionsCol = "collections"
 	projectsCol    = "projects"
 	recordsCol     = "records"
+	endpointsCol   = "endpoints"
+	apiKeysCol     = "api_keys"
+	invitationsCol = "project_invitations"
+	orgsCol        = "organizations"
+	auditCol       = "audit_events"
+	webhooksCol    = "webhooks"
+	deliveriesCol  = "webhook_deliveries"
+	snapshotsCol   = "snapshots"
+	snapRecordsCol = "snapshot_records"
+	revisionsCol   = "record_revisions"
+	templatesCol   = "templates"
+	tplRecordsCol  = "template_records"
+	violationsCol  = "schema_violations"
 )
 
 // Collections exposes read-only grouped names.
 var Collections = struct {
-	Users      string
-	Collection string
-	Projects   string
-	Records    string
+	Users         string
+	Collection    string
+	Projects      string
+	Records       string
+	Endpoints     string
+	APIKeys       string
+	Invitations   string
+	Organizations string
+	AuditEvents   string
+	Webhooks      string
+	Deliveries    string
+	Snapshots     string
+	SnapRecords   string
+	Revisions     string
+	Templates     string
+	TplRecords    string
+	Violations    string
 }{
-	Users:      usersCol,
-	Collection: collectionsCol,
-	Projects:   projectsCol,
-	Records:    recordsCol,
+	Users:         usersCol,
+	Collection:    collectionsCol,
+	Projects:      projectsCol,
+	Records:       recordsCol,
+	Endpoints:     endpointsCol,
+	APIKeys:       apiKeysCol,
+	Invitations:   invitationsCol,
+	Organizations: orgsCol,
+	AuditEvents:   auditCol,
+	Webhooks:      webhooksCol,
+	Deliveries:    deliveriesCol,
+	Snapshots:     snapshotsCol,
+	SnapRecords:   snapRecordsCol,
+	Revisions:     revisionsCol,
+	Templates:     templatesCol,
+	TplRecords:    tplRecordsCol,
+	Violations:    violationsCol,
 }
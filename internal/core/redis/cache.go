@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The cache helpers below are best effort: when Redis is not connected or a
+// command fails they behave like a cache miss, so callers always fall back
+// to the database. After a failure the cache is skipped for cacheBackoff so
+// an unreachable Redis does not add dial timeouts to every request.
+
+const cacheBackoff = 30 * time.Second
+
+var cacheDownUntil atomic.Int64
+
+func cacheAvailable() bool {
+	return Rdb != nil && time.Now().UnixNano() >= cacheDownUntil.Load()
+}
+
+// GetCachedJSON decodes the value at key into out and reports whether it was found
+func GetCachedJSON(key string, out any) bool {
+	if !cacheAvailable() {
+		return false
+	}
+
+	raw, err := Rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		logCacheError("get", key, err)
+		return false
+	}
+	return json.Unmarshal(raw, out) == nil
+}
+
+// SetCachedJSON stores value at key for ttl; a zero ttl disables caching
+func SetCachedJSON(key string, value any, ttl time.Duration) {
+	if !cacheAvailable() || ttl <= 0 {
+		return
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := Rdb.Set(ctx, key, payload, ttl).Err(); err != nil {
+		logCacheError("set", key, err)
+	}
+}
+
+// GetCachedField decodes one field of the hash at key into out
+func GetCachedField(key, field string, out any) bool {
+	if !cacheAvailable() {
+		return false
+	}
+
+	raw, err := Rdb.HGet(ctx, key, field).Bytes()
+	if err != nil {
+		logCacheError("hget", key, err)
+		return false
+	}
+	return json.Unmarshal(raw, out) == nil
+}
+
+// SetCachedField stores value in one field of the hash at key. The ttl
+// applies to the whole hash so that related entries expire together.
+func SetCachedField(key, field string, value any, ttl time.Duration) {
+	if !cacheAvailable() || ttl <= 0 {
+		return
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	pipe := Rdb.TxPipeline()
+	pipe.HSet(ctx, key, field, payload)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logCacheError("hset", key, err)
+	}
+}
+
+// InvalidateCache drops the given keys. While Redis is failing this is a
+// no-op; entries written before the outage still expire through their TTL.
+func InvalidateCache(keys ...string) {
+	if !cacheAvailable() || len(keys) == 0 {
+		return
+	}
+
+	if err := Rdb.Del(ctx, keys...).Err(); err != nil {
+		logCacheError("del", keys[0], err)
+	}
+}
+
+func logCacheError(op, key string, err error) {
+	if errors.Is(err, redis.Nil) {
+		return
+	}
+	cacheDownUntil.Store(time.Now().Add(cacheBackoff).UnixNano())
+	log.Printf("[CACHE] %s %s failed, bypassing cache for %v: %v", op, key, cacheBackoff, err)
+}
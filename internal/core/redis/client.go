@@ -14,23 +14,27 @@ var (
 	ctx = context.Background()
 )
 
-// InitRedis initializes a Redis client and verifies the connection
-func InitRedis(cfg *config.Config) {
+// InitRedis initializes a Redis client and verifies the connection.
+// On failure the global client stays nil so Redis-backed features can degrade.
+func InitRedis(cfg *config.Config) error {
 	addr := fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort)
 
-	Rdb = redis.NewClient(&redis.Options{
+	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: cfg.RedisPassword, // empty string means no password
 		DB:       0,                 // default DB
 	})
 
 	// Test connection
-	pong, err := Rdb.Ping(ctx).Result()
+	pong, err := client.Ping(ctx).Result()
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to Redis at %s: %v", addr, err)
+		_ = client.Close()
+		return fmt.Errorf("failed to connect to Redis at %s: %w", addr, err)
 	}
 
+	Rdb = client
 	log.Printf("✅ Redis connected: %s", pong)
+	return nil
 }
 
 // CloseRedis closes the Redis connection (useful for graceful shutdown)
@@ -26,6 +26,11 @@ func ProjectCollectionsKey(projectID string) string {
 	return fmt.Sprintf("project:%s:collections", projectID)
 }
 
+// Cached collection definition, by id
+func CollectionCacheKey(collectionID string) string {
+	return fmt.Sprintf("collection:%s", collectionID)
+}
+
 func ProjectDataKey(projectID, collection string) string {
 	return fmt.Sprintf("project:%s:data:%s", projectID, collection)
 }
@@ -39,6 +44,25 @@ func ProjectRequestsLogKey(projectID string) string {
 	return fmt.Sprintf("project:%s:requests", projectID)
 }
 
+// Pub/sub channel used for live tailing of captured requests
+func ProjectRequestsChannelKey(projectID string) string {
+	return fmt.Sprintf("project:%s:requests:live", projectID)
+}
+
+// Record change streams: a sequence for event ids, a capped backlog for
+// resuming clients and the pub/sub channel that fans events out
+func ProjectChangesSeqKey(projectID string) string {
+	return fmt.Sprintf("project:%s:changes:seq", projectID)
+}
+
+func ProjectChangesKey(projectID string) string {
+	return fmt.Sprintf("project:%s:changes", projectID)
+}
+
+func ProjectChangesChannelKey(projectID string) string {
+	return fmt.Sprintf("project:%s:changes:live", projectID)
+}
+
 // Optional reverse mapping to quickly find session by project
 func ProjectSessionKey(projectID string) string {
 	return fmt.Sprintf("project:%s:session", projectID)
@@ -59,3 +83,16 @@ func SessionRequestCountKey(sessionID string) string {
 func SessionRequestsLogKey(sessionID string) string {
 	return fmt.Sprintf("session:%s:requests", sessionID)
 }
+
+// Rate limiting: sliding window per client identity, plus cached plan tiers
+func RateLimitKey(identity string) string {
+	return fmt.Sprintf("ratelimit:%s", identity)
+}
+
+func UserPlanKey(userID string) string {
+	return fmt.Sprintf("user:%s:plan", userID)
+}
+
+func ProjectPlanKey(projectID string) string {
+	return fmt.Sprintf("project:%s:plan", projectID)
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FileStore is the in-memory store persisted to a directory with one
+// <repository>.json file per repository. Documents are written as relaxed
+// MongoDB Extended JSON so ObjectIDs and dates survive a restart. Every write
+// rewrites the repository file through a temp file + rename, so a crash never
+// leaves a half-written file behind.
+type FileStore struct {
+	dir   string
+	mu    sync.Mutex
+	repos map[string]*memoryRepository
+}
+
+// NewFileStore creates dir if needed and loads every repository file in it.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+
+	s := &FileStore{dir: dir, repos: map[string]*memoryRepository{}}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		docs, err := readDocuments(file)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", file, err)
+		}
+		repo := s.newRepository(name)
+		repo.docs = docs
+	}
+
+	return s, nil
+}
+
+func (s *FileStore) Repository(name string) Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[name]
+	if !ok {
+		repo = s.newRepository(name)
+	}
+	return repo
+}
+
+func (s *FileStore) Driver() string {
+	return DriverFile
+}
+
+func (s *FileStore) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", s.dir)
+	}
+	return nil
+}
+
+func (s *FileStore) Close(ctx context.Context) error {
+	return nil
+}
+
+// newRepository registers a repository that saves itself on every change;
+// callers hold s.mu or run before the store is shared.
+func (s *FileStore) newRepository(name string) *memoryRepository {
+	path := filepath.Join(s.dir, name+".json")
+	repo := &memoryRepository{
+		onChange: func(docs []bson.M) error {
+			return writeDocuments(path, docs)
+		},
+	}
+	s.repos[name] = repo
+	return repo
+}
+
+func readDocuments(path string) ([]bson.M, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	docs := make([]bson.M, 0, len(raw))
+	for _, item := range raw {
+		var doc bson.M
+		if err := bson.UnmarshalExtJSON(item, false, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func writeDocuments(path string, docs []bson.M) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, doc := range docs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		item, err := bson.MarshalExtJSON(doc, false, false)
+		if err != nil {
+			return err
+		}
+		buf.Write(item)
+	}
+	buf.WriteByte(']')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(out.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,284 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore keeps every repository in process memory. It needs no external
+// services and is meant for local demos and tests; data is lost on exit.
+type MemoryStore struct {
+	mu    sync.Mutex
+	repos map[string]*memoryRepository
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{repos: map[string]*memoryRepository{}}
+}
+
+func (s *MemoryStore) Repository(name string) Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo, ok := s.repos[name]
+	if !ok {
+		repo = &memoryRepository{}
+		s.repos[name] = repo
+	}
+	return repo
+}
+
+func (s *MemoryStore) Driver() string {
+	return DriverMemory
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
+
+// memoryRepository stores documents in insertion order. onChange, when set,
+// is called with the full document list of every write before it is applied;
+// if it fails the write is dropped.
+type memoryRepository struct {
+	mu       sync.RWMutex
+	docs     []bson.M
+	onChange func(docs []bson.M) error
+}
+
+func (r *memoryRepository) InsertOne(ctx context.Context, doc any) error {
+	return r.InsertMany(ctx, []any{doc})
+}
+
+func (r *memoryRepository) InsertMany(ctx context.Context, docs []any) error {
+	prepared := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+		m, err := toDocument(doc)
+		if err != nil {
+			return err
+		}
+		if _, ok := m["_id"]; !ok {
+			m["_id"] = primitive.NewObjectID()
+		}
+		prepared = append(prepared, m)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, doc := range prepared {
+		if r.indexOfID(doc["_id"]) >= 0 {
+			return ErrDuplicateKey
+		}
+		for _, other := range prepared[:i] {
+			if valuesEqual(other["_id"], doc["_id"]) {
+				return ErrDuplicateKey
+			}
+		}
+	}
+
+	return r.commit(append(slices.Clone(r.docs), prepared...))
+}
+
+func (r *memoryRepository) FindOne(ctx context.Context, filter bson.M, out any) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx, err := r.findIndexes(filter, 1)
+	if err != nil {
+		return err
+	}
+	if len(idx) == 0 {
+		return ErrNotFound
+	}
+	return decodeDocument(r.docs[idx[0]], out)
+}
+
+func (r *memoryRepository) Find(ctx context.Context, filter bson.M, out any, opts ...FindOptions) error {
+	r.mu.RLock()
+	idx, err := r.findIndexes(filter, 0)
+	if err != nil {
+		r.mu.RUnlock()
+		return err
+	}
+	matched := make([]bson.M, 0, len(idx))
+	for _, i := range idx {
+		matched = append(matched, r.docs[i])
+	}
+	r.mu.RUnlock()
+
+	o := findOptions(opts)
+	sortDocuments(matched, o.Sort)
+
+	if o.Skip > 0 {
+		if o.Skip >= int64(len(matched)) {
+			matched = nil
+		} else {
+			matched = matched[o.Skip:]
+		}
+	}
+	if o.Limit > 0 && o.Limit < int64(len(matched)) {
+		matched = matched[:o.Limit]
+	}
+
+	return decodeDocuments(matched, out)
+}
+
+func (r *memoryRepository) CountDocuments(ctx context.Context, filter bson.M) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx, err := r.findIndexes(filter, 0)
+	return int64(len(idx)), err
+}
+
+func (r *memoryRepository) UpdateOne(ctx context.Context, filter, update bson.M) (int64, error) {
+	return r.update(filter, update, 1, nil)
+}
+
+func (r *memoryRepository) UpdateMany(ctx context.Context, filter, update bson.M) (int64, error) {
+	return r.update(filter, update, 0, nil)
+}
+
+func (r *memoryRepository) FindOneAndUpdate(ctx context.Context, filter, update bson.M, out any) error {
+	matched, err := r.update(filter, update, 1, out)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryRepository) DeleteOne(ctx context.Context, filter bson.M) (int64, error) {
+	return r.delete(filter, 1)
+}
+
+func (r *memoryRepository) DeleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	return r.delete(filter, 0)
+}
+
+// update applies the update to up to limit matches (0 = all). Documents are
+// updated on copies, swapped in once the whole update succeeded and persisted.
+func (r *memoryRepository) update(filter, update bson.M, limit int, out any) (int64, error) {
+	normalized, err := toDocument(update)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx, err := r.findIndexes(filter, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := make([]bson.M, len(idx))
+	for n, i := range idx {
+		doc, err := toDocument(r.docs[i])
+		if err != nil {
+			return 0, err
+		}
+		if err := applyUpdate(doc, normalized); err != nil {
+			return 0, err
+		}
+		updated[n] = doc
+	}
+
+	if len(idx) > 0 {
+		docs := slices.Clone(r.docs)
+		for n, i := range idx {
+			docs[i] = updated[n]
+		}
+		if err := r.commit(docs); err != nil {
+			return 0, err
+		}
+		if out != nil {
+			if err := decodeDocument(updated[0], out); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return int64(len(idx)), nil
+}
+
+func (r *memoryRepository) delete(filter bson.M, limit int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx, err := r.findIndexes(filter, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(idx) == 0 {
+		return 0, nil
+	}
+
+	remove := make(map[int]bool, len(idx))
+	for _, i := range idx {
+		remove[i] = true
+	}
+	kept := make([]bson.M, 0, len(r.docs)-len(idx))
+	for i, doc := range r.docs {
+		if !remove[i] {
+			kept = append(kept, doc)
+		}
+	}
+	if err := r.commit(kept); err != nil {
+		return 0, err
+	}
+	return int64(len(idx)), nil
+}
+
+// findIndexes returns positions of matching documents; callers hold the lock.
+func (r *memoryRepository) findIndexes(filter bson.M, limit int) ([]int, error) {
+	normalized, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var idx []int
+	for i, doc := range r.docs {
+		ok, err := matchDocument(doc, normalized)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			idx = append(idx, i)
+			if limit > 0 && len(idx) >= limit {
+				break
+			}
+		}
+	}
+	return idx, nil
+}
+
+func (r *memoryRepository) indexOfID(id any) int {
+	for i, doc := range r.docs {
+		if valuesEqual(doc["_id"], id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// commit replaces the document list once onChange accepted it; callers hold the lock
+func (r *memoryRepository) commit(docs []bson.M) error {
+	if r.onChange != nil {
+		if err := r.onChange(docs); err != nil {
+			return err
+		}
+	}
+	r.docs = docs
+	return nil
+}
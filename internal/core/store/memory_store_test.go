@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// A write the backing file rejects must not stay applied in memory
+func TestMemoryRepositoryDropsUnpersistedWrites(t *testing.T) {
+	ctx := context.Background()
+	failing := errors.New("disk full")
+
+	tests := []struct {
+		name  string
+		write func(r *memoryRepository) error
+	}{
+		{"insert", func(r *memoryRepository) error {
+			return r.InsertOne(ctx, bson.M{"_id": "b", "n": int32(2)})
+		}},
+		{"update", func(r *memoryRepository) error {
+			_, err := r.UpdateOne(ctx, bson.M{"_id": "a"}, bson.M{"$set": bson.M{"n": int32(5)}})
+			return err
+		}},
+		{"find and update", func(r *memoryRepository) error {
+			var out bson.M
+			return r.FindOneAndUpdate(ctx, bson.M{"_id": "a"}, bson.M{"$inc": bson.M{"n": int32(1)}}, &out)
+		}},
+		{"delete", func(r *memoryRepository) error {
+			_, err := r.DeleteOne(ctx, bson.M{"_id": "a"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &memoryRepository{}
+			if err := r.InsertOne(ctx, bson.M{"_id": "a", "n": int32(1)}); err != nil {
+				t.Fatal(err)
+			}
+			r.onChange = func([]bson.M) error { return failing }
+
+			if err := tt.write(r); !errors.Is(err, failing) {
+				t.Fatalf("write error = %v, want %v", err, failing)
+			}
+
+			var docs []bson.M
+			if err := r.Find(ctx, bson.M{}, &docs); err != nil {
+				t.Fatal(err)
+			}
+			if len(docs) != 1 || docs[0]["_id"] != "a" || docs[0]["n"] != int32(1) {
+				t.Fatalf("documents = %v, want the original document only", docs)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is the MongoDB backend; repositories map 1:1 to Mongo collections.
+type MongoStore struct {
+	client *mongo.Client
+	db     *mongo.Database
+}
+
+func NewMongoStore(cfg *config.Config) (*MongoStore, error) {
+	client, err := database.InitMongo(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoStore{client: client, db: database.GetDatabase(client, cfg.MongoDBName)}, nil
+}
+
+func (s *MongoStore) Repository(name string) Repository {
+	return &mongoRepository{coll: s.db.Collection(name)}
+}
+
+func (s *MongoStore) Driver() string {
+	return DriverMongo
+}
+
+func (s *MongoStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx, nil)
+}
+
+func (s *MongoStore) Close(ctx context.Context) error {
+	return s.client.Disconnect(ctx)
+}
+
+type mongoRepository struct {
+	coll *mongo.Collection
+}
+
+func (r *mongoRepository) InsertOne(ctx context.Context, doc any) error {
+	_, err := r.coll.InsertOne(ctx, doc)
+	return mapMongoError(err)
+}
+
+func (r *mongoRepository) InsertMany(ctx context.Context, docs []any) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	_, err := r.coll.InsertMany(ctx, docs)
+	return mapMongoError(err)
+}
+
+func (r *mongoRepository) FindOne(ctx context.Context, filter bson.M, out any) error {
+	return mapMongoError(r.coll.FindOne(ctx, filter).Decode(out))
+}
+
+func (r *mongoRepository) Find(ctx context.Context, filter bson.M, out any, opts ...FindOptions) error {
+	o := findOptions(opts)
+	findOpts := options.Find()
+	if len(o.Sort) > 0 {
+		findOpts.SetSort(o.Sort)
+	}
+	if o.Skip > 0 {
+		findOpts.SetSkip(o.Skip)
+	}
+	if o.Limit > 0 {
+		findOpts.SetLimit(o.Limit)
+	}
+
+	cursor, err := r.coll.Find(ctx, filter, findOpts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, out)
+}
+
+func (r *mongoRepository) CountDocuments(ctx context.Context, filter bson.M) (int64, error) {
+	return r.coll.CountDocuments(ctx, filter)
+}
+
+func (r *mongoRepository) UpdateOne(ctx context.Context, filter, update bson.M) (int64, error) {
+	res, err := r.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.MatchedCount, nil
+}
+
+func (r *mongoRepository) UpdateMany(ctx context.Context, filter, update bson.M) (int64, error) {
+	res, err := r.coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.MatchedCount, nil
+}
+
+func (r *mongoRepository) FindOneAndUpdate(ctx context.Context, filter, update bson.M, out any) error {
+	res := r.coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	return mapMongoError(res.Decode(out))
+}
+
+func (r *mongoRepository) DeleteOne(ctx context.Context, filter bson.M) (int64, error) {
+	res, err := r.coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *mongoRepository) DeleteMany(ctx context.Context, filter bson.M) (int64, error) {
+	res, err := r.coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func mapMongoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return ErrDuplicateKey
+	}
+	return err
+}
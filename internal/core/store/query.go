@@ -0,0 +1,641 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// This file implements the subset of the MongoDB query and update language
+// used by the services, for the non-Mongo backends. Documents and filters are
+// round-tripped through BSON first so Go values (time.Time, slices, structs)
+// compare the same way the Mongo server would see them.
+
+// toDocument converts any BSON-marshalable value into a bson.M.
+func toDocument(v any) (bson.M, error) {
+	if v == nil {
+		return bson.M{}, nil
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decodeDocument decodes a stored document into out (a pointer).
+func decodeDocument(doc bson.M, out any) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, out)
+}
+
+// decodeDocuments decodes documents into out, which must point to a slice.
+func decodeDocuments(docs []bson.M, out any) error {
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Pointer || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("store: results argument must be a pointer to a slice, got %T", out)
+	}
+
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	result := reflect.MakeSlice(slice.Type(), 0, len(docs))
+
+	for _, doc := range docs {
+		elem := reflect.New(elemType)
+		if err := decodeDocument(doc, elem.Interface()); err != nil {
+			return err
+		}
+		result = reflect.Append(result, elem.Elem())
+	}
+
+	slice.Set(result)
+	return nil
+}
+
+// ---------------------------
+// Filters
+// ---------------------------
+
+func matchDocument(doc, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		switch key {
+		case "$and", "$or", "$nor":
+			clauses, ok := cond.(primitive.A)
+			if !ok {
+				return false, fmt.Errorf("store: %s requires an array", key)
+			}
+			matched := 0
+			for _, clause := range clauses {
+				sub, ok := clause.(bson.M)
+				if !ok {
+					return false, fmt.Errorf("store: %s clauses must be documents", key)
+				}
+				ok, err := matchDocument(doc, sub)
+				if err != nil {
+					return false, err
+				}
+				if ok {
+					matched++
+				}
+			}
+			switch key {
+			case "$and":
+				if matched != len(clauses) {
+					return false, nil
+				}
+			case "$or":
+				if matched == 0 {
+					return false, nil
+				}
+			case "$nor":
+				if matched > 0 {
+					return false, nil
+				}
+			}
+		default:
+			value, exists := lookupPath(doc, key)
+			ok, err := matchCondition(value, exists, cond)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func isOperatorDocument(v any) (bson.M, bool) {
+	m, ok := v.(bson.M)
+	if !ok || len(m) == 0 {
+		return nil, false
+	}
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+func matchCondition(value any, exists bool, cond any) (bool, error) {
+	ops, isOps := isOperatorDocument(cond)
+	if !isOps {
+		return equalsMatch(value, exists, cond), nil
+	}
+
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = equalsMatch(value, exists, arg)
+		case "$ne":
+			ok = !equalsMatch(value, exists, arg)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = exists && anyElement(value, func(v any) bool { return rangeMatch(op, v, arg) })
+		case "$in", "$nin":
+			list, isList := arg.(primitive.A)
+			if !isList {
+				return false, fmt.Errorf("store: %s requires an array", op)
+			}
+			found := false
+			for _, item := range list {
+				if equalsMatch(value, exists, item) {
+					found = true
+					break
+				}
+			}
+			ok = found == (op == "$in")
+		case "$exists":
+			want, _ := arg.(bool)
+			ok = exists == want
+		case "$regex":
+			pattern, isString := arg.(string)
+			if !isString {
+				return false, fmt.Errorf("store: $regex requires a string")
+			}
+			if flags, _ := ops["$options"].(string); flags != "" {
+				pattern = "(?" + flags + ")" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, err
+			}
+			ok = exists && anyElement(value, func(v any) bool {
+				s, isStr := v.(string)
+				return isStr && re.MatchString(s)
+			})
+		case "$options":
+			ok = true
+		case "$not":
+			inner, err := matchCondition(value, exists, arg)
+			if err != nil {
+				return false, err
+			}
+			ok = !inner
+		case "$size":
+			list, isList := value.(primitive.A)
+			size, hasSize := toFloat(arg)
+			ok = isList && hasSize && float64(len(list)) == size
+		default:
+			return false, fmt.Errorf("store: unsupported query operator %s", op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// equalsMatch follows Mongo semantics: null matches missing fields and
+// arrays match when any element is equal.
+func equalsMatch(value any, exists bool, expected any) bool {
+	if expected == nil {
+		return !exists || value == nil
+	}
+	if !exists {
+		return false
+	}
+	if valuesEqual(value, expected) {
+		return true
+	}
+	if list, ok := value.(primitive.A); ok {
+		for _, item := range list {
+			if valuesEqual(item, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyElement(value any, fn func(any) bool) bool {
+	if list, ok := value.(primitive.A); ok {
+		for _, item := range list {
+			if fn(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return fn(value)
+}
+
+func rangeMatch(op string, value, bound any) bool {
+	if typeClass(value) != typeClass(bound) {
+		return false
+	}
+	c := compareValues(value, bound)
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+
+// lookupPath resolves dotted paths, including numeric array indexes.
+func lookupPath(doc bson.M, path string) (any, bool) {
+	var current any = doc
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case bson.M:
+			v, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case primitive.A:
+			idx, err := strconv.Atoi(part)
+			if err != nil {
+				// Path into an array of documents: collect the field from each element
+				var collected primitive.A
+				for _, item := range node {
+					if sub, ok := item.(bson.M); ok {
+						if v, ok := lookupPath(sub, part); ok {
+							collected = append(collected, v)
+						}
+					}
+				}
+				if len(collected) == 0 {
+					return nil, false
+				}
+				current = collected
+				continue
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// ---------------------------
+// Comparison
+// ---------------------------
+
+// typeClass follows the BSON comparison order.
+func typeClass(v any) int {
+	switch v.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, float64, int:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.M, bson.D:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	}
+	return 12
+}
+
+func compareValues(a, b any) int {
+	ca, cb := typeClass(a), typeClass(b)
+	if ca != cb {
+		return ca - cb
+	}
+
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case primitive.ObjectID:
+		bv := b.(primitive.ObjectID)
+		return bytes.Compare(av[:], bv[:])
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		}
+		return 1
+	case primitive.DateTime:
+		return compareInt64(int64(av), int64(b.(primitive.DateTime)))
+	case primitive.Timestamp:
+		bv := b.(primitive.Timestamp)
+		if av.T != bv.T {
+			return compareInt64(int64(av.T), int64(bv.T))
+		}
+		return compareInt64(int64(av.I), int64(bv.I))
+	}
+
+	if fa, ok := toFloat(a); ok {
+		fb, _ := toFloat(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func valuesEqual(a, b any) bool {
+	if typeClass(a) != typeClass(b) {
+		return false
+	}
+	switch a.(type) {
+	case bson.M, primitive.A:
+		return reflect.DeepEqual(normalizeNumbers(a), normalizeNumbers(b))
+	}
+	return compareValues(a, b) == 0
+}
+
+// normalizeNumbers widens all numbers to float64 so documents that only
+// differ in int32/int64/float64 encoding compare equal.
+func normalizeNumbers(v any) any {
+	switch val := v.(type) {
+	case bson.M:
+		out := make(bson.M, len(val))
+		for k, item := range val {
+			out[k] = normalizeNumbers(item)
+		}
+		return out
+	case primitive.A:
+		out := make(primitive.A, len(val))
+		for i, item := range val {
+			out[i] = normalizeNumbers(item)
+		}
+		return out
+	}
+	if f, ok := toFloat(v); ok {
+		return f
+	}
+	return v
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// ---------------------------
+// Sorting
+// ---------------------------
+
+func sortDocuments(docs []bson.M, order bson.D) {
+	if len(order) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range order {
+			a, _ := lookupPath(docs[i], key.Key)
+			b, _ := lookupPath(docs[j], key.Key)
+			c := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if dir, _ := toFloat(key.Value); dir < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// ---------------------------
+// Updates
+// ---------------------------
+
+func applyUpdate(doc, update bson.M) error {
+	if len(update) == 0 {
+		return fmt.Errorf("store: empty update document")
+	}
+
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return fmt.Errorf("store: update operator %s requires a document", op)
+		}
+
+		for path, value := range fields {
+			var err error
+			switch op {
+			case "$set":
+				err = setPath(doc, path, value)
+			case "$unset":
+				unsetPath(doc, path)
+			case "$inc":
+				err = incPath(doc, path, value)
+			case "$push", "$addToSet":
+				err = pushPath(doc, path, value, op == "$addToSet")
+			case "$pull":
+				err = pullPath(doc, path, value)
+			case "$setOnInsert":
+				// upserts are not supported; nothing to do on update
+			default:
+				err = fmt.Errorf("store: unsupported update operator %s", op)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func setPath(doc bson.M, path string, value any) error {
+	parts := strings.Split(path, ".")
+	var current any = doc
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch node := current.(type) {
+		case bson.M:
+			if last {
+				node[part] = value
+				return nil
+			}
+			next, ok := node[part]
+			if !ok || next == nil {
+				next = bson.M{}
+				node[part] = next
+			}
+			current = next
+		case primitive.A:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return fmt.Errorf("store: cannot set %s", path)
+			}
+			if last {
+				node[idx] = value
+				return nil
+			}
+			current = node[idx]
+		default:
+			return fmt.Errorf("store: cannot set %s on a non-document value", path)
+		}
+	}
+	return nil
+}
+
+func unsetPath(doc bson.M, path string) {
+	parts := strings.Split(path, ".")
+	parent, ok := lookupPath(doc, strings.Join(parts[:len(parts)-1], "."))
+	if len(parts) == 1 {
+		parent, ok = doc, true
+	}
+	if !ok {
+		return
+	}
+	if m, isDoc := parent.(bson.M); isDoc {
+		delete(m, parts[len(parts)-1])
+	}
+}
+
+func incPath(doc bson.M, path string, delta any) error {
+	d, ok := toFloat(delta)
+	if !ok {
+		return fmt.Errorf("store: $inc requires a number for %s", path)
+	}
+
+	current, exists := lookupPath(doc, path)
+	if !exists || current == nil {
+		return setPath(doc, path, delta)
+	}
+
+	c, ok := toFloat(current)
+	if !ok {
+		return fmt.Errorf("store: cannot $inc non-numeric field %s", path)
+	}
+
+	_, currentFloat := current.(float64)
+	_, deltaFloat := delta.(float64)
+	if currentFloat || deltaFloat {
+		return setPath(doc, path, c+d)
+	}
+	// Like Mongo, int32 stays int32 unless either side is int64 or it overflows
+	sum := int64(c) + int64(d)
+	_, currentInt32 := current.(int32)
+	_, deltaInt32 := delta.(int32)
+	if currentInt32 && deltaInt32 && sum == int64(int32(sum)) {
+		return setPath(doc, path, int32(sum))
+	}
+	return setPath(doc, path, sum)
+}
+
+func pushPath(doc bson.M, path string, value any, unique bool) error {
+	items := primitive.A{value}
+	if mods, ok := isOperatorDocument(value); ok {
+		each, isList := mods["$each"].(primitive.A)
+		if !isList {
+			return fmt.Errorf("store: unsupported modifier for %s", path)
+		}
+		items = each
+	}
+
+	current, exists := lookupPath(doc, path)
+	var list primitive.A
+	if exists && current != nil {
+		existing, ok := current.(primitive.A)
+		if !ok {
+			return fmt.Errorf("store: %s is not an array", path)
+		}
+		list = existing
+	}
+
+	for _, item := range items {
+		if unique && equalsMatch(list, true, item) {
+			continue
+		}
+		list = append(list, item)
+	}
+	return setPath(doc, path, list)
+}
+
+func pullPath(doc bson.M, path string, cond any) error {
+	current, exists := lookupPath(doc, path)
+	if !exists || current == nil {
+		return nil
+	}
+	list, ok := current.(primitive.A)
+	if !ok {
+		return fmt.Errorf("store: %s is not an array", path)
+	}
+
+	kept := primitive.A{}
+	for _, item := range list {
+		var remove bool
+		if sub, isDoc := cond.(bson.M); isDoc {
+			if _, isOps := isOperatorDocument(sub); isOps {
+				matched, err := matchCondition(item, true, sub)
+				if err != nil {
+					return err
+				}
+				remove = matched
+			} else if itemDoc, ok := item.(bson.M); ok {
+				matched, err := matchDocument(itemDoc, sub)
+				if err != nil {
+					return err
+				}
+				remove = matched
+			}
+		} else {
+			remove = valuesEqual(item, cond)
+		}
+		if !remove {
+			kept = append(kept, item)
+		}
+	}
+	return setPath(doc, path, kept)
+}
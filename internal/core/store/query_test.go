@@ -0,0 +1,286 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The expectations below follow what a MongoDB server returns for the same
+// documents and queries.
+
+func mustDocument(t *testing.T, v any) bson.M {
+	t.Helper()
+	doc, err := toDocument(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestMatchDocument(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+	doc := bson.M{
+		"_id":     id,
+		"name":    "Ada",
+		"age":     int32(36),
+		"score":   12.5,
+		"active":  true,
+		"nothing": nil,
+		"tags":    []string{"admin", "dev"},
+		"created": day,
+		"address": bson.M{"city": "London", "zip": "N1"},
+		"orders": []bson.M{
+			{"sku": "a", "qty": int64(2)},
+			{"sku": "b", "qty": int64(5)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		filter bson.M
+		want   bool
+	}{
+		{"empty filter", bson.M{}, true},
+		{"implicit equals", bson.M{"name": "Ada"}, true},
+		{"implicit equals mismatch", bson.M{"name": "Bob"}, false},
+		{"object id", bson.M{"_id": id}, true},
+		{"numbers of different widths", bson.M{"age": int64(36)}, true},
+		{"int equals float", bson.M{"age": 36.0}, true},
+		{"string is not a number", bson.M{"age": "36"}, false},
+		{"null matches missing", bson.M{"missing": nil}, true},
+		{"null matches null", bson.M{"nothing": nil}, true},
+		{"null does not match a value", bson.M{"name": nil}, false},
+		{"array contains", bson.M{"tags": "dev"}, true},
+		{"array whole", bson.M{"tags": []string{"admin", "dev"}}, true},
+		{"array order matters", bson.M{"tags": []string{"dev", "admin"}}, false},
+
+		{"$eq", bson.M{"name": bson.M{"$eq": "Ada"}}, true},
+		{"$ne", bson.M{"name": bson.M{"$ne": "Bob"}}, true},
+		{"$ne on equal", bson.M{"name": bson.M{"$ne": "Ada"}}, false},
+		{"$ne matches missing", bson.M{"missing": bson.M{"$ne": "x"}}, true},
+		{"$ne on array element", bson.M{"tags": bson.M{"$ne": "dev"}}, false},
+
+		{"$gt", bson.M{"age": bson.M{"$gt": int32(35)}}, true},
+		{"$gt equal", bson.M{"age": bson.M{"$gt": int32(36)}}, false},
+		{"$gte", bson.M{"age": bson.M{"$gte": int32(36)}}, true},
+		{"$lt", bson.M{"score": bson.M{"$lt": 13}}, true},
+		{"$lte", bson.M{"score": bson.M{"$lte": 12.5}}, true},
+		{"$lte below", bson.M{"score": bson.M{"$lte": 12}}, false},
+		{"range on dates", bson.M{"created": bson.M{"$gte": day, "$lt": day.Add(time.Hour)}}, true},
+		{"range outside dates", bson.M{"created": bson.M{"$gt": day}}, false},
+		{"range across types", bson.M{"name": bson.M{"$gt": 1}}, false},
+		{"range on missing", bson.M{"missing": bson.M{"$lt": 10}}, false},
+		{"range on strings", bson.M{"name": bson.M{"$gte": "A", "$lt": "B"}}, true},
+		{"range on array element", bson.M{"orders.qty": bson.M{"$gt": 4}}, true},
+
+		{"$in", bson.M{"name": bson.M{"$in": []string{"Bob", "Ada"}}}, true},
+		{"$in none", bson.M{"name": bson.M{"$in": []string{"Bob"}}}, false},
+		{"$in array element", bson.M{"tags": bson.M{"$in": []string{"ops", "dev"}}}, true},
+		{"$in null matches missing", bson.M{"missing": bson.M{"$in": []any{nil}}}, true},
+		{"$nin", bson.M{"name": bson.M{"$nin": []string{"Bob"}}}, true},
+		{"$nin listed", bson.M{"name": bson.M{"$nin": []string{"Ada"}}}, false},
+		{"$nin matches missing", bson.M{"missing": bson.M{"$nin": []string{"x"}}}, true},
+
+		{"$exists true", bson.M{"name": bson.M{"$exists": true}}, true},
+		{"$exists true on null", bson.M{"nothing": bson.M{"$exists": true}}, true},
+		{"$exists false", bson.M{"missing": bson.M{"$exists": false}}, true},
+		{"$exists false on present", bson.M{"name": bson.M{"$exists": false}}, false},
+		{"$exists on dotted path", bson.M{"address.zip": bson.M{"$exists": true}}, true},
+
+		{"$regex", bson.M{"name": bson.M{"$regex": "^A"}}, true},
+		{"$regex case sensitive", bson.M{"name": bson.M{"$regex": "^a"}}, false},
+		{"$regex $options", bson.M{"name": bson.M{"$regex": "^a", "$options": "i"}}, true},
+		{"$regex array element", bson.M{"tags": bson.M{"$regex": "^ad"}}, true},
+		{"$regex non string", bson.M{"age": bson.M{"$regex": "3"}}, false},
+		{"$not", bson.M{"age": bson.M{"$not": bson.M{"$gt": 40}}}, true},
+		{"$not matches missing", bson.M{"missing": bson.M{"$not": bson.M{"$gt": 40}}}, true},
+		{"$size", bson.M{"tags": bson.M{"$size": 2}}, true},
+		{"$size mismatch", bson.M{"tags": bson.M{"$size": 1}}, false},
+		{"several operators", bson.M{"age": bson.M{"$gt": 30, "$lt": 40}}, true},
+		{"several fields", bson.M{"name": "Ada", "active": false}, false},
+
+		{"dotted path", bson.M{"address.city": "London"}, true},
+		{"dotted path missing", bson.M{"address.country": nil}, true},
+		{"embedded document", bson.M{"address": bson.M{"city": "London", "zip": "N1"}}, true},
+		{"array index", bson.M{"tags.1": "dev"}, true},
+		{"array index out of range", bson.M{"tags.5": bson.M{"$exists": true}}, false},
+		{"array of documents", bson.M{"orders.sku": "b"}, true},
+		{"array of documents index", bson.M{"orders.0.sku": "b"}, false},
+
+		{"$and", bson.M{"$and": []bson.M{{"name": "Ada"}, {"age": int32(36)}}}, true},
+		{"$and one fails", bson.M{"$and": []bson.M{{"name": "Ada"}, {"age": int32(1)}}}, false},
+		{"$or", bson.M{"$or": []bson.M{{"name": "Bob"}, {"age": int32(36)}}}, true},
+		{"$or none", bson.M{"$or": []bson.M{{"name": "Bob"}, {"age": int32(1)}}}, false},
+		{"$nor", bson.M{"$nor": []bson.M{{"name": "Bob"}, {"age": int32(1)}}}, true},
+		{"$nor one matches", bson.M{"$nor": []bson.M{{"name": "Ada"}}}, false},
+	}
+
+	stored := mustDocument(t, doc)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchDocument(stored, mustDocument(t, tt.filter))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("match %v = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter bson.M
+	}{
+		{"unknown operator", bson.M{"a": bson.M{"$near": 1}}},
+		{"$in without array", bson.M{"a": bson.M{"$in": 1}}},
+		{"$regex without string", bson.M{"a": bson.M{"$regex": 1}}},
+		{"invalid regex", bson.M{"a": bson.M{"$regex": "("}}},
+		{"$or without array", bson.M{"$or": bson.M{"a": 1}}},
+	}
+
+	stored := mustDocument(t, bson.M{"a": "x"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := matchDocument(stored, mustDocument(t, tt.filter)); err == nil {
+				t.Errorf("match %v succeeded, want an error", tt.filter)
+			}
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	base := bson.M{
+		"name":  "Ada",
+		"count": int32(1),
+		"ratio": 0.5,
+		"tags":  []string{"a", "b"},
+		"items": []bson.M{{"k": "x", "n": int32(1)}, {"k": "y", "n": int32(9)}},
+		"meta":  bson.M{"level": int32(1)},
+	}
+
+	tests := []struct {
+		name   string
+		update bson.M
+		want   bson.M // fields compared after the update
+	}{
+		{"$set", bson.M{"$set": bson.M{"name": "Bob"}}, bson.M{"name": "Bob"}},
+		{"$set new field", bson.M{"$set": bson.M{"email": "a@x"}}, bson.M{"email": "a@x"}},
+		{"$set dotted path", bson.M{"$set": bson.M{"meta.level": int32(2)}}, bson.M{"meta": bson.M{"level": int32(2)}}},
+		{"$set creates documents", bson.M{"$set": bson.M{"a.b.c": true}}, bson.M{"a": bson.M{"b": bson.M{"c": true}}}},
+		{"$set array index", bson.M{"$set": bson.M{"tags.1": "z"}}, bson.M{"tags": primitive.A{"a", "z"}}},
+		{"$unset", bson.M{"$unset": bson.M{"name": ""}}, bson.M{"name": nil}},
+		{"$unset dotted path", bson.M{"$unset": bson.M{"meta.level": ""}}, bson.M{"meta": bson.M{}}},
+		{"$unset missing", bson.M{"$unset": bson.M{"nope.deeper": ""}}, bson.M{"nope": nil}},
+		{"$inc", bson.M{"$inc": bson.M{"count": int32(2)}}, bson.M{"count": int32(3)}},
+		{"$inc negative", bson.M{"$inc": bson.M{"count": int32(-1)}}, bson.M{"count": int32(0)}},
+		{"$inc int32 by int64", bson.M{"$inc": bson.M{"count": int64(2)}}, bson.M{"count": int64(3)}},
+		{"$inc int32 overflow", bson.M{"$inc": bson.M{"count": int32(2147483647)}}, bson.M{"count": int64(2147483648)}},
+		{"$inc float", bson.M{"$inc": bson.M{"ratio": 0.25}}, bson.M{"ratio": 0.75}},
+		{"$inc int by float", bson.M{"$inc": bson.M{"count": 0.5}}, bson.M{"count": 1.5}},
+		{"$inc missing field", bson.M{"$inc": bson.M{"version": int32(1)}}, bson.M{"version": int32(1)}},
+		{"$inc dotted path", bson.M{"$inc": bson.M{"meta.level": int32(4)}}, bson.M{"meta": bson.M{"level": int32(5)}}},
+		{"$push", bson.M{"$push": bson.M{"tags": "a"}}, bson.M{"tags": primitive.A{"a", "b", "a"}}},
+		{"$push $each", bson.M{"$push": bson.M{"tags": bson.M{"$each": []string{"c", "d"}}}}, bson.M{"tags": primitive.A{"a", "b", "c", "d"}}},
+		{"$push missing field", bson.M{"$push": bson.M{"list": int32(1)}}, bson.M{"list": primitive.A{int32(1)}}},
+		{"$addToSet", bson.M{"$addToSet": bson.M{"tags": "a"}}, bson.M{"tags": primitive.A{"a", "b"}}},
+		{"$addToSet new", bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": []string{"b", "c"}}}}, bson.M{"tags": primitive.A{"a", "b", "c"}}},
+		{"$pull value", bson.M{"$pull": bson.M{"tags": "a"}}, bson.M{"tags": primitive.A{"b"}}},
+		{"$pull condition", bson.M{"$pull": bson.M{"items": bson.M{"n": bson.M{"$gt": 5}}}}, bson.M{"items": primitive.A{bson.M{"k": "x", "n": int32(1)}}}},
+		{"$pull document", bson.M{"$pull": bson.M{"items": bson.M{"k": "x"}}}, bson.M{"items": primitive.A{bson.M{"k": "y", "n": int32(9)}}}},
+		{"$pull missing field", bson.M{"$pull": bson.M{"nope": "a"}}, bson.M{"nope": nil}},
+		{"several operators", bson.M{"$set": bson.M{"name": "Eve"}, "$inc": bson.M{"count": int32(1)}}, bson.M{"name": "Eve", "count": int32(2)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustDocument(t, base)
+			if err := applyUpdate(doc, mustDocument(t, tt.update)); err != nil {
+				t.Fatal(err)
+			}
+			for field, want := range tt.want {
+				got, exists := doc[field]
+				if want == nil {
+					if exists {
+						t.Errorf("%s = %v, want it unset", field, got)
+					}
+					continue
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %#v, want %#v", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyUpdateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		update bson.M
+	}{
+		{"empty update", bson.M{}},
+		{"unknown operator", bson.M{"$rename": bson.M{"a": "b"}}},
+		{"operator without document", bson.M{"$set": "a"}},
+		{"$inc non-numeric field", bson.M{"$inc": bson.M{"name": int32(1)}}},
+		{"$inc by a string", bson.M{"$inc": bson.M{"count": "1"}}},
+		{"$push on a scalar", bson.M{"$push": bson.M{"name": "x"}}},
+		{"$set into a scalar", bson.M{"$set": bson.M{"name.first": "x"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := mustDocument(t, bson.M{"name": "Ada", "count": int32(1)})
+			if err := applyUpdate(doc, mustDocument(t, tt.update)); err == nil {
+				t.Errorf("update %v succeeded, want an error", tt.update)
+			}
+		})
+	}
+}
+
+func TestSortDocuments(t *testing.T) {
+	docs := func() []bson.M {
+		return []bson.M{
+			mustDocument(t, bson.M{"_id": "a", "n": int32(2), "s": "b", "meta": bson.M{"rank": int32(3)}}),
+			mustDocument(t, bson.M{"_id": "b", "n": 1.5, "s": "a", "meta": bson.M{"rank": int32(1)}}),
+			mustDocument(t, bson.M{"_id": "c", "s": "a"}),
+			mustDocument(t, bson.M{"_id": "d", "n": "text", "s": "c", "meta": bson.M{"rank": int32(2)}}),
+			mustDocument(t, bson.M{"_id": "e", "n": int64(2), "s": "a"}),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		order bson.D
+		want  []string
+	}{
+		{"no order keeps insertion order", nil, []string{"a", "b", "c", "d", "e"}},
+		// Missing sorts as null, before numbers, which sort before strings
+		{"ascending across types", bson.D{{Key: "n", Value: 1}}, []string{"c", "b", "a", "e", "d"}},
+		{"descending across types", bson.D{{Key: "n", Value: -1}}, []string{"d", "a", "e", "b", "c"}},
+		{"ties keep insertion order", bson.D{{Key: "s", Value: 1}}, []string{"b", "c", "e", "a", "d"}},
+		{"second key breaks ties", bson.D{{Key: "s", Value: 1}, {Key: "_id", Value: -1}}, []string{"e", "c", "b", "a", "d"}},
+		{"dotted path", bson.D{{Key: "meta.rank", Value: 1}}, []string{"c", "e", "b", "d", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted := docs()
+			sortDocuments(sorted, tt.order)
+			got := make([]string, len(sorted))
+			for i, doc := range sorted {
+				got[i] = doc["_id"].(string)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrNotFound is returned by FindOne / FindOneAndUpdate when nothing matches.
+var ErrNotFound = errors.New("document not found")
+
+// ErrDuplicateKey is returned when inserting a document whose _id already exists.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// FindOptions controls ordering and paging of Find.
+type FindOptions struct {
+	Sort  bson.D // e.g. bson.D{{Key: "createdAt", Value: -1}}
+	Skip  int64
+	Limit int64
+}
+
+// Repository is the storage contract for one set of documents (users,
+// projects, collections, records, ...). Filters and updates use the MongoDB
+// query language; every backend supports the same subset:
+//
+//	filters: equality, $eq $ne $gt $gte $lt $lte $in $nin $exists $regex $and $or, dotted paths
+//	updates: $set $unset $inc $push $pull $addToSet
+type Repository interface {
+	InsertOne(ctx context.Context, doc any) error
+	InsertMany(ctx context.Context, docs []any) error
+	FindOne(ctx context.Context, filter bson.M, out any) error
+	Find(ctx context.Context, filter bson.M, out any, opts ...FindOptions) error
+	CountDocuments(ctx context.Context, filter bson.M) (int64, error)
+	UpdateOne(ctx context.Context, filter, update bson.M) (matched int64, err error)
+	UpdateMany(ctx context.Context, filter, update bson.M) (matched int64, err error)
+	FindOneAndUpdate(ctx context.Context, filter, update bson.M, out any) error // decodes the updated document
+	DeleteOne(ctx context.Context, filter bson.M) (deleted int64, err error)
+	DeleteMany(ctx context.Context, filter bson.M) (deleted int64, err error)
+}
+
+// Store hands out repositories by name and owns the backend connection.
+type Store interface {
+	Repository(name string) Repository
+	Driver() string
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+const (
+	DriverMongo  = "mongo"
+	DriverMemory = "memory"
+	DriverFile   = "file"
+)
+
+// Open builds the store selected by cfg.StorageDriver.
+func Open(cfg *config.Config) (Store, error) {
+	switch cfg.StorageDriver {
+	case DriverMongo, "":
+		return NewMongoStore(cfg)
+	case DriverMemory:
+		return NewMemoryStore(), nil
+	case DriverFile:
+		return NewFileStore(cfg.StorageDir)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+}
+
+func findOptions(opts []FindOptions) FindOptions {
+	if len(opts) == 0 {
+		return FindOptions{}
+	}
+	return opts[0]
+}
@@ -0,0 +1,16 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// CreateAPIKeyRequest is the payload for creating a project API key
+type CreateAPIKeyRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Scope         string `json:"scope"`         // read-only (default), read-write or admin
+	ExpiresInDays int    `json:"expiresInDays"` // 0 = never expires
+}
+
+// CreateAPIKeyResponse returns the plain key; it cannot be retrieved again
+type CreateAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"apiKey"`
+}
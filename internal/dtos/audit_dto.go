@@ -0,0 +1,23 @@
+package dtos
+
+import "time"
+
+// Actor identifies who performs a change, for the audit log
+type Actor struct {
+	UserID    string // empty for anonymous callers
+	APIKeyID  string
+	IP        string
+	UserAgent string
+}
+
+// AuditQuery filters the audit log. Zero values are ignored.
+type AuditQuery struct {
+	Action     string    `form:"action"`
+	TargetType string    `form:"targetType"`
+	TargetID   string    `form:"targetId"`
+	ActorID    string    `form:"actorId"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit      int       `form:"limit"`
+	Skip       int       `form:"skip"`
+}
@@ -0,0 +1,95 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/saifwork/mock-service/internal/models"
+)
+
+// Bundle format markers. Version goes up when a change to the format would be
+// misread by older importers; version 2 added environments.
+const (
+	BundleFormat  = "mocknode.bundle"
+	BundleVersion = 2
+)
+
+// Import conflict modes, used when the target context already has a project
+// with the bundle's name
+const (
+	ImportRename    = "rename"    // import as a new project with a numbered name (default)
+	ImportOverwrite = "overwrite" // replace the content of the existing project
+	ImportMerge     = "merge"     // add what the existing project is missing
+)
+
+var ImportModes = []string{ImportRename, ImportOverwrite, ImportMerge}
+
+// ProjectBundle is a portable copy of a project, meant to be moved between
+// instances or kept in version control. Ids are the source instance's; they
+// let records and endpoints reference each other and are replaced on import.
+// Ids may be left out of hand-written bundles.
+type ProjectBundle struct {
+	Format      string             `json:"format"`
+	Version     int                `json:"version"`
+	ExportedAt  time.Time          `json:"exportedAt"`
+	Project     BundleProject      `json:"project"`
+	Collections []BundleCollection `json:"collections"`
+	Endpoints   []BundleEndpoint   `json:"endpoints"`
+	Settings    BundleSettings     `json:"settings"`
+}
+
+type BundleProject struct {
+	ID           string   `json:"id,omitempty"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Environments []string `json:"environments,omitempty"` // besides the default one
+}
+
+type BundleCollection struct {
+	ID            string                   `json:"id,omitempty"`
+	Name          string                   `json:"name"`
+	Fields        []models.FieldDefinition `json:"fields"`
+	Rules         []models.ResponseRule    `json:"rules,omitempty"`
+	RevisionLimit *int                     `json:"revisionLimit,omitempty"`
+	Records       []BundleRecord           `json:"records"`
+}
+
+type BundleRecord struct {
+	ID          string         `json:"id,omitempty"`
+	Data        map[string]any `json:"data"`
+	Environment string         `json:"environment,omitempty"` // empty for the default environment
+	CreatedAt   *time.Time     `json:"createdAt,omitempty"`
+	UpdatedAt   *time.Time     `json:"updatedAt,omitempty"`
+}
+
+type BundleEndpoint struct {
+	ID          string                `json:"id,omitempty"`
+	Method      string                `json:"method"`
+	Path        string                `json:"path"`
+	Description string                `json:"description,omitempty"`
+	Rules       []models.ResponseRule `json:"rules"`
+	Response    models.MockResponse   `json:"response"`
+}
+
+// BundleSettings holds project settings that are not part of a collection.
+// Webhook secrets are not exported; imported webhooks get new ones.
+type BundleSettings struct {
+	Webhooks []BundleWebhook `json:"webhooks"`
+}
+
+type BundleWebhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+}
+
+// ImportResult describes what an import created. Secrets of the imported
+// webhooks are only part of this response.
+type ImportResult struct {
+	Project     *models.Project         `json:"project"`
+	Mode        string                  `json:"mode"`
+	Collections int                     `json:"collections"`
+	Records     int                     `json:"records"`
+	Endpoints   int                     `json:"endpoints"`
+	Webhooks    []CreateWebhookResponse `json:"webhooks"`
+	Skipped     []string                `json:"skipped"` // merged items the project already had
+}
@@ -0,0 +1,37 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/saifwork/mock-service/internal/models"
+)
+
+// ChangeEvent is pushed to change stream subscribers when a record is written.
+// IDs increase per project, so a reconnecting client can resume after the
+// last one it saw.
+type ChangeEvent struct {
+	ID           int64          `json:"id,omitempty"`
+	Type         string         `json:"type"` // record.created, record.updated or record.deleted
+	ProjectID    string         `json:"projectId"`
+	CollectionID string         `json:"collectionId"`
+	Collection   string         `json:"collection"`
+	RecordID     string         `json:"recordId"`
+	Record       *models.Record `json:"record"` // the record as deleted, for record.deleted
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+// ChangeFilter narrows a change stream; empty fields match everything
+type ChangeFilter struct {
+	Types       string `form:"types"`       // comma-separated, e.g. "created,deleted" or "record.updated"
+	Collection  string `form:"collection"`  // collection name or id, on project streams
+	RecordID    string `form:"recordId"`    // a single record
+	LastEventID int64  `form:"lastEventId"` // resume after this event; the Last-Event-ID header also works
+}
+
+// ChangeTicket lets browsers open a change stream, since EventSource and
+// WebSocket cannot send an Authorization header. It goes in the ticket query
+// parameter of the stream URL and only works for the project it was issued for.
+type ChangeTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,17 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// EndpointRequestDto is the payload for creating or replacing a custom endpoint
+type EndpointRequestDto struct {
+	Method      string                `json:"method" binding:"required"`
+	Path        string                `json:"path" binding:"required"`
+	Description string                `json:"description"`
+	Rules       []models.ResponseRule `json:"rules"`
+	Response    models.MockResponse   `json:"response"`
+}
+
+// CollectionRulesRequestDto replaces the response rules of a collection route
+type CollectionRulesRequestDto struct {
+	Rules []models.ResponseRule `json:"rules"`
+}
@@ -0,0 +1,47 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// EnvironmentHeader selects the environment of a mock request, as an
+// alternative to an /@name path segment
+const EnvironmentHeader = "X-Mock-Env"
+
+// CreateEnvironmentRequest adds a named record set to a project
+type CreateEnvironmentRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// PromoteRequest copies the records of one environment over another. Without
+// collections every collection is promoted; they are given by name or id.
+type PromoteRequest struct {
+	From        string   `json:"from" form:"from" binding:"required"`
+	To          string   `json:"to" form:"to" binding:"required"`
+	Collections []string `json:"collections" form:"collections"`
+}
+
+// PromotionDiff describes what a promotion changes, or changed, in the target environment
+type PromotionDiff struct {
+	From        string                    `json:"from"`
+	To          string                    `json:"to"`
+	Applied     bool                      `json:"applied"`
+	Collections []CollectionPromotionDiff `json:"collections"`
+}
+
+// CollectionPromotionDiff lists the records of the target environment a
+// promotion creates, updates and deletes in one collection. Created holds the
+// ids of the source records.
+type CollectionPromotionDiff struct {
+	CollectionID string           `json:"collectionId"`
+	Name         string           `json:"name"`
+	Created      []string         `json:"created"`
+	Updated      []PromotedRecord `json:"updated"`
+	Deleted      []string         `json:"deleted"`
+	Unchanged    int              `json:"unchanged"`
+}
+
+// PromotedRecord is a target record whose data a promotion replaces
+type PromotedRecord struct {
+	RecordID string               `json:"recordId"`
+	SourceID string               `json:"sourceId"`
+	Changes  []models.AuditChange `json:"changes"`
+}
@@ -0,0 +1,26 @@
+package dtos
+
+// HARImportQuery holds the filters and options of a HAR import
+type HARImportQuery struct {
+	Hosts      string `form:"hosts"`                                               // comma separated, e.g. api.example.com,*.example.org
+	Paths      string `form:"paths"`                                               // comma separated path prefixes, e.g. /api,/v2/users
+	Latency    bool   `form:"latency"`                                             // replay the recorded server time as delayMs
+	OnConflict string `form:"onConflict" binding:"omitempty,oneof=skip overwrite"` // for endpoints the project already has
+}
+
+// HARSkippedEntry is an entry of the HAR that was not imported, and why
+type HARSkippedEntry struct {
+	Entry   int    `json:"entry"`   // index in log.entries
+	Request string `json:"request"` // method and URL
+	Reason  string `json:"reason"`
+}
+
+// HARImportResult describes what a HAR import did to a project
+type HARImportResult struct {
+	Created    int               `json:"created"`
+	Updated    int               `json:"updated"`
+	Duplicates int               `json:"duplicates"` // entries repeating an earlier request, the last one kept
+	Filtered   int               `json:"filtered"`   // entries left out by hosts and paths
+	Endpoints  []string          `json:"endpoints"`  // method and path of every endpoint written
+	Skipped    []HARSkippedEntry `json:"skipped"`
+}
@@ -0,0 +1,24 @@
+package dtos
+
+import "time"
+
+// InviteMemberRequest invites someone by email to a project
+type InviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"` // owner, editor or viewer; defaults to viewer
+}
+
+// UpdateMemberRoleRequest changes the role of an existing member
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ProjectMemberResponse is a member with the user details needed by the UI
+type ProjectMemberResponse struct {
+	UserID   string    `json:"userId"`
+	FullName string    `json:"fullName"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	AddedAt  time.Time `json:"addedAt"`
+	IsOwner  bool      `json:"isOwner"` // the project creator, who cannot be removed
+}
@@ -0,0 +1,24 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// OpenAPIImportQuery holds the options of an OpenAPI import
+type OpenAPIImportQuery struct {
+	Name    string `form:"name"`                                      // defaults to the spec's title
+	Records int    `form:"records" binding:"omitempty,min=0,max=100"` // records per collection, examples first
+}
+
+// OpenAPIIssue is a part of a spec the import could not map, and what it did instead
+type OpenAPIIssue struct {
+	Location string `json:"location"` // e.g. components.schemas.Pet.properties.owner
+	Message  string `json:"message"`
+}
+
+// OpenAPIImportResult describes the project generated from a spec
+type OpenAPIImportResult struct {
+	Project     *models.Project `json:"project"`
+	Collections []string        `json:"collections"`
+	Endpoints   int             `json:"endpoints"`
+	Records     int             `json:"records"`
+	Report      []OpenAPIIssue  `json:"report"`
+}
@@ -0,0 +1,33 @@
+package dtos
+
+import "time"
+
+// OrganizationRequest creates or renames an organization
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateOrgPlanRequest switches the plan of an organization
+type UpdateOrgPlanRequest struct {
+	Plan string `json:"plan" binding:"required"` // free or pro
+}
+
+// AddOrgMemberRequest adds a registered user to an organization by email
+type AddOrgMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"` // owner, admin or member; defaults to member
+}
+
+// UpdateOrgMemberRoleRequest changes the role of an organization member
+type UpdateOrgMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// OrgMemberResponse is an organization member with the user details needed by the UI
+type OrgMemberResponse struct {
+	UserID   string    `json:"userId"`
+	FullName string    `json:"fullName"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	AddedAt  time.Time `json:"addedAt"`
+}
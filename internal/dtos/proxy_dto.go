@@ -0,0 +1,14 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// ProxyRequest changes the record-and-replay setup of a project; fields left
+// out keep their current value
+type ProxyRequest struct {
+	Mode          string             `json:"mode" binding:"required"`
+	UpstreamURL   string             `json:"upstreamUrl"`
+	Target        string             `json:"target"`     // endpoints or records
+	Collection    string             `json:"collection"` // records target
+	Match         *models.ProxyMatch `json:"match"`
+	RedactHeaders []string           `json:"redactHeaders"`
+}
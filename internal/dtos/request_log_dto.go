@@ -0,0 +1,32 @@
+package dtos
+
+// RequestLogEntry is one captured mock request, stored in Redis as JSON
+type RequestLogEntry struct {
+	ID            string            `json:"id"`
+	ProjectID     string            `json:"projectId"`
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	Query         string            `json:"query,omitempty"`
+	Headers       map[string]string `json:"headers"`
+	Body          string            `json:"body,omitempty"`
+	Status        int               `json:"status"`
+	LatencyMs     int64             `json:"latencyMs"`
+	ClientIP      string            `json:"clientIp"`
+	Timestamp     string            `json:"timestamp"`
+	BodyTruncated bool              `json:"bodyTruncated,omitempty"`
+}
+
+// RequestLogFilter narrows the captured request list
+type RequestLogFilter struct {
+	Method string `form:"method"`
+	Path   string `form:"path"`   // substring match
+	Status int    `form:"status"` // exact status code
+	Limit  int    `form:"limit"`
+}
+
+// RequestLogListResponse is returned by the inspector list endpoint
+type RequestLogListResponse struct {
+	Total    int64             `json:"total"` // all requests ever received by the project
+	Captured int               `json:"captured"`
+	Items    []RequestLogEntry `json:"items"`
+}
@@ -0,0 +1,24 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// RevisionDiffQuery selects the two sides of a revision diff: revision
+// numbers, or "current" for the record as it is now. To defaults to current.
+type RevisionDiffQuery struct {
+	From string `form:"from" binding:"required"`
+	To   string `form:"to"`
+}
+
+// RevisionDiff lists the top-level fields that differ between two revisions
+type RevisionDiff struct {
+	RecordID string               `json:"recordId"`
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	Changes  []models.AuditChange `json:"changes"`
+}
+
+// RevisionLimitRequest sets how many revisions a collection keeps per record.
+// A null limit restores the default.
+type RevisionLimitRequest struct {
+	RevisionLimit *int `json:"revisionLimit"`
+}
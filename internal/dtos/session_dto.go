@@ -12,6 +12,8 @@ type CreateSessionResponse struct {
 	SessionID     string `json:"sessionId"`
 	ProjectID     string `json:"projectId"` // 🔹 new: link session → project
 	ExpirySeconds int    `json:"expirySeconds"`
+	RequestLimit  int    `json:"requestLimit"`
+	MockBaseURL   string `json:"mockBaseUrl"`
 	Message       string `json:"message"`
 }
 
@@ -22,4 +24,12 @@ type SessionMeta struct {
 	CreatedAt string        `json:"createdAt"`
 	Expiry    time.Duration `json:"expiry"`   // in seconds
 	ReqCount  int           `json:"reqCount"` // total requests so far
+	ReqLimit  int           `json:"reqLimit"`
+	ExpiresAt string        `json:"expiresAt"`
+}
+
+// Request DTO to turn a session into a project owned by the caller
+type ConvertSessionRequest struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
 }
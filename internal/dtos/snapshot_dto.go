@@ -0,0 +1,40 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// CreateSnapshotRequest is the payload for taking a project snapshot
+type CreateSnapshotRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// RestoreSnapshotRequest limits a restore to some collections, by name or id.
+// Without collections the whole project is restored.
+type RestoreSnapshotRequest struct {
+	Collections []string `json:"collections"`
+}
+
+// SnapshotRestoreResult lists the collections a restore rewrote or removed
+type SnapshotRestoreResult struct {
+	Restored []string `json:"restored"`
+	Removed  []string `json:"removed"` // created after the snapshot, full restores only
+	Records  int      `json:"records"`
+}
+
+// SnapshotDiff compares a snapshot with the current state of its project
+type SnapshotDiff struct {
+	SnapshotID  string           `json:"snapshotId"`
+	Collections []CollectionDiff `json:"collections"`
+}
+
+// CollectionDiff describes how a collection changed since the snapshot.
+// Status is unchanged, modified, created or deleted (since the snapshot).
+type CollectionDiff struct {
+	CollectionID   string               `json:"collectionId"`
+	Name           string               `json:"name"`
+	Status         string               `json:"status"`
+	SchemaChanges  []models.AuditChange `json:"schemaChanges,omitempty"`
+	RecordsCreated []string             `json:"recordsCreated"`
+	RecordsUpdated []string             `json:"recordsUpdated"`
+	RecordsDeleted []string             `json:"recordsDeleted"`
+}
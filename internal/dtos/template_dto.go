@@ -0,0 +1,23 @@
+package dtos
+
+// DuplicateProjectRequest copies a project. Without includeData only the
+// schemas, rules and custom endpoints are copied.
+type DuplicateProjectRequest struct {
+	Name        string `json:"name"` // defaults to "<source name> (copy)"
+	Description string `json:"description"`
+	IncludeData bool   `json:"includeData"`
+}
+
+// PublishTemplateRequest publishes a project as a template
+type PublishTemplateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"` // private (default), org or public
+	IncludeData bool   `json:"includeData"`
+}
+
+// InstantiateTemplateRequest creates a project from a template
+type InstantiateTemplateRequest struct {
+	Name        string `json:"name"` // defaults to the template name
+	Description string `json:"description"`
+}
@@ -0,0 +1,10 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// ProjectTrash lists what was deleted inside a live project. Records trashed
+// with their collection are not listed; they come back with the collection.
+type ProjectTrash struct {
+	Collections []models.Collection `json:"collections"`
+	Records     []models.Record     `json:"records"`
+}
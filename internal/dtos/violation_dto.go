@@ -0,0 +1,58 @@
+package dtos
+
+import "time"
+
+// ValidationRequest switches the validation mode of a project
+type ValidationRequest struct {
+	Mode string `json:"mode" binding:"required,oneof=enforce observe"`
+}
+
+// ValidationSettings is the validation mode of a project
+type ValidationSettings struct {
+	Mode string `json:"mode"`
+}
+
+// ViolationQuery filters the logged violations of a project. Zero values are ignored.
+type ViolationQuery struct {
+	Collection string    `form:"collection"`
+	Field      string    `form:"field"`
+	Rule       string    `form:"rule"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit      int       `form:"limit"`
+	Skip       int       `form:"skip"`
+}
+
+// ViolationSummaryQuery picks the period and granularity of a violation summary
+type ViolationSummaryQuery struct {
+	Collection string    `form:"collection"`
+	Interval   string    `form:"interval" binding:"omitempty,oneof=hour day"` // defaults to day
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// ViolationBucket counts the violations of a field in one interval
+type ViolationBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// ViolationFieldSummary is how often one field broke its rules over a period
+type ViolationFieldSummary struct {
+	Collection string            `json:"collection"`
+	Field      string            `json:"field"`
+	Total      int               `json:"total"`
+	Rules      map[string]int    `json:"rules"` // by broken rule
+	FirstSeen  time.Time         `json:"firstSeen"`
+	LastSeen   time.Time         `json:"lastSeen"`
+	Series     []ViolationBucket `json:"series"` // one bucket per interval, oldest first
+}
+
+// ViolationSummary groups the violations of a period by field, most frequent first
+type ViolationSummary struct {
+	From     time.Time               `json:"from"`
+	To       time.Time               `json:"to"`
+	Interval string                  `json:"interval"`
+	Writes   int                     `json:"writes"` // record writes with violations in the period
+	Fields   []ViolationFieldSummary `json:"fields"`
+}
@@ -0,0 +1,22 @@
+package dtos
+
+import "github.com/saifwork/mock-service/internal/models"
+
+// CreateWebhookRequest is the payload for registering a webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+// UpdateWebhookRequest changes a webhook; omitted fields are kept
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// CreateWebhookResponse returns the signing secret; it cannot be retrieved again
+type CreateWebhookResponse struct {
+	Secret  string          `json:"secret"`
+	Webhook *models.Webhook `json:"webhook"`
+}
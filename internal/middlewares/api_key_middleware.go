@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// APIKeyMiddleware authenticates machine clients through the X-API-Key
+// header and falls back to AuthMiddleware (JWT) when no key is sent.
+//
+// A key only works on its own project (the :pid or :collectionId route
+// param) and acts on behalf of the user who created it. GET requests need
+// any scope; other methods need at least writeScope.
+func APIKeyMiddleware(cfg *config.Config, keys *services.APIKeyService, writeScope string) gin.HandlerFunc {
+	jwtAuth := AuthMiddleware(cfg)
+
+	return func(c *gin.Context) {
+		plain := c.GetHeader("X-API-Key")
+		if plain == "" {
+			jwtAuth(c)
+			return
+		}
+
+		key, err := keys.Authenticate(plain)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		projectID := c.Param("pid")
+		if cid := c.Param("collectionId"); projectID == "" && cid != "" {
+			pid, err := keys.CollectionProjectID(cid)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				c.Abort()
+				return
+			}
+			projectID = pid.Hex()
+		}
+		if projectID != key.ProjectID.Hex() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key is not valid for this project"})
+			c.Abort()
+			return
+		}
+
+		if !services.ScopeAllows(key, c.Request.Method, writeScope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key scope " + key.Scope + " does not allow this request"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userId", key.UserID.Hex())
+		c.Set("apiKeyId", key.ID.Hex())
+		c.Set("apiKeyScope", key.Scope)
+
+		c.Next()
+	}
+}
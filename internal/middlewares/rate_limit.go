@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saifwork/mock-service/internal/core/config"
+	"github.com/saifwork/mock-service/internal/services"
+)
+
+// RateLimit limits requests per client over a sliding window shared by all
+// instances through Redis. Clients are identified, in order, by the project
+// of a mock route, a valid API key, the JWT user and finally the client IP;
+// each gets the limit of its plan tier. If Redis is unavailable requests pass.
+func RateLimit(cfg *config.Config, limiter *services.RateLimitService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Enabled() || c.FullPath() == "/health" || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		identity, plan := rateLimitIdentity(c, cfg, limiter)
+
+		result, err := limiter.Allow(identity, plan)
+		if err != nil {
+			log.Printf("[RATELIMIT] Check failed for %s, allowing request: %v", identity, err)
+			c.Next()
+			return
+		}
+
+		resetSeconds := int(math.Ceil(result.Reset.Seconds()))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		c.Header("RateLimit-Policy", strconv.Itoa(result.Limit)+";w="+strconv.Itoa(int(result.Window.Seconds())))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(resetSeconds, 1)))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded, retry later"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitIdentity(c *gin.Context, cfg *config.Config, limiter *services.RateLimitService) (string, string) {
+	if pid := c.Param("pid"); pid != "" && c.FullPath() == "/mock/:pid/*path" {
+		return "project:" + pid, limiter.ProjectPlan(pid)
+	}
+
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		if keyID, plan, ok := limiter.APIKeyPlan(apiKey); ok {
+			return "apikey:" + keyID, plan
+		}
+		return "ip:" + c.ClientIP(), services.PlanAnonymous
+	}
+
+	if userID := bearerUserID(c, cfg); userID != "" {
+		return "user:" + userID, limiter.UserPlan(userID)
+	}
+
+	return "ip:" + c.ClientIP(), services.PlanAnonymous
+}
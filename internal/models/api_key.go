@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key scopes, from least to most privileged
+const (
+	APIKeyScopeReadOnly  = "read-only"  // GET requests only
+	APIKeyScopeReadWrite = "read-write" // plus record writes
+	APIKeyScopeAdmin     = "admin"      // plus collections, rules and endpoints
+)
+
+// APIKey grants machine access to a single project. Only a SHA-256 hash of
+// the key is stored; the plain key is shown once, when it is created.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID  primitive.ObjectID `bson:"projectId" json:"projectId"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"` // creator; requests act on their behalf
+	Name       string             `bson:"name" json:"name"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // first characters, to tell keys apart
+	KeyHash    string             `bson:"keyHash" json:"-"`
+	Scope      string             `bson:"scope" json:"scope"`
+	ExpiresAt  *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time         `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time         `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audited actions
+const (
+	AuditProjectCreate      = "project.create"
+	AuditProjectUpdate      = "project.update"
+	AuditProjectDelete      = "project.delete"
+	AuditProjectRestore     = "project.restore"
+	AuditProjectImport      = "project.import"
+	AuditCollectionCreate   = "collection.create"
+	AuditCollectionRules    = "collection.rules.update"
+	AuditCollectionUpdate   = "collection.update"
+	AuditCollectionDelete   = "collection.delete"
+	AuditCollectionRestore  = "collection.restore"
+	AuditRecordCreate       = "record.create"
+	AuditRecordUpdate       = "record.update"
+	AuditRecordDelete       = "record.delete"
+	AuditRecordRestore      = "record.restore"
+	AuditSnapshotCreate     = "snapshot.create"
+	AuditSnapshotRestore    = "snapshot.restore"
+	AuditSnapshotDelete     = "snapshot.delete"
+	AuditTemplatePublish    = "template.publish"
+	AuditTemplateDelete     = "template.delete"
+	AuditEnvironmentCreate  = "environment.create"
+	AuditEnvironmentDelete  = "environment.delete"
+	AuditEnvironmentPromote = "environment.promote"
+	AuditProxyUpdate        = "proxy.update"
+	AuditValidationUpdate   = "validation.update"
+	AuditLogin              = "auth.login"
+	AuditLoginFailed        = "auth.login.failed"
+	AuditPasswordChange     = "auth.password.change"
+	AuditPasswordReset      = "auth.password.reset"
+)
+
+// AuditEvent is an append-only entry describing who changed what
+type AuditEvent struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Action     string              `bson:"action" json:"action"`
+	TargetType string              `bson:"targetType" json:"targetType"` // project, collection, record, snapshot, template, environment, proxy or user
+	TargetID   string              `bson:"targetId" json:"targetId"`
+	ProjectID  *primitive.ObjectID `bson:"projectId,omitempty" json:"projectId,omitempty"`
+	ActorID    *primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"` // nil for anonymous callers (mock routes, sandboxes)
+	APIKeyID   string              `bson:"apiKeyId,omitempty" json:"apiKeyId,omitempty"`
+	IP         string              `bson:"ip" json:"ip"`
+	UserAgent  string              `bson:"userAgent" json:"userAgent"`
+	Changes    []AuditChange       `bson:"changes,omitempty" json:"changes,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// AuditChange is the before and after value of one changed field
+type AuditChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before,omitempty" json:"before,omitempty"`
+	After  any    `bson:"after,omitempty" json:"after,omitempty"`
+}
@@ -21,10 +21,14 @@ type FieldDefinition struct {
 }
 
 type Collection struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	ProjectID primitive.ObjectID `bson:"projectId" json:"projectId"`
-	Name      string             `bson:"name" json:"name"`
-	Fields    []FieldDefinition  `bson:"fields" json:"fields"`
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ProjectID     primitive.ObjectID  `bson:"projectId" json:"projectId"`
+	Name          string              `bson:"name" json:"name"`
+	Fields        []FieldDefinition   `bson:"fields" json:"fields"`
+	Rules         []ResponseRule      `bson:"rules,omitempty" json:"rules,omitempty"`                 // evaluated on mock routes before CRUD
+	RevisionLimit *int                `bson:"revisionLimit,omitempty" json:"revisionLimit,omitempty"` // revisions kept per record; nil uses the default, 0 keeps none
+	DeletedAt     *time.Time          `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`         // set while the collection is in the trash
+	DeletedWith   *primitive.ObjectID `bson:"deletedWith,omitempty" json:"deletedWith,omitempty"`     // project whose deletion trashed it
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockResponse describes what a mock route sends back to the caller.
+type MockResponse struct {
+	Status  int               `bson:"status" json:"status"`
+	Headers map[string]string `bson:"headers,omitempty" json:"headers,omitempty"`
+	Body    any               `bson:"body,omitempty" json:"body,omitempty"`
+	DelayMs int               `bson:"delayMs,omitempty" json:"delayMs,omitempty"`
+}
+
+// RuleCondition is a single check against an incoming mock request.
+type RuleCondition struct {
+	Source   string `bson:"source" json:"source"`                   // method, param, query, header, body
+	Key      string `bson:"key,omitempty" json:"key,omitempty"`     // name or dotted JSON path for body
+	Operator string `bson:"operator" json:"operator"`               // equals, notEquals, regex, exists, notExists, gt, gte, lt, lte
+	Value    any    `bson:"value,omitempty" json:"value,omitempty"` // compared value (unused by exists/notExists)
+}
+
+// ResponseRule picks a response variant when all of its conditions match.
+type ResponseRule struct {
+	Name       string          `bson:"name,omitempty" json:"name,omitempty"`
+	Conditions []RuleCondition `bson:"conditions" json:"conditions"`
+	Response   MockResponse    `bson:"response" json:"response"`
+}
+
+// Endpoint is a user-defined mock route inside a project.
+type Endpoint struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID   primitive.ObjectID `bson:"projectId" json:"projectId"`
+	Method      string             `bson:"method" json:"method"`
+	Path        string             `bson:"path" json:"path"` // e.g. /users/:id/orders
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Rules       []ResponseRule     `bson:"rules" json:"rules"`
+	Response    MockResponse       `bson:"response" json:"response"` // fallback when no rule matches
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Project roles, from most to least privileged. The creator in Project.UserID
+// is always an owner.
+const (
+	RoleOwner  = "owner"  // manage members, API keys and delete the project
+	RoleEditor = "editor" // change collections, records and endpoints
+	RoleViewer = "viewer" // read only
+)
+
+// Invitation states
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// ProjectMember is a collaborator embedded in a project
+type ProjectMember struct {
+	UserID  primitive.ObjectID `bson:"userId" json:"userId"`
+	Role    string             `bson:"role" json:"role"`
+	AddedAt time.Time          `bson:"addedAt" json:"addedAt"`
+}
+
+// ProjectInvitation invites an email address to join a project with a role
+type ProjectInvitation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID   primitive.ObjectID `bson:"projectId" json:"projectId"`
+	ProjectName string             `bson:"projectName" json:"projectName"`
+	Email       string             `bson:"email" json:"email"`
+	Role        string             `bson:"role" json:"role"`
+	Token       string             `bson:"token" json:"-"`
+	InvitedBy   primitive.ObjectID `bson:"invitedBy" json:"invitedBy"`
+	Status      string             `bson:"status" json:"status"`
+	ExpiresAt   time.Time          `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	RespondedAt *time.Time         `bson:"respondedAt,omitempty" json:"respondedAt,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization roles. Owners and admins are owners of every project of the
+// organization; members are editors.
+const (
+	OrgRoleOwner  = "owner"  // billing, plan and organization deletion
+	OrgRoleAdmin  = "admin"  // manage members and settings
+	OrgRoleMember = "member" // work on the organization's projects
+)
+
+// Organization is a team workspace that owns projects and holds the paid plan
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Plan      string             `bson:"plan" json:"plan"` // free or pro
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	Members   []OrgMember        `bson:"members" json:"members"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// OrgMember is a user embedded in an organization
+type OrgMember struct {
+	UserID  primitive.ObjectID `bson:"userId" json:"userId"`
+	Role    string             `bson:"role" json:"role"`
+	AddedAt time.Time          `bson:"addedAt" json:"addedAt"`
+}
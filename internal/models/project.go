@@ -6,11 +6,24 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// DefaultEnvironment is the record set of a project that every project has.
+// Its records carry no environment.
+const DefaultEnvironment = "default"
+
 type Project struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
-	Name        string             `bson:"name" json:"name"`
-	Description string             `bson:"description" json:"description"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID  `bson:"userId" json:"userId"`
+	OrgID          *primitive.ObjectID `bson:"orgId,omitempty" json:"orgId,omitempty"` // owning organization, nil for personal projects
+	Name           string              `bson:"name" json:"name"`
+	Description    string              `bson:"description" json:"description"`
+	SessionID      string              `bson:"sessionId,omitempty" json:"-"`                         // set for anonymous sandbox projects
+	ExpiresAt      *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`       // sandbox expiry, nil for owned projects
+	Members        []ProjectMember     `bson:"members,omitempty" json:"members,omitempty"`           // collaborators besides the owner
+	Environments   []string            `bson:"environments,omitempty" json:"environments,omitempty"` // record sets besides the default one
+	Proxy          *ProxyConfig        `bson:"proxy,omitempty" json:"-"`                             // record-and-replay setup, nil until configured
+	Validation     string              `bson:"validation,omitempty" json:"-"`                        // enforce (empty) or observe
+	RestoringUntil *time.Time          `bson:"restoringUntil,omitempty" json:"-"`                    // set while a snapshot is restored
+	DeletedAt      *time.Time          `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`       // set while the project is in the trash
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time           `bson:"updatedAt" json:"updatedAt"`
 }
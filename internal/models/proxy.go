@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Proxy modes of a project
+const (
+	ProxyModeOff    = "off"    // mock routes are served by the project
+	ProxyModeRecord = "record" // mock routes are forwarded upstream and recorded
+	ProxyModeReplay = "replay" // recordings are served offline
+)
+
+var ProxyModes = []string{ProxyModeOff, ProxyModeRecord, ProxyModeReplay}
+
+// Where a project keeps its recordings
+const (
+	ProxyTargetEndpoints = "endpoints" // a custom endpoint per method and path, a rule per request variant
+	ProxyTargetRecords   = "records"   // a record per request variant in a collection
+)
+
+var ProxyTargets = []string{ProxyTargetEndpoints, ProxyTargetRecords}
+
+// ProxyMatch picks what, besides the method and path, tells recorded requests apart.
+type ProxyMatch struct {
+	Query   bool     `bson:"query" json:"query"`               // the query string
+	Body    bool     `bson:"body" json:"body"`                 // the request body
+	Headers []string `bson:"headers,omitempty" json:"headers"` // values of these request headers
+}
+
+// ProxyConfig is the record-and-replay setup of a project.
+type ProxyConfig struct {
+	Mode          string     `bson:"mode" json:"mode"`
+	UpstreamURL   string     `bson:"upstreamUrl,omitempty" json:"upstreamUrl"`
+	Target        string     `bson:"target" json:"target"`
+	Collection    string     `bson:"collection,omitempty" json:"collection,omitempty"` // collection of the records target
+	Match         ProxyMatch `bson:"match" json:"match"`
+	RedactHeaders []string   `bson:"redactHeaders" json:"redactHeaders"`             // stored as [REDACTED] in recordings
+	UpdatedAt     *time.Time `bson:"updatedAt,omitempty" json:"updatedAt,omitempty"` // nil until configured
+}
@@ -10,6 +10,11 @@ type Record struct {
 	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
 	CollectionID primitive.ObjectID     `bson:"collectionId" json:"collectionId"`
 	Data         map[string]interface{} `bson:"data" json:"data"`
+	Version      int64                  `bson:"version,omitempty" json:"version"`                     // bumped by every change of data, sent as the ETag; 0 for records older than versions
+	Environment  string                 `bson:"environment,omitempty" json:"environment,omitempty"`   // empty for the default environment
+	PromotedFrom *primitive.ObjectID    `bson:"promotedFrom,omitempty" json:"promotedFrom,omitempty"` // record of another environment this one was last promoted from
+	DeletedAt    *time.Time             `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`       // set while the record is in the trash
+	DeletedWith  *primitive.ObjectID    `bson:"deletedWith,omitempty" json:"deletedWith,omitempty"`   // collection or project whose deletion trashed it
 	CreatedAt    time.Time              `bson:"createdAt" json:"createdAt"`
 	UpdatedAt    time.Time              `bson:"updatedAt" json:"updatedAt"`
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecordRevision keeps the data a record had before one of its changes,
+// with who made that change and when. Revisions are numbered per record.
+type RecordRevision struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	RecordID     primitive.ObjectID     `bson:"recordId" json:"recordId"`
+	CollectionID primitive.ObjectID     `bson:"collectionId" json:"collectionId"`
+	ProjectID    primitive.ObjectID     `bson:"projectId" json:"projectId"`
+	Revision     int                    `bson:"revision" json:"revision"`
+	Data         map[string]interface{} `bson:"data" json:"data"`
+	ActorID      *primitive.ObjectID    `bson:"actorId,omitempty" json:"actorId,omitempty"` // nil for anonymous callers (mock routes)
+	APIKeyID     string                 `bson:"apiKeyId,omitempty" json:"apiKeyId,omitempty"`
+	RevertedFrom int                    `bson:"revertedFrom,omitempty" json:"revertedFrom,omitempty"` // set when the change was a revert
+	CreatedAt    time.Time              `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Snapshot captures the collections (schemas and rules) of a project at a
+// point in time. Its records are stored as SnapshotRecord documents.
+type Snapshot struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID   primitive.ObjectID `bson:"projectId" json:"projectId"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Collections []Collection       `bson:"collections" json:"collections"` // with their original ids
+	RecordCount int                `bson:"recordCount" json:"recordCount"`
+	CreatedBy   primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// SnapshotRecord is a copy of one record, with its original id, taken by a snapshot
+type SnapshotRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SnapshotID primitive.ObjectID `bson:"snapshotId" json:"snapshotId"`
+	ProjectID  primitive.ObjectID `bson:"projectId" json:"projectId"`
+	Record     Record             `bson:"record" json:"record"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Template visibilities
+const (
+	TemplatePrivate = "private" // only its author
+	TemplateOrg     = "org"     // members of the organization it was published from
+	TemplatePublic  = "public"  // every user
+)
+
+var TemplateVisibilities = []string{TemplatePrivate, TemplateOrg, TemplatePublic}
+
+// Template is a reusable copy of a project's collections (schemas and rules)
+// and custom endpoints that new projects can be created from. Its records, if
+// it was published with data, are stored as TemplateRecord documents.
+type Template struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name            string              `bson:"name" json:"name"`
+	Description     string              `bson:"description,omitempty" json:"description,omitempty"`
+	Visibility      string              `bson:"visibility" json:"visibility"`
+	OrgID           *primitive.ObjectID `bson:"orgId,omitempty" json:"orgId,omitempty"` // set for org templates
+	SourceProjectID primitive.ObjectID  `bson:"sourceProjectId" json:"-"`
+	Environments    []string            `bson:"environments,omitempty" json:"environments,omitempty"`
+	Collections     []Collection        `bson:"collections" json:"collections"` // ids are the template's own, remapped on instantiation
+	Endpoints       []Endpoint          `bson:"endpoints" json:"endpoints"`
+	RecordCount     int                 `bson:"recordCount" json:"recordCount"`
+	CreatedBy       primitive.ObjectID  `bson:"createdBy" json:"createdBy"`
+	CreatedAt       time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// TemplateRecord is a copy of one record published with a template
+type TemplateRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TemplateID primitive.ObjectID `bson:"templateId" json:"templateId"`
+	Record     Record             `bson:"record" json:"record"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Validation modes of a project
+const (
+	ValidationEnforce = "enforce" // records that break their collection's schema are rejected
+	ValidationObserve = "observe" // they are accepted and their violations are logged
+)
+
+var ValidationModes = []string{ValidationEnforce, ValidationObserve}
+
+// FieldViolation is one schema rule a record broke
+type FieldViolation struct {
+	Field   string `bson:"field" json:"field"`
+	Rule    string `bson:"rule" json:"rule"` // required, type, minLength, maxLength, pattern, email, url, minValue, maxValue, enum
+	Message string `bson:"message" json:"message"`
+}
+
+// Violation is a record write accepted in observe mode despite breaking the
+// schema of its collection, with the offending payload.
+type Violation struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ProjectID    primitive.ObjectID  `bson:"projectId" json:"projectId"`
+	CollectionID primitive.ObjectID  `bson:"collectionId" json:"collectionId"`
+	Collection   string              `bson:"collection" json:"collection"`
+	RecordID     primitive.ObjectID  `bson:"recordId" json:"recordId"`
+	Environment  string              `bson:"environment,omitempty" json:"environment,omitempty"`
+	Operation    string              `bson:"operation" json:"operation"` // create or update
+	Violations   []FieldViolation    `bson:"violations" json:"violations"`
+	Payload      map[string]any      `bson:"payload" json:"payload"`
+	ActorID      *primitive.ObjectID `bson:"actorId,omitempty" json:"actorId,omitempty"` // nil for anonymous callers (mock routes, sandboxes)
+	APIKeyID     string              `bson:"apiKeyId,omitempty" json:"apiKeyId,omitempty"`
+	IP           string              `bson:"ip" json:"ip"`
+	UserAgent    string              `bson:"userAgent" json:"userAgent"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+}
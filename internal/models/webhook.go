@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook events a project can subscribe to
+const (
+	WebhookRecordCreated     = "record.created"
+	WebhookRecordUpdated     = "record.updated"
+	WebhookRecordDeleted     = "record.deleted"
+	WebhookCollectionUpdated = "collection.updated"
+)
+
+var WebhookEvents = []string{
+	WebhookRecordCreated,
+	WebhookRecordUpdated,
+	WebhookRecordDeleted,
+	WebhookCollectionUpdated,
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"   // waiting for its next attempt
+	DeliverySucceeded = "succeeded" // the receiver answered 2xx
+	DeliveryFailed    = "failed"    // attempts exhausted or webhook gone
+)
+
+// Webhook sends signed POST requests to a URL when subscribed events happen
+// in a project. The secret signs every payload and is shown once.
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID primitive.ObjectID `bson:"projectId" json:"projectId"`
+	URL       string             `bson:"url" json:"url"`
+	Events    []string           `bson:"events" json:"events"`
+	Secret    string             `bson:"secret" json:"-"`
+	Active    bool               `bson:"active" json:"active"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// WebhookDelivery is one event sent (or to be sent) to a webhook. The payload
+// is stored as sent so retries and redeliveries carry the same body.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	WebhookID      primitive.ObjectID  `bson:"webhookId" json:"webhookId"`
+	ProjectID      primitive.ObjectID  `bson:"projectId" json:"projectId"`
+	Event          string              `bson:"event" json:"event"`
+	Payload        string              `bson:"payload" json:"payload"`
+	Status         string              `bson:"status" json:"status"`
+	Attempts       int                 `bson:"attempts" json:"attempts"`
+	NextAttemptAt  *time.Time          `bson:"nextAttemptAt,omitempty" json:"nextAttemptAt,omitempty"`
+	LastStatusCode int                 `bson:"lastStatusCode,omitempty" json:"lastStatusCode,omitempty"`
+	LastError      string              `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	History        []DeliveryAttempt   `bson:"history,omitempty" json:"history,omitempty"`
+	RedeliveryOf   *primitive.ObjectID `bson:"redeliveryOf,omitempty" json:"redeliveryOf,omitempty"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	DeliveredAt    *time.Time          `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+}
+
+// DeliveryAttempt is one try at sending a delivery, kept in its history
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"statusCode,omitempty" json:"statusCode,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"durationMs" json:"durationMs"`
+}
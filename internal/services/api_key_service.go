@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyPrefix marks our keys so they are easy to spot in configs and logs
+const apiKeyPrefix = "mk_"
+
+// lastUsedResolution avoids a database write on every request of a busy key
+const lastUsedResolution = time.Minute
+
+var ErrInvalidAPIKey = errors.New("invalid, expired or revoked API key")
+
+var apiKeyScopeLevels = map[string]int{
+	models.APIKeyScopeReadOnly:  1,
+	models.APIKeyScopeReadWrite: 2,
+	models.APIKeyScopeAdmin:     3,
+}
+
+// APIKeyService manages project API keys used by CI jobs and other machines.
+type APIKeyService struct {
+	coll           store.Repository
+	access         *projectAccess
+	collectionColl store.Repository
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewAPIKeyService(db store.Store, cfg *config.Config) *APIKeyService {
+	return &APIKeyService{
+		coll:           db.Repository(database.Collections.APIKeys),
+		access:         newProjectAccess(db),
+		collectionColl: db.Repository(database.Collections.Collection),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// CreateKey issues a new key for a project owned by the user. The plain key
+// is only part of this response.
+func (s *APIKeyService) CreateKey(projectID, userID string, req *dtos.CreateAPIKeyRequest) (*dtos.CreateAPIKeyResponse, error) {
+	pid, uid, err := s.ownedProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = models.APIKeyScopeReadOnly
+	}
+	if _, ok := apiKeyScopeLevels[scope]; !ok {
+		return nil, errors.New("scope must be read-only, read-write or admin")
+	}
+	if req.ExpiresInDays < 0 {
+		return nil, errors.New("expiresInDays cannot be negative")
+	}
+
+	secret, err := utils.GenerateRandomID(24)
+	if err != nil {
+		return nil, err
+	}
+	plain := apiKeyPrefix + secret
+
+	now := time.Now()
+	key := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		ProjectID: pid,
+		UserID:    uid,
+		Name:      req.Name,
+		Prefix:    plain[:len(apiKeyPrefix)+6],
+		KeyHash:   hashAPIKey(plain),
+		Scope:     scope,
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.coll.InsertOne(s.ctx, key); err != nil {
+		return nil, err
+	}
+
+	return &dtos.CreateAPIKeyResponse{Key: plain, APIKey: key}, nil
+}
+
+// ListKeys returns the keys of a project, including revoked and expired ones
+func (s *APIKeyService) ListKeys(projectID, userID string) ([]models.APIKey, error) {
+	pid, _, err := s.ownedProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []models.APIKey{}
+	if err := s.coll.Find(s.ctx, bson.M{"projectId": pid}, &keys, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeKey disables a key immediately; it stays listed for reference
+func (s *APIKeyService) RevokeKey(projectID, keyID, userID string) error {
+	pid, _, err := s.ownedProject(projectID, userID)
+	if err != nil {
+		return err
+	}
+	kid, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return errors.New("invalid key id")
+	}
+
+	matched, err := s.coll.UpdateOne(s.ctx,
+		bson.M{"_id": kid, "projectId": pid, "revokedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return notFoundError("api key not found or already revoked")
+	}
+
+	return nil
+}
+
+// Authenticate resolves a plain key and records its use
+func (s *APIKeyService) Authenticate(plain string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.coll.FindOne(s.ctx, bson.M{"keyHash": hashAPIKey(plain)}, &key); err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if _, err := s.coll.UpdateOne(s.ctx, bson.M{"_id": key.ID}, bson.M{"$set": bson.M{"lastUsedAt": now}}); err == nil {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return &key, nil
+}
+
+// CollectionProjectID returns the project a collection belongs to, so keys
+// can be checked on routes that are addressed by collection
+func (s *APIKeyService) CollectionProjectID(collectionID string) (primitive.ObjectID, error) {
+	cid, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("invalid collection id")
+	}
+
+	collection, err := findCollection(s.collectionColl, s.cfg, cid)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return collection.ProjectID, nil
+}
+
+// ScopeAllows reports whether a key may perform a request. Reads need any
+// scope; writes need at least writeScope.
+func ScopeAllows(key *models.APIKey, method, writeScope string) bool {
+	required := writeScope
+	if method == http.MethodGet || method == http.MethodHead {
+		required = models.APIKeyScopeReadOnly
+	}
+	return apiKeyScopeLevels[key.Scope] >= apiKeyScopeLevels[required]
+}
+
+// ownedProject checks that the user is an owner of the project
+func (s *APIKeyService) ownedProject(projectID, userID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return pid, primitive.NilObjectID, errors.New("invalid project id")
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return pid, uid, errors.New("invalid user id")
+	}
+
+	if _, err := s.access.authorizeProject(pid, userID, models.RoleOwner); err != nil {
+		return pid, uid, err
+	}
+
+	return pid, uid, nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
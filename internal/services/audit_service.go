@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// auditLog appends audit events for the services that change data. Recording
+// is best effort: a failure is logged and never fails the audited change.
+type auditLog struct {
+	coll store.Repository
+}
+
+func newAuditLog(db store.Store) *auditLog {
+	return &auditLog{coll: db.Repository(database.Collections.AuditEvents)}
+}
+
+// record completes the event with the actor and stores it
+func (a *auditLog) record(actor dtos.Actor, event *models.AuditEvent) {
+	event.ID = primitive.NewObjectID()
+	event.APIKeyID = actor.APIKeyID
+	event.IP = actor.IP
+	event.UserAgent = actor.UserAgent
+	event.CreatedAt = time.Now()
+	if uid, err := primitive.ObjectIDFromHex(actor.UserID); err == nil {
+		event.ActorID = &uid
+	}
+
+	if err := a.coll.InsertOne(context.Background(), event); err != nil {
+		log.Printf("[AUDIT] Failed to record %s on %s %s: %v", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
+// diffFields lists the top-level fields whose value differs between two
+// snapshots. Pass nil as before for creations and as after for deletions.
+func diffFields(before, after map[string]any) []models.AuditChange {
+	fields := map[string]bool{}
+	for k := range before {
+		fields[k] = true
+	}
+	for k := range after {
+		fields[k] = true
+	}
+
+	changes := []models.AuditChange{}
+	for field := range fields {
+		// A missing field and a nil one are the same; neither is worth a change
+		b, v := utils.NormalizeBSON(before[field]), utils.NormalizeBSON(after[field])
+		if sameJSON(b, v) {
+			continue
+		}
+		changes = append(changes, models.AuditChange{Field: field, Before: b, After: v})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// AuditService queries the audit log and enforces its retention.
+type AuditService struct {
+	coll   store.Repository
+	access *projectAccess
+	ctx    context.Context
+	cfg    *config.Config
+}
+
+func NewAuditService(db store.Store, cfg *config.Config) *AuditService {
+	return &AuditService{
+		coll:   db.Repository(database.Collections.AuditEvents),
+		access: newProjectAccess(db),
+		ctx:    context.Background(),
+		cfg:    cfg,
+	}
+}
+
+// ProjectEvents returns the audit events of a project, newest first. Only
+// project owners can read the log.
+func (s *AuditService) ProjectEvents(projectID, userID string, query *dtos.AuditQuery) ([]models.AuditEvent, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	if _, err := s.access.authorizeProject(pid, userID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	filter, err := auditFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	filter["projectId"] = pid
+
+	return s.find(filter, query)
+}
+
+// UserEvents returns the events performed by the user, across all projects
+// and including sign-ins and password changes
+func (s *AuditService) UserEvents(userID string, query *dtos.AuditQuery) ([]models.AuditEvent, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	filter, err := auditFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	filter["actorId"] = uid
+
+	return s.find(filter, query)
+}
+
+// StartRetention periodically deletes events older than the configured
+// retention period
+func (s *AuditService) StartRetention(ctx context.Context, interval time.Duration) {
+	if s.cfg.AuditRetention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeExpired()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *AuditService) purgeExpired() {
+	cutoff := time.Now().Add(-s.cfg.AuditRetention)
+	deleted, err := s.coll.DeleteMany(s.ctx, bson.M{"createdAt": bson.M{"$lt": cutoff}})
+	if err != nil {
+		log.Printf("[AUDIT] Failed to purge events older than %v: %v", cutoff, err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[AUDIT] Purged %d events older than %v", deleted, s.cfg.AuditRetention)
+	}
+}
+
+func (s *AuditService) find(filter bson.M, query *dtos.AuditQuery) ([]models.AuditEvent, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	limit = min(limit, maxAuditLimit)
+
+	events := []models.AuditEvent{}
+	err := s.coll.Find(s.ctx, filter, &events, store.FindOptions{
+		Sort:  bson.D{{Key: "createdAt", Value: -1}},
+		Skip:  int64(max(query.Skip, 0)),
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Before and after values are BSON-decoded, turn them back into plain JSON types
+	for i := range events {
+		for j := range events[i].Changes {
+			change := &events[i].Changes[j]
+			change.Before = utils.NormalizeBSON(change.Before)
+			change.After = utils.NormalizeBSON(change.After)
+		}
+	}
+
+	return events, nil
+}
+
+func auditFilter(query *dtos.AuditQuery) (bson.M, error) {
+	filter := bson.M{}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.TargetType != "" {
+		filter["targetType"] = query.TargetType
+	}
+	if query.TargetID != "" {
+		filter["targetId"] = query.TargetID
+	}
+	if query.ActorID != "" {
+		aid, err := primitive.ObjectIDFromHex(query.ActorID)
+		if err != nil {
+			return nil, errors.New("invalid actor id")
+		}
+		filter["actorId"] = aid
+	}
+
+	createdAt := bson.M{}
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		createdAt["$lte"] = query.To
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	return filter, nil
+}
@@ -7,9 +7,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/saifwork/mock-service/internal/core/store"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/saifwork/mock-service/internal/core/config"
@@ -20,18 +20,20 @@ import (
 )
 
 type AuthService struct {
-	coll *mongo.Collection
-	ctx  context.Context
-	cfg  *config.Config
+	coll  store.Repository
+	audit *auditLog
+	ctx   context.Context
+	cfg   *config.Config
 }
 
 // Constructor
-func NewAuthService(client *mongo.Client, cfg *config.Config) *AuthService {
-	collection := client.Database(cfg.MongoDBName).Collection(database.Collections.Users)
+func NewAuthService(db store.Store, cfg *config.Config) *AuthService {
+	collection := db.Repository(database.Collections.Users)
 	return &AuthService{
-		coll: collection,
-		ctx:  context.Background(),
-		cfg:  cfg,
+		coll:  collection,
+		audit: newAuditLog(db),
+		ctx:   context.Background(),
+		cfg:   cfg,
 	}
 }
 
@@ -42,9 +44,9 @@ func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
 	}
 
 	var user models.User
-	err = s.coll.FindOne(s.ctx, bson.M{"_id": objID}).Decode(&user)
+	err = s.coll.FindOne(s.ctx, bson.M{"_id": objID}, &user)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if err == store.ErrNotFound {
 			return nil, errors.New("user not found")
 		}
 		return nil, err
@@ -88,7 +90,7 @@ func (s *AuthService) Signup(req *dtos.UserRegisterRequestDto) error {
 		UpdatedAt: time.Now(),
 	}
 
-	_, err = s.coll.InsertOne(s.ctx, user)
+	err = s.coll.InsertOne(s.ctx, user)
 	if err != nil {
 		return err
 	}
@@ -139,7 +141,7 @@ func (s *AuthService) ResendVerification(email string) error {
 
 	// Find user by email
 	var user models.User
-	err := s.coll.FindOne(s.ctx, bson.M{"email": email}).Decode(&user)
+	err := s.coll.FindOne(s.ctx, bson.M{"email": email}, &user)
 	if err != nil {
 		return errors.New("user not found")
 	}
@@ -262,7 +264,7 @@ func (s *AuthService) RefreshToken(refreshToken string) (string, string, error)
 
 	// Fetch user
 	var user models.User
-	err = s.coll.FindOne(s.ctx, bson.M{"_id": userId}).Decode(&user)
+	err = s.coll.FindOne(s.ctx, bson.M{"_id": userId}, &user)
 	if err != nil {
 		return "", "", errors.New("user not found")
 	}
@@ -340,11 +342,11 @@ func (s *AuthService) VerifyEmail(token string) error {
 		},
 	}
 
-	res, err := s.coll.UpdateOne(s.ctx, filter, update)
+	matched, err := s.coll.UpdateOne(s.ctx, filter, update)
 	if err != nil {
 		return err
 	}
-	if res.ModifiedCount == 0 {
+	if matched == 0 {
 		return errors.New("invalid or expired token")
 	}
 
@@ -352,9 +354,9 @@ func (s *AuthService) VerifyEmail(token string) error {
 }
 
 // -------------------- Login --------------------
-func (s *AuthService) Login(req *dtos.UserLoginRequestDto) (*dtos.AuthTokens, error) {
+func (s *AuthService) Login(req *dtos.UserLoginRequestDto, actor dtos.Actor) (*dtos.AuthTokens, error) {
 	var user models.User
-	err := s.coll.FindOne(s.ctx, bson.M{"email": req.Email}).Decode(&user)
+	err := s.coll.FindOne(s.ctx, bson.M{"email": req.Email}, &user)
 	if err != nil {
 		return nil, errors.New("invalid credentials")
 	}
@@ -363,7 +365,10 @@ func (s *AuthService) Login(req *dtos.UserLoginRequestDto) (*dtos.AuthTokens, er
 		return nil, errors.New("email not verified")
 	}
 
+	// Failed attempts are logged on the account they target
+	actor.UserID = user.ID.Hex()
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		s.recordAuthEvent(actor, models.AuditLoginFailed, user.ID)
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -401,6 +406,7 @@ func (s *AuthService) Login(req *dtos.UserLoginRequestDto) (*dtos.AuthTokens, er
 	if err != nil {
 		return nil, err
 	}
+	s.recordAuthEvent(actor, models.AuditLogin, user.ID)
 
 	return &dtos.AuthTokens{
 		AccessToken:  accessToken,
@@ -424,11 +430,11 @@ func (s *AuthService) ForgotPassword(email string) error {
 		},
 	}
 
-	res, err := s.coll.UpdateOne(s.ctx, filter, update)
+	matched, err := s.coll.UpdateOne(s.ctx, filter, update)
 	if err != nil {
 		return err
 	}
-	if res.MatchedCount == 0 {
+	if matched == 0 {
 		return errors.New("user not found")
 	}
 
@@ -454,7 +460,7 @@ func (s *AuthService) ForgotPassword(email string) error {
 }
 
 // -------------------- Reset Password via Forgot --------------------
-func (s *AuthService) ResetForgotPassword(token, newPassword string) error {
+func (s *AuthService) ResetForgotPassword(token, newPassword string, actor dtos.Actor) error {
 
 	var user models.User
 	err := s.coll.FindOne(s.ctx, bson.M{
@@ -462,7 +468,7 @@ func (s *AuthService) ResetForgotPassword(token, newPassword string) error {
 		"resetExpires": bson.M{
 			"$gt": time.Now(),
 		},
-	}).Decode(&user)
+	}, &user)
 	if err != nil {
 		return errors.New("invalid or expired token")
 	}
@@ -480,16 +486,21 @@ func (s *AuthService) ResetForgotPassword(token, newPassword string) error {
 		},
 	}
 
-	_, err = s.coll.UpdateByID(s.ctx, user.ID, update)
-	return err
+	if _, err = s.coll.UpdateOne(s.ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return err
+	}
+
+	actor.UserID = user.ID.Hex()
+	s.recordAuthEvent(actor, models.AuditPasswordReset, user.ID)
+	return nil
 }
 
 // -------------------- Reset Password (Logged-in user) --------------------
-func (s *AuthService) ResetPassword(userID, oldPwd, newPwd string) error {
+func (s *AuthService) ResetPassword(actor dtos.Actor, oldPwd, newPwd string) error {
 
-	objID, _ := primitive.ObjectIDFromHex(userID)
+	objID, _ := primitive.ObjectIDFromHex(actor.UserID)
 	var user models.User
-	err := s.coll.FindOne(s.ctx, bson.M{"_id": objID}).Decode(&user)
+	err := s.coll.FindOne(s.ctx, bson.M{"_id": objID}, &user)
 	if err != nil {
 		return errors.New("user not found")
 	}
@@ -505,11 +516,25 @@ func (s *AuthService) ResetPassword(userID, oldPwd, newPwd string) error {
 
 	hashed, _ := bcrypt.GenerateFromPassword([]byte(newPwd), bcrypt.DefaultCost)
 
-	_, err = s.coll.UpdateByID(s.ctx, user.ID, bson.M{
+	_, err = s.coll.UpdateOne(s.ctx, bson.M{"_id": user.ID}, bson.M{
 		"$set": bson.M{
 			"passwordHash": string(hashed),
 			"updatedAt":    time.Now(),
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	s.recordAuthEvent(actor, models.AuditPasswordChange, user.ID)
+	return nil
+}
+
+// recordAuthEvent logs a sign-in or password event on a user account
+func (s *AuthService) recordAuthEvent(actor dtos.Actor, action string, uid primitive.ObjectID) {
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     action,
+		TargetType: "user",
+		TargetID:   uid.Hex(),
+	})
 }
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BundleService exports projects as portable bundles and imports them back,
+// on this instance or another one.
+type BundleService struct {
+	projectColl    store.Repository
+	collectionColl store.Repository
+	recordColl     store.Repository
+	endpointColl   store.Repository
+	webhookColl    store.Repository
+	orgColl        store.Repository
+	templates      *TemplateService
+	access         *projectAccess
+	audit          *auditLog
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewBundleService(db store.Store, cfg *config.Config, templates *TemplateService) *BundleService {
+	return &BundleService{
+		projectColl:    db.Repository(database.Collections.Projects),
+		collectionColl: db.Repository(database.Collections.Collection),
+		recordColl:     db.Repository(database.Collections.Records),
+		endpointColl:   db.Repository(database.Collections.Endpoints),
+		webhookColl:    db.Repository(database.Collections.Webhooks),
+		orgColl:        db.Repository(database.Collections.Organizations),
+		templates:      templates,
+		access:         newProjectAccess(db),
+		audit:          newAuditLog(db),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// ExportProject builds the bundle of a project with its live collections,
+// records and custom endpoints. Webhooks are only exported for owners, who
+// are the ones allowed to see them.
+func (s *BundleService) ExportProject(projectID, userID string) (*dtos.ProjectBundle, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	project, err := s.access.authorizeProject(pid, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.templates.projectBlueprint(project, true)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &dtos.ProjectBundle{
+		Format:      dtos.BundleFormat,
+		Version:     dtos.BundleVersion,
+		ExportedAt:  time.Now().UTC(),
+		Project:     dtos.BundleProject{ID: pid.Hex(), Name: project.Name, Description: project.Description, Environments: project.Environments},
+		Collections: []dtos.BundleCollection{},
+		Endpoints:   []dtos.BundleEndpoint{},
+		Settings:    dtos.BundleSettings{Webhooks: []dtos.BundleWebhook{}},
+	}
+
+	records := map[primitive.ObjectID][]dtos.BundleRecord{}
+	for _, record := range content.records {
+		data, _ := utils.NormalizeBSON(record.Data).(map[string]any)
+		createdAt, updatedAt := record.CreatedAt.UTC(), record.UpdatedAt.UTC()
+		records[record.CollectionID] = append(records[record.CollectionID], dtos.BundleRecord{
+			ID:          record.ID.Hex(),
+			Data:        data,
+			Environment: record.Environment,
+			CreatedAt:   &createdAt,
+			UpdatedAt:   &updatedAt,
+		})
+	}
+	for _, collection := range content.collections {
+		normalizeRules(collection.Rules)
+		for i := range collection.Fields {
+			collection.Fields[i].Default = utils.NormalizeBSON(collection.Fields[i].Default)
+		}
+		bundled := dtos.BundleCollection{
+			ID:            collection.ID.Hex(),
+			Name:          collection.Name,
+			Fields:        collection.Fields,
+			Rules:         collection.Rules,
+			RevisionLimit: collection.RevisionLimit,
+			Records:       records[collection.ID],
+		}
+		if bundled.Records == nil {
+			bundled.Records = []dtos.BundleRecord{}
+		}
+		bundle.Collections = append(bundle.Collections, bundled)
+	}
+	for _, endpoint := range content.endpoints {
+		normalizeEndpoint(&endpoint)
+		bundle.Endpoints = append(bundle.Endpoints, dtos.BundleEndpoint{
+			ID:          endpoint.ID.Hex(),
+			Method:      endpoint.Method,
+			Path:        endpoint.Path,
+			Description: endpoint.Description,
+			Rules:       endpoint.Rules,
+			Response:    endpoint.Response,
+		})
+	}
+
+	uid, _ := primitive.ObjectIDFromHex(userID)
+	if s.access.role(project, uid) == models.RoleOwner {
+		var hooks []models.Webhook
+		if err := s.webhookColl.Find(s.ctx, bson.M{"projectId": pid}, &hooks, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+			return nil, err
+		}
+		for _, hook := range hooks {
+			bundle.Settings.Webhooks = append(bundle.Settings.Webhooks, dtos.BundleWebhook{URL: hook.URL, Events: hook.Events, Active: hook.Active})
+		}
+	}
+
+	return bundle, nil
+}
+
+// ImportProject creates a project from a bundle in the caller's personal
+// space, or in an organization when orgID is set. When the context already
+// has a live project with the bundle's name, mode decides what happens; see
+// dtos.ImportModes. Overwrite and merge need the owner role on that project.
+func (s *BundleService) ImportProject(actor dtos.Actor, orgID, mode string, bundle *dtos.ProjectBundle) (*dtos.ImportResult, error) {
+	if mode == "" {
+		mode = dtos.ImportRename
+	}
+	if !slices.Contains(dtos.ImportModes, mode) {
+		return nil, fmt.Errorf("onConflict must be one of %v", dtos.ImportModes)
+	}
+	content, hooks, err := bundleBlueprint(bundle, s.cfg.WebhookAllowPrivate)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	// 🔎 Step 1: Look for a project of the same name in the target context
+	scope, err := importScope(s.orgColl, actor, orgID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(bundle.Project.Name)
+	var existing models.Project
+	err = s.projectColl.FindOne(s.ctx, notDeleted(withName(scope, name)), &existing)
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+	if err == store.ErrNotFound {
+		mode = dtos.ImportRename
+	}
+
+	// 📦 Step 2: Apply the bundle
+	result := &dtos.ImportResult{Mode: mode, Webhooks: []dtos.CreateWebhookResponse{}, Skipped: []string{}}
+	switch mode {
+	case dtos.ImportRename:
+		if name, err = freeProjectName(s.projectColl, scope, name); err != nil {
+			return nil, err
+		}
+		project, err := s.templates.instantiate(actor, orgID, name, bundle.Project.Description, content)
+		if err != nil {
+			return nil, err
+		}
+		result.Project = project
+		result.Collections, result.Records, result.Endpoints = len(content.collections), len(content.records), len(content.endpoints)
+	case dtos.ImportOverwrite:
+		if err := s.overwrite(&existing, actor, bundle.Project.Description, content, result); err != nil {
+			return nil, err
+		}
+	case dtos.ImportMerge:
+		if err := s.merge(&existing, actor, content, result); err != nil {
+			return nil, err
+		}
+		hooks = s.newWebhooks(existing.ID, hooks, result)
+	}
+
+	// 🪝 Step 3: Webhooks get new secrets, returned once
+	for _, hook := range hooks {
+		created, err := newWebhook(result.Project.ID, uid, hook.URL, hook.Events)
+		if err != nil {
+			return nil, err
+		}
+		created.Active = hook.Active
+		if err := s.webhookColl.InsertOne(s.ctx, created); err != nil {
+			return nil, err
+		}
+		result.Webhooks = append(result.Webhooks, dtos.CreateWebhookResponse{Secret: created.Secret, Webhook: created})
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectImport,
+		TargetType: "project",
+		TargetID:   result.Project.ID.Hex(),
+		ProjectID:  &result.Project.ID,
+		Changes: diffFields(nil, map[string]any{
+			"mode":        result.Mode,
+			"collections": result.Collections,
+			"records":     result.Records,
+			"endpoints":   result.Endpoints,
+			"webhooks":    len(result.Webhooks),
+		}),
+	})
+
+	return result, nil
+}
+
+// overwrite replaces the content of an existing project. Its collections go
+// to the trash with their records, so the overwrite can be undone; custom
+// endpoints and webhooks are replaced.
+func (s *BundleService) overwrite(project *models.Project, actor dtos.Actor, description string, content *blueprint, result *dtos.ImportResult) error {
+	if _, err := s.access.authorizeProject(project.ID, actor.UserID, models.RoleOwner); err != nil {
+		return err
+	}
+	if s.access.plan(project) != PlanPro && len(content.collections) > freeCollectionLimit {
+		return fmt.Errorf("free plans can only have %d collections per project and the bundle has %d — upgrade to import it", freeCollectionLimit, len(content.collections))
+	}
+
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, notDeleted(bson.M{"projectId": project.ID}), &collections); err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range collections {
+		cid := collections[i].ID
+		if _, err := s.recordColl.UpdateMany(s.ctx, notDeleted(bson.M{"collectionId": cid}), bson.M{"$set": bson.M{"deletedAt": now, "deletedWith": cid}}); err != nil {
+			return err
+		}
+		if _, err := s.collectionColl.UpdateOne(s.ctx, bson.M{"_id": cid}, bson.M{"$set": bson.M{"deletedAt": now}}); err != nil {
+			return err
+		}
+		invalidateCollectionCache(&collections[i])
+	}
+	if _, err := s.endpointColl.DeleteMany(s.ctx, bson.M{"projectId": project.ID}); err != nil {
+		return err
+	}
+	if _, err := s.webhookColl.DeleteMany(s.ctx, bson.M{"projectId": project.ID}); err != nil {
+		return err
+	}
+
+	set := bson.M{"description": description, "environments": content.environments, "updatedAt": now}
+	if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, bson.M{"$set": set}); err != nil {
+		return err
+	}
+	project.Description, project.Environments, project.UpdatedAt = description, content.environments, now
+
+	if err := s.templates.fill(content.remap(project.ID, nil)); err != nil {
+		return err
+	}
+
+	result.Project = project
+	result.Collections, result.Records, result.Endpoints = len(content.collections), len(content.records), len(content.endpoints)
+	return nil
+}
+
+// merge adds to an existing project the collections, endpoints and records it
+// does not have. Collections are matched by name and keep their schema;
+// endpoints are matched by method and path; records already present with the
+// same data, or that do not fit the existing schema, are skipped.
+func (s *BundleService) merge(project *models.Project, actor dtos.Actor, content *blueprint, result *dtos.ImportResult) error {
+	if _, err := s.access.authorizeProject(project.ID, actor.UserID, models.RoleOwner); err != nil {
+		return err
+	}
+
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, notDeleted(bson.M{"projectId": project.ID}), &collections); err != nil {
+		return err
+	}
+	byName := map[string]*models.Collection{}
+	for i := range collections {
+		byName[collections[i].Name] = &collections[i]
+	}
+	matched := map[primitive.ObjectID]primitive.ObjectID{}
+	added := 0
+	for _, collection := range content.collections {
+		if current, ok := byName[collection.Name]; ok {
+			matched[collection.ID] = current.ID
+			continue
+		}
+		added++
+	}
+	if s.access.plan(project) != PlanPro && len(collections)+added > freeCollectionLimit {
+		return fmt.Errorf("free plans can only have %d collections per project and the merge would make %d — upgrade to import it", freeCollectionLimit, len(collections)+added)
+	}
+	environments := slices.Clone(project.Environments)
+	for _, env := range content.environments {
+		if !slices.Contains(environments, env) {
+			environments = append(environments, env)
+		}
+	}
+	if len(environments) > maxEnvironments {
+		return fmt.Errorf("a project can have at most %d environments besides the default one and the merge would make %d", maxEnvironments, len(environments))
+	}
+
+	existingIDs := map[primitive.ObjectID]*models.Collection{}
+	present := map[primitive.ObjectID]map[string]primitive.ObjectID{}
+	for _, current := range byName {
+		existingIDs[current.ID] = current
+		keys, err := s.recordKeys(current.ID)
+		if err != nil {
+			return err
+		}
+		present[current.ID] = keys
+	}
+
+	// Records already present are matched to the existing ones, so references
+	// to them point there. Matching a record changes the data of the records
+	// referencing it, which may then match too, hence the repeat.
+	remapped := content.remap(project.ID, matched)
+	for changed := true; changed; {
+		changed = false
+		for i, record := range remapped.records {
+			original := content.records[i].ID
+			if _, done := matched[original]; done {
+				continue
+			}
+			if id, ok := present[record.CollectionID][recordKey(record.Environment, record.Data)]; ok {
+				matched[original] = id
+				changed = true
+			}
+		}
+		if changed {
+			remapped = content.remap(project.ID, matched)
+		}
+	}
+
+	merged := &blueprint{ownerID: project.ID}
+	for _, collection := range remapped.collections {
+		if _, ok := existingIDs[collection.ID]; ok {
+			result.Skipped = append(result.Skipped, "collection "+collection.Name)
+			continue
+		}
+		merged.collections = append(merged.collections, collection)
+	}
+
+	var endpoints []models.Endpoint
+	if err := s.endpointColl.Find(s.ctx, bson.M{"projectId": project.ID}, &endpoints); err != nil {
+		return err
+	}
+	for _, endpoint := range remapped.endpoints {
+		route := endpoint.Method + " " + endpoint.Path
+		if slices.ContainsFunc(endpoints, func(e models.Endpoint) bool { return e.Method+" "+e.Path == route }) {
+			result.Skipped = append(result.Skipped, "endpoint "+route)
+			continue
+		}
+		merged.endpoints = append(merged.endpoints, endpoint)
+	}
+
+	for i, record := range remapped.records {
+		current, ok := existingIDs[record.CollectionID]
+		if !ok {
+			merged.records = append(merged.records, record)
+			continue
+		}
+		if _, found := matched[content.records[i].ID]; found {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("record %s in %s", content.records[i].ID.Hex(), current.Name))
+			continue
+		}
+		if err := validateRecordData(current.Fields, record.Data); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("record %s in %s: %v", content.records[i].ID.Hex(), current.Name, err))
+			continue
+		}
+		merged.records = append(merged.records, record)
+	}
+
+	if err := s.templates.fill(merged); err != nil {
+		return err
+	}
+	if len(environments) > len(project.Environments) {
+		if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, bson.M{"$set": bson.M{"environments": environments}}); err != nil {
+			return err
+		}
+		project.Environments = environments
+	}
+	for _, current := range existingIDs {
+		invalidateRecordCache(current)
+	}
+
+	result.Project = project
+	result.Collections, result.Records, result.Endpoints = len(merged.collections), len(merged.records), len(merged.endpoints)
+	return nil
+}
+
+// newWebhooks returns the bundle webhooks whose URL the project does not use yet
+func (s *BundleService) newWebhooks(pid primitive.ObjectID, hooks []dtos.BundleWebhook, result *dtos.ImportResult) []dtos.BundleWebhook {
+	var current []models.Webhook
+	if err := s.webhookColl.Find(s.ctx, bson.M{"projectId": pid}, &current); err != nil {
+		return nil
+	}
+
+	var missing []dtos.BundleWebhook
+	for _, hook := range hooks {
+		if slices.ContainsFunc(current, func(w models.Webhook) bool { return w.URL == hook.URL }) {
+			result.Skipped = append(result.Skipped, "webhook "+hook.URL)
+			continue
+		}
+		missing = append(missing, hook)
+	}
+	return missing
+}
+
+// recordKeys maps the live records of a collection, see recordKey, to their ids
+func (s *BundleService) recordKeys(cid primitive.ObjectID) (map[string]primitive.ObjectID, error) {
+	var records []models.Record
+	if err := s.recordColl.Find(s.ctx, notDeleted(bson.M{"collectionId": cid}), &records); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]primitive.ObjectID, len(records))
+	for _, record := range records {
+		keys[recordKey(record.Environment, utils.NormalizeBSON(record.Data))] = record.ID
+	}
+	return keys, nil
+}
+
+// importScope is the filter of the projects an import lands among: the
+// caller's personal projects, or those of the organization named by orgID
+func importScope(orgColl store.Repository, actor dtos.Actor, orgID string) (bson.M, error) {
+	if orgID != "" {
+		org, _, err := authorizeOrg(orgColl, orgID, actor.UserID, models.OrgRoleMember)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"orgId": org.ID}, nil
+	}
+	uid, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+	return bson.M{"userId": uid, "orgId": bson.M{"$exists": false}}, nil
+}
+
+// freeProjectName returns name, numbered until it is unused in scope when it
+// is taken: "Name (2)", "Name (3)"...
+func freeProjectName(projectColl store.Repository, scope bson.M, name string) (string, error) {
+	candidate := name
+	for n := 2; ; n++ {
+		count, err := projectColl.CountDocuments(context.Background(), notDeleted(withName(scope, candidate)))
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+}
+
+// withName copies a filter and narrows it to a name
+func withName(filter bson.M, name string) bson.M {
+	named := bson.M{"name": name}
+	for k, v := range filter {
+		named[k] = v
+	}
+	return named
+}
+
+// recordKey identifies a record by its environment and the JSON encoding of
+// its data, which sorts keys and writes numbers the same whatever their
+// decoded type
+func recordKey(env string, data any) string {
+	encoded, _ := json.Marshal(data)
+	return env + "/" + string(encoded)
+}
+
+// bundleBlueprint checks a bundle and turns it into the content of a project.
+// Missing ids are generated.
+func bundleBlueprint(bundle *dtos.ProjectBundle, allowPrivateWebhooks bool) (*blueprint, []dtos.BundleWebhook, error) {
+	if bundle.Format != dtos.BundleFormat {
+		return nil, nil, fmt.Errorf("not a project bundle: format must be %q", dtos.BundleFormat)
+	}
+	if bundle.Version < 1 || bundle.Version > dtos.BundleVersion {
+		return nil, nil, fmt.Errorf("unsupported bundle version %d, this instance reads up to version %d", bundle.Version, dtos.BundleVersion)
+	}
+	if strings.TrimSpace(bundle.Project.Name) == "" {
+		return nil, nil, errors.New("the bundle's project needs a name")
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	bundleID := func(raw, what string) (primitive.ObjectID, error) {
+		if raw == "" {
+			return primitive.NewObjectID(), nil
+		}
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return id, fmt.Errorf("invalid %s id %q", what, raw)
+		}
+		if seen[id] {
+			return id, fmt.Errorf("duplicate id %q in the bundle", raw)
+		}
+		seen[id] = true
+		return id, nil
+	}
+
+	content := &blueprint{}
+	var err error
+	if content.ownerID, err = bundleID(bundle.Project.ID, "project"); err != nil {
+		return nil, nil, err
+	}
+	for _, env := range bundle.Project.Environments {
+		if !environmentName.MatchString(env) || env == models.DefaultEnvironment || slices.Contains(content.environments, env) {
+			return nil, nil, fmt.Errorf("invalid or duplicate environment %q", env)
+		}
+		content.environments = append(content.environments, env)
+	}
+	if len(content.environments) > maxEnvironments {
+		return nil, nil, fmt.Errorf("a project can have at most %d environments besides the default one", maxEnvironments)
+	}
+
+	names := map[string]bool{}
+	for _, bundled := range bundle.Collections {
+		name := strings.TrimSpace(bundled.Name)
+		if name == "" {
+			return nil, nil, errors.New("every collection needs a name")
+		}
+		if names[name] {
+			return nil, nil, fmt.Errorf("collection %q appears twice in the bundle", name)
+		}
+		names[name] = true
+		if err := validateRules(bundled.Rules); err != nil {
+			return nil, nil, fmt.Errorf("collection %s: %w", name, err)
+		}
+		if bundled.RevisionLimit != nil && (*bundled.RevisionLimit < 0 || *bundled.RevisionLimit > maxRevisionLimit) {
+			return nil, nil, fmt.Errorf("collection %s: revisionLimit must be between 0 and %d", name, maxRevisionLimit)
+		}
+
+		collection := models.Collection{
+			Name:          name,
+			Fields:        bundled.Fields,
+			Rules:         bundled.Rules,
+			RevisionLimit: bundled.RevisionLimit,
+		}
+		if collection.Fields == nil {
+			collection.Fields = []models.FieldDefinition{}
+		}
+		if collection.ID, err = bundleID(bundled.ID, "collection"); err != nil {
+			return nil, nil, err
+		}
+		content.collections = append(content.collections, collection)
+
+		for _, record := range bundled.Records {
+			if record.Data == nil {
+				record.Data = map[string]any{}
+			}
+			if err := validateRecordData(collection.Fields, record.Data); err != nil {
+				return nil, nil, fmt.Errorf("collection %s: %w", name, err)
+			}
+			if record.Environment == models.DefaultEnvironment {
+				record.Environment = ""
+			}
+			if record.Environment != "" && !slices.Contains(content.environments, record.Environment) {
+				return nil, nil, fmt.Errorf("collection %s: record in unknown environment %q", name, record.Environment)
+			}
+			copied := models.Record{CollectionID: collection.ID, Data: record.Data, Environment: record.Environment}
+			if copied.ID, err = bundleID(record.ID, "record"); err != nil {
+				return nil, nil, err
+			}
+			if record.CreatedAt != nil {
+				copied.CreatedAt = *record.CreatedAt
+				copied.UpdatedAt = copied.CreatedAt
+			}
+			if record.UpdatedAt != nil {
+				copied.UpdatedAt = *record.UpdatedAt
+			}
+			content.records = append(content.records, copied)
+		}
+	}
+
+	for _, bundled := range bundle.Endpoints {
+		input := dtos.EndpointRequestDto{
+			Method:      bundled.Method,
+			Path:        bundled.Path,
+			Description: bundled.Description,
+			Rules:       bundled.Rules,
+			Response:    bundled.Response,
+		}
+		if err := normalizeEndpointInput(&input); err != nil {
+			return nil, nil, fmt.Errorf("endpoint %s %s: %w", bundled.Method, bundled.Path, err)
+		}
+		endpoint := models.Endpoint{
+			Method:      input.Method,
+			Path:        input.Path,
+			Description: input.Description,
+			Rules:       input.Rules,
+			Response:    input.Response,
+		}
+		if endpoint.ID, err = bundleID(bundled.ID, "endpoint"); err != nil {
+			return nil, nil, err
+		}
+		content.endpoints = append(content.endpoints, endpoint)
+	}
+
+	for _, hook := range bundle.Settings.Webhooks {
+		if err := validateWebhookURL(hook.URL, allowPrivateWebhooks); err != nil {
+			return nil, nil, fmt.Errorf("webhook %s: %w", hook.URL, err)
+		}
+		if err := validateWebhookEvents(hook.Events); err != nil {
+			return nil, nil, fmt.Errorf("webhook %s: %w", hook.URL, err)
+		}
+	}
+
+	return content, bundle.Settings.Webhooks, nil
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// changeBacklogSize is how many recent events per project can be resumed
+	changeBacklogSize = 1000
+	changeBacklogTTL  = 7 * 24 * time.Hour
+	// changeBufferSize is how far an in-process subscriber may fall behind
+	// before it is dropped; it resumes from its last event id on reconnect
+	changeBufferSize = 64
+	// changeSweepInterval is how often the in-process backlog drops expired events
+	changeSweepInterval = time.Minute
+	// changeTicketTTL is how long a stream ticket can be used to connect
+	changeTicketTTL = time.Minute
+)
+
+// changeAccessInterval is how often open streams check that their subscriber
+// can still read the project
+var changeAccessInterval = 30 * time.Second
+
+var changeTypes = []string{models.WebhookRecordCreated, models.WebhookRecordUpdated, models.WebhookRecordDeleted}
+
+// publishChangeScript assigns the next event id of a project, appends the
+// event to the capped backlog and publishes it in one step, so every instance
+// sees ids in order. ARGV[1] is the event JSON without its id.
+var publishChangeScript = goredis.NewScript(`
+local id = redis.call("INCR", KEYS[1])
+local payload = '{"id":' .. id .. ',' .. string.sub(ARGV[1], 2)
+redis.call("RPUSH", KEYS[2], payload)
+redis.call("LTRIM", KEYS[2], -tonumber(ARGV[2]), -1)
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+redis.call("PUBLISH", KEYS[3], payload)
+return id
+`)
+
+// changeFeed fans record changes out to stream subscribers. With Redis the
+// events go through pub/sub so subscribers on every instance receive them;
+// without Redis they stay in this process.
+type changeFeed struct {
+	mu          sync.Mutex
+	seq         map[string]int64
+	backlog     map[string][]dtos.ChangeEvent
+	subscribers map[string]map[chan dtos.ChangeEvent]struct{}
+	sweptAt     time.Time
+}
+
+var liveChanges = newChangeFeed()
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{
+		seq:         map[string]int64{},
+		backlog:     map[string][]dtos.ChangeEvent{},
+		subscribers: map[string]map[chan dtos.ChangeEvent]struct{}{},
+	}
+}
+
+// publishRecordChange pushes a record write to change stream subscribers
+func publishRecordChange(changeType string, collection *models.Collection, record *models.Record) {
+	liveChanges.publish(dtos.ChangeEvent{
+		Type:         changeType,
+		ProjectID:    collection.ProjectID.Hex(),
+		CollectionID: collection.ID.Hex(),
+		Collection:   collection.Name,
+		RecordID:     record.ID.Hex(),
+		Record:       record,
+		CreatedAt:    time.Now(),
+	})
+}
+
+// publish is best effort: a failure is logged and never fails the write
+func (f *changeFeed) publish(event dtos.ChangeEvent) {
+	if rdb := redisClient.GetClient(); rdb != nil {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("[CHANGES] Failed to encode %s event: %v", event.Type, err)
+			return
+		}
+
+		keys := []string{
+			redisClient.ProjectChangesSeqKey(event.ProjectID),
+			redisClient.ProjectChangesKey(event.ProjectID),
+			redisClient.ProjectChangesChannelKey(event.ProjectID),
+		}
+		err = publishChangeScript.Run(context.Background(), rdb, keys, payload, changeBacklogSize, changeBacklogTTL.Milliseconds()).Err()
+		if err != nil {
+			log.Printf("[CHANGES] Failed to publish %s for project %s: %v", event.Type, event.ProjectID, err)
+		}
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sweep(time.Now())
+	f.seq[event.ProjectID]++
+	event.ID = f.seq[event.ProjectID]
+
+	backlog := append(f.backlog[event.ProjectID], event)
+	if len(backlog) > changeBacklogSize {
+		backlog = slices.Clone(backlog[len(backlog)-changeBacklogSize:])
+	}
+	f.backlog[event.ProjectID] = backlog
+
+	for ch := range f.subscribers[event.ProjectID] {
+		select {
+		case ch <- event:
+		default:
+			delete(f.subscribers[event.ProjectID], ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns the live events of a project and the backlog events after
+// lastID. Live events can repeat backlog ones, so callers skip ids they have
+// already sent. The live channel closes when ctx ends.
+func (f *changeFeed) subscribe(ctx context.Context, projectID string, lastID int64) (<-chan dtos.ChangeEvent, []dtos.ChangeEvent, error) {
+	if rdb := redisClient.GetClient(); rdb != nil {
+		return f.subscribeRedis(ctx, rdb, projectID, lastID)
+	}
+
+	ch := make(chan dtos.ChangeEvent, changeBufferSize)
+
+	f.mu.Lock()
+	f.sweep(time.Now())
+	if f.subscribers[projectID] == nil {
+		f.subscribers[projectID] = map[chan dtos.ChangeEvent]struct{}{}
+	}
+	f.subscribers[projectID][ch] = struct{}{}
+	backlog := eventsAfter(f.backlog[projectID], lastID)
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[projectID][ch]; ok {
+			delete(f.subscribers[projectID], ch)
+			close(ch)
+		}
+		if len(f.subscribers[projectID]) == 0 {
+			delete(f.subscribers, projectID)
+		}
+	}()
+
+	return ch, backlog, nil
+}
+
+// sweep drops in-process events older than changeBacklogTTL, as the Redis
+// backlog expires, and forgets the projects left without events or
+// subscribers. It runs at most once per changeSweepInterval; f.mu must be held.
+func (f *changeFeed) sweep(now time.Time) {
+	if now.Sub(f.sweptAt) < changeSweepInterval {
+		return
+	}
+	f.sweptAt = now
+
+	cutoff := now.Add(-changeBacklogTTL)
+	for projectID, backlog := range f.backlog {
+		expired := 0
+		for expired < len(backlog) && backlog[expired].CreatedAt.Before(cutoff) {
+			expired++
+		}
+		switch {
+		case expired == 0:
+		case expired < len(backlog):
+			f.backlog[projectID] = slices.Clone(backlog[expired:])
+		default:
+			delete(f.backlog, projectID)
+			if len(f.subscribers[projectID]) == 0 {
+				delete(f.seq, projectID)
+			}
+		}
+	}
+}
+
+func (f *changeFeed) subscribeRedis(ctx context.Context, rdb *goredis.Client, projectID string, lastID int64) (<-chan dtos.ChangeEvent, []dtos.ChangeEvent, error) {
+	// Subscribe before reading the backlog so no event falls in between
+	sub := rdb.Subscribe(ctx, redisClient.ProjectChangesChannelKey(projectID))
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, nil, err
+	}
+
+	raw, err := rdb.LRange(ctx, redisClient.ProjectChangesKey(projectID), 0, -1).Result()
+	if err != nil {
+		_ = sub.Close()
+		return nil, nil, err
+	}
+	backlog := make([]dtos.ChangeEvent, 0, len(raw))
+	for _, item := range raw {
+		var event dtos.ChangeEvent
+		if err := json.Unmarshal([]byte(item), &event); err == nil {
+			backlog = append(backlog, event)
+		}
+	}
+
+	out := make(chan dtos.ChangeEvent)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event dtos.ChangeEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, eventsAfter(backlog, lastID), nil
+}
+
+// eventsAfter returns the backlog events newer than lastID. An id beyond the
+// newest event means the sequence was reset, so the whole backlog is replayed.
+func eventsAfter(backlog []dtos.ChangeEvent, lastID int64) []dtos.ChangeEvent {
+	if len(backlog) == 0 || lastID > backlog[len(backlog)-1].ID {
+		return slices.Clone(backlog)
+	}
+
+	events := []dtos.ChangeEvent{}
+	for _, event := range backlog {
+		if event.ID > lastID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// ChangeStreamService streams record changes of a project or a collection to
+// live subscribers (Server-Sent Events and WebSocket).
+type ChangeStreamService struct {
+	collectionColl store.Repository
+	access         *projectAccess
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewChangeStreamService(db store.Store, cfg *config.Config) *ChangeStreamService {
+	return &ChangeStreamService{
+		collectionColl: db.Repository(database.Collections.Collection),
+		access:         newProjectAccess(db),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// IssueTicket signs a stream ticket for a viewer of the project, or of the
+// project of the collection when collectionID is set
+func (s *ChangeStreamService) IssueTicket(projectID, collectionID, userID string) (*dtos.ChangeTicket, error) {
+	var pid primitive.ObjectID
+	if collectionID != "" {
+		collection, err := s.access.authorizeCollection(s.collectionColl, s.cfg, "", collectionID, userID, models.RoleViewer)
+		if err != nil {
+			return nil, err
+		}
+		pid = collection.ProjectID
+	} else {
+		var err error
+		if pid, err = primitive.ObjectIDFromHex(projectID); err != nil {
+			return nil, errors.New("invalid project id")
+		}
+		if _, err := s.access.authorizeProject(pid, userID, models.RoleViewer); err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt := time.Now().Add(changeTicketTTL)
+	ticket, err := utils.GenerateStreamTicket(userID, pid.Hex(), s.cfg.JWTAccessSecret, changeTicketTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.ChangeTicket{Ticket: ticket, ExpiresAt: expiresAt}, nil
+}
+
+// TicketUser returns the user a ticket was issued to, provided it was issued
+// for the project streamed by the route (or the project of its collection)
+func (s *ChangeStreamService) TicketUser(ticket, projectID, collectionID string) (string, error) {
+	userID, ticketProject, err := utils.ParseStreamTicket(ticket, s.cfg.JWTAccessSecret)
+	if err != nil {
+		return "", err
+	}
+
+	if collectionID != "" {
+		cid, err := primitive.ObjectIDFromHex(collectionID)
+		if err != nil {
+			return "", errors.New("invalid collection id")
+		}
+		collection, err := findCollection(s.collectionColl, s.cfg, cid)
+		if err != nil {
+			return "", err
+		}
+		projectID = collection.ProjectID.Hex()
+	}
+	if projectID != ticketProject {
+		return "", errors.New("ticket was issued for another project")
+	}
+
+	return userID, nil
+}
+
+// SubscribeProject streams the record changes of every collection of a
+// project until ctx is cancelled or the user loses access to it
+func (s *ChangeStreamService) SubscribeProject(ctx context.Context, projectID, userID string, filter *dtos.ChangeFilter) (<-chan dtos.ChangeEvent, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	authorize := func() error {
+		_, err := s.access.authorizeProject(pid, userID, models.RoleViewer)
+		return err
+	}
+	if err := authorize(); err != nil {
+		return nil, err
+	}
+
+	return s.stream(ctx, pid.Hex(), "", filter, authorize)
+}
+
+// SubscribeCollection streams the record changes of one collection until ctx
+// is cancelled or the user loses access to it
+func (s *ChangeStreamService) SubscribeCollection(ctx context.Context, collectionID, userID string, filter *dtos.ChangeFilter) (<-chan dtos.ChangeEvent, error) {
+	collection, err := s.access.authorizeCollection(s.collectionColl, s.cfg, "", collectionID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	authorize := func() error {
+		_, err := s.access.authorizeCollection(s.collectionColl, s.cfg, "", collectionID, userID, models.RoleViewer)
+		return err
+	}
+
+	return s.stream(ctx, collection.ProjectID.Hex(), collection.ID.Hex(), filter, authorize)
+}
+
+// stream replays the backlog after filter.LastEventID, then forwards live
+// events, both narrowed by the filter. Every changeAccessInterval it runs
+// authorize again and closes the stream once access is gone (member removed,
+// project or collection deleted).
+func (s *ChangeStreamService) stream(ctx context.Context, projectID, collectionID string, filter *dtos.ChangeFilter, authorize func() error) (<-chan dtos.ChangeEvent, error) {
+	match, err := changeMatcher(collectionID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	live, backlog, err := liveChanges.subscribe(ctx, projectID, filter.LastEventID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan dtos.ChangeEvent)
+	go func() {
+		defer cancel()
+		defer close(out)
+
+		var last int64
+		send := func(event dtos.ChangeEvent) bool {
+			if event.ID <= last {
+				return true
+			}
+			last = event.ID
+			if !match(&event) {
+				return true
+			}
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, event := range backlog {
+			if !send(event) {
+				return
+			}
+		}
+
+		recheck := time.NewTicker(changeAccessInterval)
+		defer recheck.Stop()
+		for {
+			select {
+			case event, ok := <-live:
+				if !ok || !send(event) {
+					return
+				}
+			case <-recheck.C:
+				if !stillAuthorized(authorize) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// stillAuthorized reports whether an open stream may go on. Only a refusal
+// ends it; a failing lookup keeps the stream until the next check.
+func stillAuthorized(authorize func() error) bool {
+	err := authorize()
+	var accessErr *AccessError
+	if errors.As(err, &accessErr) {
+		return false
+	}
+	if err != nil {
+		log.Printf("[CHANGES] Failed to re-check stream access: %v", err)
+	}
+	return true
+}
+
+// changeMatcher builds the predicate of a filter. Types may be given in full
+// ("record.updated") or short ("updated").
+func changeMatcher(collectionID string, filter *dtos.ChangeFilter) (func(*dtos.ChangeEvent) bool, error) {
+	var types []string
+	for _, t := range strings.Split(filter.Types, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !strings.Contains(t, ".") {
+			t = "record." + t
+		}
+		if !slices.Contains(changeTypes, t) {
+			return nil, fmt.Errorf("unknown change type %q, expected one of %v", t, changeTypes)
+		}
+		types = append(types, t)
+	}
+
+	return func(event *dtos.ChangeEvent) bool {
+		if len(types) > 0 && !slices.Contains(types, event.Type) {
+			return false
+		}
+		if collectionID != "" && event.CollectionID != collectionID {
+			return false
+		}
+		if filter.Collection != "" && event.CollectionID != filter.Collection && event.Collection != filter.Collection {
+			return false
+		}
+		if filter.RecordID != "" && event.RecordID != filter.RecordID {
+			return false
+		}
+		return true
+	}, nil
+}
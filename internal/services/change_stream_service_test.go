@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// An open stream ends once its subscriber is removed from the project
+func TestChangeStreamClosesWhenAccessIsLost(t *testing.T) {
+	defer func(interval time.Duration) { changeAccessInterval = interval }(changeAccessInterval)
+	changeAccessInterval = 10 * time.Millisecond
+
+	db := store.NewMemoryStore()
+	svc := NewChangeStreamService(db, &config.Config{AppName: "test"})
+	projects := db.Repository(database.Collections.Projects)
+
+	member := primitive.NewObjectID()
+	project := models.Project{
+		ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Name: "Shop",
+		Members:   []models.ProjectMember{{UserID: member, Role: models.RoleViewer, AddedAt: time.Now()}},
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := projects.InsertOne(context.Background(), project); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := svc.SubscribeProject(ctx, project.ID.Hex(), member.Hex(), &dtos.ChangeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-events:
+		t.Fatal("stream ended while the user was still a member")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, err := projects.UpdateOne(context.Background(), bson.M{"_id": project.ID}, bson.M{"$pull": bson.M{"members": bson.M{"userId": member}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("got an event, want the stream closed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open after the member was removed")
+	}
+}
+
+// The in-process backlog forgets events past their TTL and the projects they
+// leave empty
+func TestChangeFeedSweepsExpiredEvents(t *testing.T) {
+	feed := newChangeFeed()
+	expired := time.Now().Add(-changeBacklogTTL - time.Hour)
+
+	feed.publish(dtos.ChangeEvent{ProjectID: "idle", CreatedAt: expired})
+	feed.publish(dtos.ChangeEvent{ProjectID: "busy", CreatedAt: expired})
+	feed.publish(dtos.ChangeEvent{ProjectID: "busy", CreatedAt: time.Now()})
+
+	feed.sweptAt = time.Time{}
+	feed.publish(dtos.ChangeEvent{ProjectID: "busy", CreatedAt: time.Now()})
+
+	if _, ok := feed.backlog["idle"]; ok {
+		t.Error("idle project still has a backlog")
+	}
+	if _, ok := feed.seq["idle"]; ok {
+		t.Error("idle project still has a sequence")
+	}
+	if got := len(feed.backlog["busy"]); got != 2 {
+		t.Errorf("busy backlog has %d events, want 2", got)
+	}
+	if got := feed.seq["busy"]; got != 3 {
+		t.Errorf("busy sequence = %d, want 3", got)
+	}
+}
@@ -3,39 +3,46 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/saifwork/mock-service/internal/core/config"
 	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
 	"github.com/saifwork/mock-service/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CollectionService struct {
-	coll        *mongo.Collection
-	projectColl *mongo.Collection
-	userColl    *mongo.Collection
-	ctx         context.Context
-	cfg         *config.Config
+	coll       store.Repository
+	recordColl store.Repository
+	access     *projectAccess
+	audit      *auditLog
+	webhooks   *webhookDispatcher
+	revisions  *revisionTrail
+	ctx        context.Context
+	cfg        *config.Config
 }
 
-func NewCollectionService(client *mongo.Client, cfg *config.Config) *CollectionService {
-	collection := client.Database(cfg.MongoDBName).Collection(database.Collections.Collection)
-	projectcollection := client.Database(cfg.MongoDBName).Collection(database.Collections.Projects)
-	usercollection := client.Database(cfg.MongoDBName).Collection(database.Collections.Users)
+func NewCollectionService(db store.Store, cfg *config.Config) *CollectionService {
+	collection := db.Repository(database.Collections.Collection)
 	return &CollectionService{
-		coll:        collection,
-		projectColl: projectcollection,
-		userColl:    usercollection,
-		ctx:         context.Background(),
-		cfg:         cfg,
+		coll:       collection,
+		recordColl: db.Repository(database.Collections.Records),
+		access:     newProjectAccess(db),
+		audit:      newAuditLog(db),
+		webhooks:   newWebhookDispatcher(db),
+		revisions:  newRevisionTrail(db),
+		ctx:        context.Background(),
+		cfg:        cfg,
 	}
 }
 
 // CreateCollection creates a new collection under a specific project
-func (s *CollectionService) CreateCollection(projectID, name string, fields []models.FieldDefinition) (*models.Collection, error) {
+func (s *CollectionService) CreateCollection(projectID string, actor dtos.Actor, name string, fields []models.FieldDefinition) (*models.Collection, error) {
 	pid, err := primitive.ObjectIDFromHex(projectID)
 	if err != nil {
 		return nil, errors.New("invalid project id")
@@ -43,29 +50,21 @@ func (s *CollectionService) CreateCollection(projectID, name string, fields []mo
 
 	ctx := context.Background()
 
-	// ✅ Ensure project exists
-	var project models.Project
-	err = s.projectColl.FindOne(ctx, bson.M{"_id": pid}).Decode(&project)
+	// ✅ Ensure project exists and the caller may edit it
+	project, err := s.access.authorizeProject(pid, actor.UserID, models.RoleEditor)
 	if err != nil {
-		return nil, errors.New("project not found")
-	}
-
-	// ✅ Fetch user to check upgrade status
-	var user models.User
-	err = s.userColl.FindOne(ctx, bson.M{"_id": project.UserID}).Decode(&user)
-	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, err
 	}
 
-	// ✅ Count how many collections this user has under this project
-	count, err := s.coll.CountDocuments(ctx, bson.M{"projectId": pid})
+	// ✅ Count how many collections this project has
+	count, err := s.coll.CountDocuments(ctx, notDeleted(bson.M{"projectId": pid}))
 	if err != nil {
 		return nil, err
 	}
 
-	// ⚠️ Restrict non-upgraded users to max 3 collections per project
-	if !user.IsUpgraded && count >= 3 {
-		return nil, errors.New("free users can only create up to 3 collections per project — upgrade to add more")
+	// ⚠️ Restrict projects outside the pro plan (account or organization) to 3 collections
+	if s.access.plan(project) != PlanPro && count >= freeCollectionLimit {
+		return nil, fmt.Errorf("free plans can only create up to %d collections per project — upgrade to add more", freeCollectionLimit)
 	}
 
 	// ✅ Create collection
@@ -78,65 +77,238 @@ func (s *CollectionService) CreateCollection(projectID, name string, fields []mo
 		UpdatedAt: time.Now(),
 	}
 
-	_, err = s.coll.InsertOne(ctx, collection)
+	err = s.coll.InsertOne(ctx, collection)
 	if err != nil {
 		return nil, err
 	}
 
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditCollectionCreate,
+		TargetType: "collection",
+		TargetID:   collection.ID.Hex(),
+		ProjectID:  &collection.ProjectID,
+		Changes:    diffFields(nil, collectionSnapshot(collection)),
+	})
+
 	return collection, nil
 }
 
 // GetCollectionsByProject returns all collections under a project
-func (s *CollectionService) GetCollectionsByProject(projectID string) ([]models.Collection, error) {
+func (s *CollectionService) GetCollectionsByProject(projectID, userID string) ([]models.Collection, error) {
 	pid, err := primitive.ObjectIDFromHex(projectID)
 	if err != nil {
 		return nil, errors.New("invalid project id")
 	}
-
-	cursor, err := s.coll.Find(context.Background(), bson.M{"projectId": pid})
-	if err != nil {
+	if _, err := s.access.authorizeProject(pid, userID, models.RoleViewer); err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
 
 	var collections []models.Collection
-	if err := cursor.All(context.Background(), &collections); err != nil {
+	if err := s.coll.Find(context.Background(), notDeleted(bson.M{"projectId": pid}), &collections); err != nil {
 		return nil, err
 	}
+	for i := range collections {
+		normalizeRules(collections[i].Rules)
+	}
 
 	return collections, nil
 }
 
-// GetCollectionByID returns a specific collection
-func (s *CollectionService) GetCollectionByID(id string) (*models.Collection, error) {
-	cid, err := primitive.ObjectIDFromHex(id)
+// GetCollectionByID returns a specific collection of a project
+func (s *CollectionService) GetCollectionByID(projectID, id, userID string) (*models.Collection, error) {
+	return s.access.authorizeCollection(s.coll, s.cfg, projectID, id, userID, models.RoleViewer)
+}
+
+// GetCollectionByName returns the collection of a project with the given name
+func (s *CollectionService) GetCollectionByName(projectID primitive.ObjectID, name string) (*models.Collection, error) {
+	cacheKey := redisClient.ProjectCollectionsKey(projectID.Hex())
+
+	var collection models.Collection
+	if redisClient.GetCachedField(cacheKey, name, &collection) {
+		return &collection, nil
+	}
+
+	err := s.coll.FindOne(context.Background(), notDeleted(bson.M{"projectId": projectID, "name": name}), &collection)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("collection not found")
+		}
+		return nil, err
+	}
+	normalizeRules(collection.Rules)
+	redisClient.SetCachedField(cacheKey, name, &collection, s.cfg.CollectionCacheTTL)
+
+	return &collection, nil
+}
+
+// UpdateCollectionRules replaces the ordered response rules of a collection route
+func (s *CollectionService) UpdateCollectionRules(projectID, id string, actor dtos.Actor, rules []models.ResponseRule) (*models.Collection, error) {
+	existing, err := s.access.authorizeCollection(s.coll, s.cfg, projectID, id, actor.UserID, models.RoleEditor)
 	if err != nil {
-		return nil, errors.New("invalid collection id")
+		return nil, err
+	}
+	cid := existing.ID
+
+	if rules == nil {
+		rules = []models.ResponseRule{}
+	}
+	if err := validateRules(rules); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"rules":     rules,
+			"updatedAt": time.Now(),
+		},
 	}
 
 	var collection models.Collection
-	err = s.coll.FindOne(context.Background(), bson.M{"_id": cid}).Decode(&collection)
+	if err := s.coll.FindOneAndUpdate(context.Background(), bson.M{"_id": cid}, update, &collection); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("collection not found")
+		}
+		return nil, err
+	}
+	normalizeRules(collection.Rules)
+	invalidateCollectionCache(&collection)
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditCollectionRules,
+		TargetType: "collection",
+		TargetID:   collection.ID.Hex(),
+		ProjectID:  &collection.ProjectID,
+		Changes:    diffFields(collectionSnapshot(existing), collectionSnapshot(&collection)),
+	})
+	s.webhooks.emit(collection.ProjectID, models.WebhookCollectionUpdated, map[string]any{"collection": &collection})
+
+	return &collection, nil
+}
+
+// UpdateRevisionLimit sets how many revisions the collection keeps per
+// record; nil restores the default. Lowering the limit prunes right away.
+func (s *CollectionService) UpdateRevisionLimit(projectID, id string, actor dtos.Actor, limit *int) (*models.Collection, error) {
+	existing, err := s.access.authorizeCollection(s.coll, s.cfg, projectID, id, actor.UserID, models.RoleEditor)
 	if err != nil {
-		return nil, errors.New("collection not found")
+		return nil, err
 	}
 
+	update := bson.M{"$unset": bson.M{"revisionLimit": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	if limit != nil {
+		if *limit < 0 || *limit > maxRevisionLimit {
+			return nil, fmt.Errorf("revision limit must be between 0 and %d", maxRevisionLimit)
+		}
+		update = bson.M{"$set": bson.M{"revisionLimit": *limit, "updatedAt": time.Now()}}
+	}
+
+	var collection models.Collection
+	if err := s.coll.FindOneAndUpdate(context.Background(), bson.M{"_id": existing.ID}, update, &collection); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("collection not found")
+		}
+		return nil, err
+	}
+	normalizeRules(collection.Rules)
+	invalidateCollectionCache(&collection)
+
+	if revisionLimit(&collection) < revisionLimit(existing) {
+		if err := s.revisions.prune(collection.ID, revisionLimit(&collection)); err != nil {
+			return nil, err
+		}
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditCollectionUpdate,
+		TargetType: "collection",
+		TargetID:   collection.ID.Hex(),
+		ProjectID:  &collection.ProjectID,
+		Changes:    diffFields(collectionSnapshot(existing), collectionSnapshot(&collection)),
+	})
+
 	return &collection, nil
 }
 
-// DeleteCollection removes a collection
-func (s *CollectionService) DeleteCollection(id string) error {
-	cid, err := primitive.ObjectIDFromHex(id)
+// DeleteCollection moves a collection and its records to the trash
+func (s *CollectionService) DeleteCollection(projectID, id string, actor dtos.Actor) error {
+	collection, err := s.access.authorizeCollection(s.coll, s.cfg, projectID, id, actor.UserID, models.RoleEditor)
 	if err != nil {
-		return errors.New("invalid collection id")
+		return err
 	}
 
-	res, err := s.coll.DeleteOne(context.Background(), bson.M{"_id": cid})
+	now := time.Now()
+	matched, err := s.coll.UpdateOne(context.Background(), notDeleted(bson.M{"_id": collection.ID}), bson.M{"$set": bson.M{"deletedAt": now}})
 	if err != nil {
 		return err
 	}
-	if res.DeletedCount == 0 {
-		return errors.New("collection not found")
+	if matched == 0 {
+		return notFoundError("collection not found")
+	}
+	invalidateCollectionCache(collection)
+
+	// Records go to the trash with their collection and come back with it;
+	// records already in the trash keep their own deletion
+	_, err = s.recordColl.UpdateMany(context.Background(),
+		notDeleted(bson.M{"collectionId": collection.ID}),
+		bson.M{"$set": bson.M{"deletedAt": now, "deletedWith": collection.ID}},
+	)
+	if err != nil {
+		return err
 	}
 
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditCollectionDelete,
+		TargetType: "collection",
+		TargetID:   collection.ID.Hex(),
+		ProjectID:  &collection.ProjectID,
+		Changes:    diffFields(collectionSnapshot(collection), nil),
+	})
+
 	return nil
 }
+
+// collectionSnapshot holds the audited fields of a collection
+func collectionSnapshot(collection *models.Collection) map[string]any {
+	snapshot := map[string]any{
+		"name":   collection.Name,
+		"fields": collection.Fields,
+		"rules":  collection.Rules,
+	}
+	if collection.RevisionLimit != nil {
+		snapshot["revisionLimit"] = *collection.RevisionLimit
+	}
+	return snapshot
+}
+
+// findCollection loads a collection definition by id, going through the
+// Redis cache first. Shared with RecordService, which needs the schema on
+// every write.
+func findCollection(coll store.Repository, cfg *config.Config, cid primitive.ObjectID) (*models.Collection, error) {
+	cacheKey := redisClient.CollectionCacheKey(cid.Hex())
+
+	var collection models.Collection
+	if redisClient.GetCachedJSON(cacheKey, &collection) {
+		return &collection, nil
+	}
+
+	if err := coll.FindOne(context.Background(), notDeleted(bson.M{"_id": cid}), &collection); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("collection not found")
+		}
+		return nil, err
+	}
+	normalizeRules(collection.Rules)
+	redisClient.SetCachedJSON(cacheKey, &collection, cfg.CollectionCacheTTL)
+
+	return &collection, nil
+}
+
+// invalidateCollectionCache drops the cached definition of a collection and
+// the cached mock responses built from it
+func invalidateCollectionCache(collection *models.Collection) {
+	projectID := collection.ProjectID.Hex()
+	redisClient.InvalidateCache(
+		redisClient.CollectionCacheKey(collection.ID.Hex()),
+		redisClient.ProjectCollectionsKey(projectID),
+		redisClient.ProjectDataKey(projectID, collection.Name),
+	)
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxMockDelayMs caps the artificial latency a mock response may ask for.
+const maxMockDelayMs = 30000
+
+var mockMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+type EndpointService struct {
+	coll   store.Repository
+	access *projectAccess
+	ctx    context.Context
+	cfg    *config.Config
+}
+
+func NewEndpointService(db store.Store, cfg *config.Config) *EndpointService {
+	collection := db.Repository(database.Collections.Endpoints)
+	return &EndpointService{
+		coll:   collection,
+		access: newProjectAccess(db),
+		ctx:    context.Background(),
+		cfg:    cfg,
+	}
+}
+
+// CreateEndpoint adds a custom endpoint to a project the user can edit
+func (s *EndpointService) CreateEndpoint(projectID, userID string, input dtos.EndpointRequestDto) (*models.Endpoint, error) {
+	pid, err := s.authorizedProjectID(projectID, userID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := normalizeEndpointInput(&input); err != nil {
+		return nil, err
+	}
+
+	count, err := s.coll.CountDocuments(s.ctx, bson.M{"projectId": pid, "method": input.Method, "path": input.Path})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("endpoint %s %s already exists", input.Method, input.Path)
+	}
+
+	endpoint := &models.Endpoint{
+		ID:          primitive.NewObjectID(),
+		ProjectID:   pid,
+		Method:      input.Method,
+		Path:        input.Path,
+		Description: input.Description,
+		Rules:       input.Rules,
+		Response:    input.Response,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.coll.InsertOne(s.ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	return endpoint, nil
+}
+
+// GetEndpointsByProject lists the custom endpoints of a project
+func (s *EndpointService) GetEndpointsByProject(projectID, userID string) ([]models.Endpoint, error) {
+	pid, err := s.authorizedProjectID(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.findEndpoints(pid)
+}
+
+// GetEndpointByID returns one custom endpoint of a project
+func (s *EndpointService) GetEndpointByID(projectID, endpointID, userID string) (*models.Endpoint, error) {
+	pid, err := s.authorizedProjectID(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	eid, err := primitive.ObjectIDFromHex(endpointID)
+	if err != nil {
+		return nil, errors.New("invalid endpoint id")
+	}
+
+	var endpoint models.Endpoint
+	if err := s.coll.FindOne(s.ctx, bson.M{"_id": eid, "projectId": pid}, &endpoint); err != nil {
+		return nil, notFoundError("endpoint not found")
+	}
+	normalizeEndpoint(&endpoint)
+
+	return &endpoint, nil
+}
+
+// UpdateEndpoint replaces the definition of a custom endpoint
+func (s *EndpointService) UpdateEndpoint(projectID, endpointID, userID string, input dtos.EndpointRequestDto) (*models.Endpoint, error) {
+	pid, err := s.authorizedProjectID(projectID, userID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	eid, err := primitive.ObjectIDFromHex(endpointID)
+	if err != nil {
+		return nil, errors.New("invalid endpoint id")
+	}
+
+	if err := normalizeEndpointInput(&input); err != nil {
+		return nil, err
+	}
+
+	count, err := s.coll.CountDocuments(s.ctx, bson.M{
+		"projectId": pid,
+		"method":    input.Method,
+		"path":      input.Path,
+		"_id":       bson.M{"$ne": eid},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("endpoint %s %s already exists", input.Method, input.Path)
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"method":      input.Method,
+			"path":        input.Path,
+			"description": input.Description,
+			"rules":       input.Rules,
+			"response":    input.Response,
+			"updatedAt":   time.Now(),
+		},
+	}
+
+	matched, err := s.coll.UpdateOne(s.ctx, bson.M{"_id": eid, "projectId": pid}, update)
+	if err != nil {
+		return nil, err
+	}
+	if matched == 0 {
+		return nil, notFoundError("endpoint not found")
+	}
+
+	return s.GetEndpointByID(projectID, endpointID, userID)
+}
+
+// DeleteEndpoint removes a custom endpoint
+func (s *EndpointService) DeleteEndpoint(projectID, endpointID, userID string) error {
+	pid, err := s.authorizedProjectID(projectID, userID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	eid, err := primitive.ObjectIDFromHex(endpointID)
+	if err != nil {
+		return errors.New("invalid endpoint id")
+	}
+
+	deleted, err := s.coll.DeleteOne(s.ctx, bson.M{"_id": eid, "projectId": pid})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return notFoundError("endpoint not found")
+	}
+
+	return nil
+}
+
+// MatchEndpoint finds the custom endpoint serving method+path in a project and
+// returns it together with the extracted path parameters. When several paths
+// match, the one with the most literal segments wins (/users/me over
+// /users/:id), then the one created first.
+func (s *EndpointService) MatchEndpoint(projectID primitive.ObjectID, method, path string) (*models.Endpoint, map[string]string, error) {
+	endpoints, err := s.findEndpoints(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var best *models.Endpoint
+	var bestParams map[string]string
+	bestLiterals := -1
+	for i := range endpoints {
+		if endpoints[i].Method != method {
+			continue
+		}
+		params, ok := matchPath(endpoints[i].Path, path)
+		if !ok {
+			continue
+		}
+		if literals := literalSegments(endpoints[i].Path); literals > bestLiterals {
+			best, bestParams, bestLiterals = &endpoints[i], params, literals
+		}
+	}
+
+	return best, bestParams, nil
+}
+
+func (s *EndpointService) findEndpoints(pid primitive.ObjectID) ([]models.Endpoint, error) {
+	var endpoints []models.Endpoint
+	opts := store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}}
+	if err := s.coll.Find(s.ctx, bson.M{"projectId": pid}, &endpoints, opts); err != nil {
+		return nil, err
+	}
+	for i := range endpoints {
+		normalizeEndpoint(&endpoints[i])
+	}
+
+	return endpoints, nil
+}
+
+// authorizedProjectID checks the caller's role on a project and returns its id
+func (s *EndpointService) authorizedProjectID(projectID, userID, minRole string) (primitive.ObjectID, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return primitive.NilObjectID, errors.New("invalid project id")
+	}
+	if _, err := s.access.authorizeProject(pid, userID, minRole); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return pid, nil
+}
+
+func normalizeEndpointInput(input *dtos.EndpointRequestDto) error {
+	input.Method = strings.ToUpper(strings.TrimSpace(input.Method))
+	if !slices.Contains(mockMethods, input.Method) {
+		return fmt.Errorf("unsupported method %q", input.Method)
+	}
+
+	input.Path = "/" + strings.Trim(strings.TrimSpace(input.Path), "/")
+
+	if input.Response.Status == 0 {
+		input.Response.Status = http.StatusOK
+	}
+	if err := validateMockResponse(input.Response); err != nil {
+		return err
+	}
+	if input.Rules == nil {
+		input.Rules = []models.ResponseRule{}
+	}
+
+	return validateRules(input.Rules)
+}
+
+// normalizeEndpoint turns BSON-decoded bodies and values back into plain JSON types
+func normalizeEndpoint(e *models.Endpoint) {
+	e.Response.Body = utils.NormalizeBSON(e.Response.Body)
+	normalizeRules(e.Rules)
+}
+
+func normalizeRules(rules []models.ResponseRule) {
+	for i := range rules {
+		rules[i].Response.Body = utils.NormalizeBSON(rules[i].Response.Body)
+		for j := range rules[i].Conditions {
+			rules[i].Conditions[j].Value = utils.NormalizeBSON(rules[i].Conditions[j].Value)
+		}
+	}
+}
+
+// literalSegments counts the segments of a path pattern that are not parameters
+func literalSegments(pattern string) int {
+	literals := 0
+	for _, part := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if !strings.HasPrefix(part, ":") {
+			literals++
+		}
+	}
+	return literals
+}
+
+// matchPath matches a concrete path against a pattern such as /users/:id.
+func matchPath(pattern, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(patternParts) != len(pathParts) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, ":") {
+			params[part[1:]] = pathParts[i]
+			continue
+		}
+		if part != pathParts[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxEnvironments = 10
+
+var environmentName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// inEnvironment narrows a record filter to one environment; "" is the default one
+func inEnvironment(filter bson.M, env string) bson.M {
+	if env == "" {
+		filter["environment"] = bson.M{"$exists": false}
+	} else {
+		filter["environment"] = env
+	}
+	return filter
+}
+
+// resolveEnvironment checks that a project has an environment and returns how
+// its records store it: "" for the default environment
+func resolveEnvironment(project *models.Project, name string) (string, error) {
+	if name == "" || name == models.DefaultEnvironment {
+		return "", nil
+	}
+	if !slices.Contains(project.Environments, name) {
+		return "", notFoundError(fmt.Sprintf("environment %q not found", name))
+	}
+	return name, nil
+}
+
+// environment resolves an environment of a project the caller was already authorized on
+func (a *projectAccess) environment(pid primitive.ObjectID, name string) (string, error) {
+	if name == "" || name == models.DefaultEnvironment {
+		return "", nil
+	}
+
+	var project models.Project
+	if err := a.projectColl.FindOne(context.Background(), bson.M{"_id": pid}, &project); err != nil {
+		return "", err
+	}
+	return resolveEnvironment(&project, name)
+}
+
+// environmentLabel names an environment as stored on records for responses
+func environmentLabel(env string) string {
+	if env == "" {
+		return models.DefaultEnvironment
+	}
+	return env
+}
+
+// EnvironmentService manages the named record sets of a project. Environments
+// share the collections (schemas, rules) and custom endpoints of the project.
+type EnvironmentService struct {
+	projectColl    store.Repository
+	collectionColl store.Repository
+	recordColl     store.Repository
+	access         *projectAccess
+	audit          *auditLog
+	revisions      *revisionTrail
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewEnvironmentService(db store.Store, cfg *config.Config) *EnvironmentService {
+	return &EnvironmentService{
+		projectColl:    db.Repository(database.Collections.Projects),
+		collectionColl: db.Repository(database.Collections.Collection),
+		recordColl:     db.Repository(database.Collections.Records),
+		access:         newProjectAccess(db),
+		audit:          newAuditLog(db),
+		revisions:      newRevisionTrail(db),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// ListEnvironments returns the environments of a project, the default one first
+func (s *EnvironmentService) ListEnvironments(projectID, userID string) ([]string, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{models.DefaultEnvironment}, project.Environments...), nil
+}
+
+// CreateEnvironment adds an empty environment to a project
+func (s *EnvironmentService) CreateEnvironment(projectID string, actor dtos.Actor, name string) ([]string, error) {
+	project, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !environmentName.MatchString(name) {
+		return nil, errors.New("environment names are 1-32 lowercase letters, digits, dashes or underscores")
+	}
+	if name == models.DefaultEnvironment || slices.Contains(project.Environments, name) {
+		return nil, conflictError(fmt.Sprintf("environment %q already exists", name))
+	}
+	if len(project.Environments) >= maxEnvironments {
+		return nil, fmt.Errorf("a project can have at most %d environments besides the default one", maxEnvironments)
+	}
+
+	if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, bson.M{"$addToSet": bson.M{"environments": name}}); err != nil {
+		return nil, err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditEnvironmentCreate,
+		TargetType: "environment",
+		TargetID:   name,
+		ProjectID:  &project.ID,
+		Changes:    diffFields(nil, map[string]any{"name": name}),
+	})
+
+	return append([]string{models.DefaultEnvironment}, append(project.Environments, name)...), nil
+}
+
+// DeleteEnvironment removes an environment with its records and their
+// revisions. The default environment cannot be deleted.
+func (s *EnvironmentService) DeleteEnvironment(projectID string, actor dtos.Actor, name string) error {
+	project, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if name == models.DefaultEnvironment {
+		return errors.New("the default environment cannot be deleted")
+	}
+	env, err := resolveEnvironment(project, name)
+	if err != nil {
+		return err
+	}
+
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, bson.M{"projectId": project.ID}, &collections); err != nil {
+		return err
+	}
+	if len(collections) > 0 {
+		ids := bson.A{}
+		for i := range collections {
+			ids = append(ids, collections[i].ID)
+			invalidateRecordCache(&collections[i])
+		}
+		var records []models.Record
+		if err := s.recordColl.Find(s.ctx, inEnvironment(bson.M{"collectionId": bson.M{"$in": ids}}, env), &records); err != nil {
+			return err
+		}
+		for _, record := range records {
+			s.revisions.drop(record.ID)
+		}
+		if _, err := s.recordColl.DeleteMany(s.ctx, inEnvironment(bson.M{"collectionId": bson.M{"$in": ids}}, env)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, bson.M{"$pull": bson.M{"environments": env}}); err != nil {
+		return err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditEnvironmentDelete,
+		TargetType: "environment",
+		TargetID:   name,
+		ProjectID:  &project.ID,
+		Changes:    diffFields(map[string]any{"name": name}, nil),
+	})
+
+	return nil
+}
+
+// PreviewPromotion returns what promoting req.From over req.To would change
+func (s *EnvironmentService) PreviewPromotion(projectID, userID string, req *dtos.PromoteRequest) (*dtos.PromotionDiff, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planPromotion(project, req)
+	if err != nil {
+		return nil, err
+	}
+	return plan.diff, nil
+}
+
+// Promote makes the records of req.To match those of req.From in the chosen
+// collections. Records promoted earlier are updated in place, so their ids in
+// the target stay stable; the others are created, and target records with no
+// counterpart move to the trash. References between promoted records are
+// rewritten to the target ids.
+func (s *EnvironmentService) Promote(projectID string, actor dtos.Actor, req *dtos.PromoteRequest) (*dtos.PromotionDiff, error) {
+	project, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planPromotion(project, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, step := range plan.steps {
+		for _, update := range step.updates {
+			set := bson.M{
+				"$set": bson.M{"data": update.data, "promotedFrom": update.source, "updatedAt": now},
+				"$inc": bson.M{"version": 1},
+			}
+			if _, err := s.recordColl.UpdateOne(s.ctx, bson.M{"_id": update.target.ID}, set); err != nil {
+				return nil, err
+			}
+			s.revisions.save(step.collection, update.target, actor, 0)
+		}
+		if len(step.creates) > 0 {
+			docs := make([]any, 0, len(step.creates))
+			for _, record := range step.creates {
+				record.CreatedAt, record.UpdatedAt = now, now
+				docs = append(docs, record)
+			}
+			if err := s.recordColl.InsertMany(s.ctx, docs); err != nil {
+				return nil, err
+			}
+		}
+		if len(step.deletes) > 0 {
+			ids := bson.A{}
+			for _, id := range step.deletes {
+				ids = append(ids, id)
+			}
+			if _, err := s.recordColl.UpdateMany(s.ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}}), bson.M{"$set": bson.M{"deletedAt": now}}); err != nil {
+				return nil, err
+			}
+		}
+		invalidateRecordCache(step.collection)
+	}
+	plan.diff.Applied = true
+
+	created, updated, deleted := 0, 0, 0
+	for _, collection := range plan.diff.Collections {
+		created += len(collection.Created)
+		updated += len(collection.Updated)
+		deleted += len(collection.Deleted)
+	}
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditEnvironmentPromote,
+		TargetType: "environment",
+		TargetID:   plan.diff.To,
+		ProjectID:  &project.ID,
+		Changes: diffFields(nil, map[string]any{
+			"from":    plan.diff.From,
+			"created": created,
+			"updated": updated,
+			"deleted": deleted,
+		}),
+	})
+
+	return plan.diff, nil
+}
+
+// promotionPlan holds the writes of a promotion with their diff
+type promotionPlan struct {
+	diff  *dtos.PromotionDiff
+	steps []promotionStep
+}
+
+type promotionStep struct {
+	collection *models.Collection
+	creates    []models.Record
+	updates    []promotedUpdate
+	deletes    []primitive.ObjectID
+}
+
+type promotedUpdate struct {
+	target *models.Record
+	source *primitive.ObjectID
+	data   map[string]any
+}
+
+// planPromotion pairs the records of both environments and works out the
+// writes. A target record is the counterpart of a source record when one was
+// promoted from the other, or both from the same record.
+func (s *EnvironmentService) planPromotion(project *models.Project, req *dtos.PromoteRequest) (*promotionPlan, error) {
+	from, err := resolveEnvironment(project, req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := resolveEnvironment(project, req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, errors.New("from and to must be different environments")
+	}
+
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, notDeleted(bson.M{"projectId": project.ID}), &collections, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+		return nil, err
+	}
+	if len(req.Collections) > 0 {
+		var chosen []models.Collection
+		for _, key := range req.Collections {
+			i := slices.IndexFunc(collections, func(c models.Collection) bool { return c.Name == key || c.ID.Hex() == key })
+			if i < 0 {
+				return nil, notFoundError(fmt.Sprintf("collection %q not found", key))
+			}
+			chosen = append(chosen, collections[i])
+		}
+		collections = chosen
+	}
+
+	// 🔗 Step 1: Pair the records and give the unpaired sources new ids
+	type pairing struct {
+		sources []models.Record
+		targets []models.Record
+		paired  map[primitive.ObjectID]int // source id → index in targets
+	}
+	pairings := make([]pairing, len(collections))
+	ids := map[string]string{}
+	for i, collection := range collections {
+		p := pairing{paired: map[primitive.ObjectID]int{}}
+		if err := s.recordColl.Find(s.ctx, notDeleted(inEnvironment(bson.M{"collectionId": collection.ID}, from)), &p.sources, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+			return nil, err
+		}
+		if err := s.recordColl.Find(s.ctx, notDeleted(inEnvironment(bson.M{"collectionId": collection.ID}, to)), &p.targets, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+			return nil, err
+		}
+		taken := map[int]bool{}
+		for _, source := range p.sources {
+			j := slices.IndexFunc(p.targets, func(target models.Record) bool {
+				return counterparts(&source, &target)
+			})
+			if j >= 0 && !taken[j] {
+				taken[j] = true
+				p.paired[source.ID] = j
+				ids[source.ID.Hex()] = p.targets[j].ID.Hex()
+				continue
+			}
+			ids[source.ID.Hex()] = primitive.NewObjectID().Hex()
+		}
+		pairings[i] = p
+	}
+
+	// 🧮 Step 2: Diff every collection with the references rewritten
+	plan := &promotionPlan{diff: &dtos.PromotionDiff{From: environmentLabel(from), To: environmentLabel(to), Collections: []dtos.CollectionPromotionDiff{}}}
+	for i := range collections {
+		collection, p := &collections[i], pairings[i]
+		diff := dtos.CollectionPromotionDiff{
+			CollectionID: collection.ID.Hex(),
+			Name:         collection.Name,
+			Created:      []string{},
+			Updated:      []dtos.PromotedRecord{},
+			Deleted:      []string{},
+		}
+		step := promotionStep{collection: collection}
+
+		kept := map[int]bool{}
+		for _, source := range p.sources {
+			data, _ := remapIDs(utils.NormalizeBSON(source.Data), ids).(map[string]any)
+			origin := source.ID
+			if source.PromotedFrom != nil {
+				origin = *source.PromotedFrom
+			}
+
+			j, ok := p.paired[source.ID]
+			if !ok {
+				newID, _ := primitive.ObjectIDFromHex(ids[source.ID.Hex()])
+				diff.Created = append(diff.Created, source.ID.Hex())
+				step.creates = append(step.creates, models.Record{
+					ID:           newID,
+					CollectionID: collection.ID,
+					Data:         data,
+					Version:      1,
+					Environment:  to,
+					PromotedFrom: &origin,
+				})
+				continue
+			}
+
+			kept[j] = true
+			target := &p.targets[j]
+			changes := diffFields(utils.NormalizeBSON(target.Data).(map[string]any), data)
+			if len(changes) == 0 {
+				diff.Unchanged++
+				continue
+			}
+			diff.Updated = append(diff.Updated, dtos.PromotedRecord{RecordID: target.ID.Hex(), SourceID: source.ID.Hex(), Changes: changes})
+			step.updates = append(step.updates, promotedUpdate{target: target, source: &origin, data: data})
+		}
+		for j, target := range p.targets {
+			if !kept[j] {
+				diff.Deleted = append(diff.Deleted, target.ID.Hex())
+				step.deletes = append(step.deletes, target.ID)
+			}
+		}
+
+		plan.diff.Collections = append(plan.diff.Collections, diff)
+		plan.steps = append(plan.steps, step)
+	}
+
+	return plan, nil
+}
+
+// counterparts tells if two records of different environments stand for the
+// same record: one was promoted from the other, or both from the same one
+func counterparts(a, b *models.Record) bool {
+	switch {
+	case a.PromotedFrom != nil && *a.PromotedFrom == b.ID:
+		return true
+	case b.PromotedFrom != nil && *b.PromotedFrom == a.ID:
+		return true
+	case a.PromotedFrom != nil && b.PromotedFrom != nil:
+		return *a.PromotedFrom == *b.PromotedFrom
+	}
+	return false
+}
+
+func (s *EnvironmentService) authorize(projectID, userID, minRole string) (*models.Project, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	return s.access.authorizeProject(pid, userID, minRole)
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxHARBytes       = 20 << 20
+	harOnConflictSkip = "skip"
+)
+
+// harFile is the part of a HAR 1.2 archive the import reads
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Time     float64     `json:"time"` // total milliseconds, -1 when unknown
+	Request  harRequest  `json:"request"`
+	Response harResponse `json:"response"`
+	Timings  struct {
+		Wait float64 `json:"wait"` // milliseconds spent waiting for the server
+	} `json:"timings"`
+}
+
+type harRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers []harHeader `json:"headers"`
+}
+
+type harResponse struct {
+	Status  int         `json:"status"`
+	Headers []harHeader `json:"headers"`
+	Content struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding"` // base64 for binary bodies
+	} `json:"content"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harRoute gathers the recorded variants of one method and path, by query
+type harRoute struct {
+	method   string
+	path     string
+	host     string
+	entry    int // last entry recorded
+	queries  []string
+	variants map[string]harVariant
+}
+
+type harVariant struct {
+	query    url.Values
+	response models.MockResponse
+}
+
+// HARService turns HTTP archives captured by browser DevTools into custom endpoints.
+type HARService struct {
+	endpointColl store.Repository
+	access       *projectAccess
+	audit        *auditLog
+	ctx          context.Context
+	cfg          *config.Config
+}
+
+func NewHARService(db store.Store, cfg *config.Config) *HARService {
+	return &HARService{
+		endpointColl: db.Repository(database.Collections.Endpoints),
+		access:       newProjectAccess(db),
+		audit:        newAuditLog(db),
+		ctx:          context.Background(),
+		cfg:          cfg,
+	}
+}
+
+// ImportHAR adds a custom endpoint per method and path recorded in a HAR,
+// answering with the recorded status, headers and body. Requests differing
+// only by their query become rules; repeated requests keep the last answer.
+func (s *HARService) ImportHAR(projectID string, actor dtos.Actor, raw []byte, query *dtos.HARImportQuery) (*dtos.HARImportResult, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	project, err := s.access.authorizeProject(pid, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxHARBytes {
+		return nil, fmt.Errorf("HAR files are limited to %d bytes", maxHARBytes)
+	}
+
+	// 📄 Step 1: Read the archive
+	var har harFile
+	if err := json.Unmarshal(raw, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR: %v", err)
+	}
+	if har.Log.Entries == nil {
+		return nil, errors.New("invalid HAR: log.entries is missing")
+	}
+
+	hosts := splitList(query.Hosts)
+	prefixes := splitList(query.Paths)
+	for i := range prefixes {
+		prefixes[i] = "/" + strings.Trim(prefixes[i], "/")
+	}
+
+	// 🧹 Step 2: Filter the entries and fold them into routes
+	result := &dtos.HARImportResult{Endpoints: []string{}, Skipped: []dtos.HARSkippedEntry{}}
+	routes := map[string]*harRoute{}
+	order := []string{}
+	for i := range har.Log.Entries {
+		entry := &har.Log.Entries[i]
+		method := strings.ToUpper(entry.Request.Method)
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, dtos.HARSkippedEntry{Entry: i, Request: method + " " + entry.Request.URL, Reason: reason})
+		}
+
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			skip("not an http(s) URL")
+			continue
+		}
+		path := "/" + strings.Trim(u.Path, "/")
+		if !harHostMatches(hosts, u.Hostname()) || !harPathMatches(prefixes, path) {
+			result.Filtered++
+			continue
+		}
+
+		switch {
+		case !slices.Contains(mockMethods, method):
+			skip("unsupported method")
+			continue
+		case method == http.MethodOptions && harHeaderValue(entry.Request.Headers, "Access-Control-Request-Method") != "":
+			skip("CORS preflight, answered by the mock server itself")
+			continue
+		case entry.Response.Status < 200 || entry.Response.Status > 599:
+			// 0 for blocked or failed requests, 101 for websockets
+			skip(fmt.Sprintf("no usable response (status %d)", entry.Response.Status))
+			continue
+		case strings.Contains(path, "/:"):
+			skip("path segments starting with ':' would become path parameters")
+			continue
+		}
+
+		response, err := harMockResponse(entry, query.Latency)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+
+		key := method + " " + path
+		route, ok := routes[key]
+		if !ok {
+			route = &harRoute{method: method, path: path, host: u.Host, variants: map[string]harVariant{}}
+			routes[key] = route
+			order = append(order, key)
+		}
+		variant := u.Query().Encode()
+		if _, seen := route.variants[variant]; seen {
+			result.Duplicates++
+		} else {
+			route.queries = append(route.queries, variant)
+		}
+		route.variants[variant] = harVariant{query: u.Query(), response: *response}
+		route.entry = i
+	}
+
+	// 💾 Step 3: Write the endpoints
+	for _, key := range order {
+		route := routes[key]
+		input := route.endpoint()
+		if err := normalizeEndpointInput(&input); err != nil {
+			result.Skipped = append(result.Skipped, dtos.HARSkippedEntry{Entry: route.entry, Request: key, Reason: err.Error()})
+			continue
+		}
+
+		var existing models.Endpoint
+		err := s.endpointColl.FindOne(s.ctx, bson.M{"projectId": project.ID, "method": input.Method, "path": input.Path}, &existing)
+		switch {
+		case err == store.ErrNotFound:
+			endpoint := &models.Endpoint{
+				ID:          primitive.NewObjectID(),
+				ProjectID:   project.ID,
+				Method:      input.Method,
+				Path:        input.Path,
+				Description: input.Description,
+				Rules:       input.Rules,
+				Response:    input.Response,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if err := s.endpointColl.InsertOne(s.ctx, endpoint); err != nil {
+				return nil, err
+			}
+			result.Created++
+		case err != nil:
+			return nil, err
+		case query.OnConflict == "" || query.OnConflict == harOnConflictSkip:
+			result.Skipped = append(result.Skipped, dtos.HARSkippedEntry{Entry: route.entry, Request: key, Reason: "the project already has this endpoint"})
+			continue
+		default:
+			set := bson.M{
+				"description": input.Description,
+				"rules":       input.Rules,
+				"response":    input.Response,
+				"updatedAt":   time.Now(),
+			}
+			if _, err := s.endpointColl.UpdateOne(s.ctx, bson.M{"_id": existing.ID}, bson.M{"$set": set}); err != nil {
+				return nil, err
+			}
+			result.Updated++
+		}
+		result.Endpoints = append(result.Endpoints, key)
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectImport,
+		TargetType: "project",
+		TargetID:   project.ID.Hex(),
+		ProjectID:  &project.ID,
+		Changes: diffFields(nil, map[string]any{
+			"source":  "har",
+			"created": result.Created,
+			"updated": result.Updated,
+		}),
+	})
+
+	return result, nil
+}
+
+// endpoint serves the variant without a query (or the first one recorded) by
+// default, and every other variant through a rule on its query parameters
+func (r *harRoute) endpoint() dtos.EndpointRequestDto {
+	fallback := r.queries[0]
+	if _, ok := r.variants[""]; ok {
+		fallback = ""
+	}
+
+	input := dtos.EndpointRequestDto{
+		Method:      r.method,
+		Path:        r.path,
+		Description: "Imported from a HAR of " + r.host,
+		Rules:       []models.ResponseRule{},
+		Response:    r.variants[fallback].response,
+	}
+	for _, query := range r.queries {
+		variant := r.variants[query]
+		if query == "" || len(r.queries) == 1 {
+			continue
+		}
+		keys := make([]string, 0, len(variant.query))
+		for key := range variant.query {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		conditions := make([]models.RuleCondition, 0, len(keys))
+		for _, key := range keys {
+			conditions = append(conditions, models.RuleCondition{Source: "query", Key: key, Operator: "equals", Value: variant.query.Get(key)})
+		}
+		input.Rules = withRecordedRule(input.Rules, models.ResponseRule{Name: "?" + query, Conditions: conditions, Response: variant.response})
+	}
+	return input
+}
+
+// harMockResponse builds the mock response of an entry. Bodies are kept as
+// JSON when they parse, as text otherwise; binary bodies cannot be served.
+func harMockResponse(entry *harEntry, latency bool) (*models.MockResponse, error) {
+	recorded := make(http.Header, len(entry.Response.Headers))
+	for _, header := range entry.Response.Headers {
+		// HTTP/2 pseudo headers such as :status
+		if !strings.HasPrefix(header.Name, ":") && header.Value != "" {
+			recorded.Add(header.Name, header.Value)
+		}
+	}
+	// Bodies in a HAR are already decoded, and the mock server answers CORS itself
+	headers := flattenHeaders(recorded, "Content-Encoding", "Date")
+	for name := range headers {
+		if strings.HasPrefix(name, "Access-Control-") {
+			delete(headers, name)
+		}
+	}
+	response := &models.MockResponse{Status: entry.Response.Status, Headers: stripHeaders(headers, defaultRedactHeaders)}
+
+	content := entry.Response.Content
+	text := content.Text
+	if content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, errors.New("invalid base64 response body")
+		}
+		text = string(decoded)
+	}
+	if text != "" {
+		if !harTextual(content.MimeType) || !utf8.ValidString(text) {
+			return nil, fmt.Errorf("binary response body (%s)", content.MimeType)
+		}
+		var body any
+		if strings.Contains(content.MimeType, "json") && json.Unmarshal([]byte(text), &body) == nil {
+			response.Body = body
+		} else {
+			if recorded.Get("Content-Type") == "" {
+				response.Headers["Content-Type"] = "text/plain; charset=utf-8"
+			}
+			response.Body = text
+		}
+	}
+
+	if latency {
+		// Waiting for the server is what a mock can replay; the rest was network
+		delay := entry.Timings.Wait
+		if delay <= 0 {
+			delay = entry.Time
+		}
+		response.DelayMs = min(max(int(math.Round(delay)), 0), maxMockDelayMs)
+	}
+
+	return response, nil
+}
+
+// harTextual tells whether a MIME type carries text a mock can serve back
+func harTextual(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	if mimeType == "" || strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, kind := range []string{"json", "xml", "javascript", "x-www-form-urlencoded", "graphql", "yaml"} {
+		if strings.Contains(mimeType, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func harHeaderValue(headers []harHeader, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// harHostMatches accepts exact hosts and *.domain wildcards; no filter accepts all
+func harHostMatches(hosts []string, host string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	for _, pattern := range hosts {
+		if strings.EqualFold(pattern, host) {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok && strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// harPathMatches accepts paths under one of the prefixes, segment by segment
+func harPathMatches(prefixes []string, path string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
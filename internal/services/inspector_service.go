@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCapturedBody is the largest request body kept in the inspector log.
+const maxCapturedBody = 64 * 1024
+
+var errInspectorUnavailable = errors.New("request inspector requires Redis")
+
+// InspectorService keeps a capped per-project log of mock requests in Redis.
+type InspectorService struct {
+	access *projectAccess
+	ctx    context.Context
+	cfg    *config.Config
+}
+
+func NewInspectorService(db store.Store, cfg *config.Config) *InspectorService {
+	return &InspectorService{
+		access: newProjectAccess(db),
+		ctx:    context.Background(),
+		cfg:    cfg,
+	}
+}
+
+// Record appends a captured request to the project log, trims it to the
+// configured size and publishes it for live tails. Credential headers are
+// stored as [REDACTED]. Failures are only logged.
+func (s *InspectorService) Record(entry *dtos.RequestLogEntry) {
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return
+	}
+
+	entry.Headers = redactHeaders(entry.Headers, defaultRedactHeaders)
+	if len(entry.Body) > maxCapturedBody {
+		entry.Body = entry.Body[:maxCapturedBody]
+		entry.BodyTruncated = true
+	}
+
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[INSPECTOR] Failed to encode request log entry: %v", err)
+		return
+	}
+
+	logKey := redisClient.ProjectRequestsLogKey(entry.ProjectID)
+	pipe := rdb.TxPipeline()
+	pipe.LPush(s.ctx, logKey, payload)
+	pipe.LTrim(s.ctx, logKey, 0, int64(s.cfg.RequestLogLimit-1))
+	pipe.Incr(s.ctx, redisClient.ProjectRequestCountKey(entry.ProjectID))
+	pipe.Publish(s.ctx, redisClient.ProjectRequestsChannelKey(entry.ProjectID), payload)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		log.Printf("[INSPECTOR] Failed to record request for project %s: %v", entry.ProjectID, err)
+	}
+}
+
+// ListRequests returns captured requests, newest first, matching the filter
+func (s *InspectorService) ListRequests(projectID, userID string, filter dtos.RequestLogFilter) (*dtos.RequestLogListResponse, error) {
+	if err := s.checkProjectRole(projectID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.loadEntries(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dtos.RequestLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.Method != "" && !strings.EqualFold(entry.Method, filter.Method) {
+			continue
+		}
+		if filter.Path != "" && !strings.Contains(entry.Path, filter.Path) {
+			continue
+		}
+		if filter.Status != 0 && entry.Status != filter.Status {
+			continue
+		}
+		items = append(items, entry)
+		if filter.Limit > 0 && len(items) >= filter.Limit {
+			break
+		}
+	}
+
+	total, err := redisClient.GetClient().Get(s.ctx, redisClient.ProjectRequestCountKey(projectID)).Int64()
+	if err != nil {
+		total = int64(len(entries))
+	}
+
+	return &dtos.RequestLogListResponse{
+		Total:    total,
+		Captured: len(entries),
+		Items:    items,
+	}, nil
+}
+
+// GetRequest returns a single captured request by its id
+func (s *InspectorService) GetRequest(projectID, requestID, userID string) (*dtos.RequestLogEntry, error) {
+	if err := s.checkProjectRole(projectID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.loadEntries(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].ID == requestID {
+			return &entries[i], nil
+		}
+	}
+
+	return nil, notFoundError("request not found")
+}
+
+// ClearRequests drops the captured log of a project (the total counter is kept)
+func (s *InspectorService) ClearRequests(projectID, userID string) error {
+	if err := s.checkProjectRole(projectID, userID, models.RoleEditor); err != nil {
+		return err
+	}
+
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return errInspectorUnavailable
+	}
+
+	return rdb.Del(s.ctx, redisClient.ProjectRequestsLogKey(projectID)).Err()
+}
+
+// TailRequests streams newly captured requests until ctx is cancelled
+func (s *InspectorService) TailRequests(ctx context.Context, projectID, userID string) (<-chan dtos.RequestLogEntry, error) {
+	if err := s.checkProjectRole(projectID, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return nil, errInspectorUnavailable
+	}
+
+	sub := rdb.Subscribe(ctx, redisClient.ProjectRequestsChannelKey(projectID))
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	out := make(chan dtos.RequestLogEntry)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var entry dtos.RequestLogEntry
+				if err := json.Unmarshal([]byte(msg.Payload), &entry); err != nil {
+					continue
+				}
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (s *InspectorService) loadEntries(projectID string) ([]dtos.RequestLogEntry, error) {
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return nil, errInspectorUnavailable
+	}
+
+	raw, err := rdb.LRange(s.ctx, redisClient.ProjectRequestsLogKey(projectID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]dtos.RequestLogEntry, 0, len(raw))
+	for _, item := range raw {
+		var entry dtos.RequestLogEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (s *InspectorService) checkProjectRole(projectID, userID, minRole string) error {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return errors.New("invalid project id")
+	}
+
+	_, err = s.access.authorizeProject(pid, userID, minRole)
+	return err
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// invitationTTL is how long an invitation link stays valid
+const invitationTTL = 7 * 24 * time.Hour
+
+// MemberService manages project collaborators and their invitations.
+type MemberService struct {
+	projectColl    store.Repository
+	invitationColl store.Repository
+	access         *projectAccess
+	userColl       store.Repository
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewMemberService(db store.Store, cfg *config.Config) *MemberService {
+	return &MemberService{
+		projectColl:    db.Repository(database.Collections.Projects),
+		invitationColl: db.Repository(database.Collections.Invitations),
+		access:         newProjectAccess(db),
+		userColl:       db.Repository(database.Collections.Users),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// ListMembers returns the owner and collaborators of a project
+func (s *MemberService) ListMembers(projectID, userID string) ([]dtos.ProjectMemberResponse, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	members := append([]models.ProjectMember{{
+		UserID:  project.UserID,
+		Role:    models.RoleOwner,
+		AddedAt: project.CreatedAt,
+	}}, project.Members...)
+
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	var users []models.User
+	if err := s.userColl.Find(s.ctx, bson.M{"_id": bson.M{"$in": ids}}, &users); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	result := make([]dtos.ProjectMemberResponse, 0, len(members))
+	for _, m := range members {
+		user := byID[m.UserID]
+		result = append(result, dtos.ProjectMemberResponse{
+			UserID:   m.UserID.Hex(),
+			FullName: user.FullName,
+			Email:    user.Email,
+			Role:     m.Role,
+			AddedAt:  m.AddedAt,
+			IsOwner:  m.UserID == project.UserID,
+		})
+	}
+
+	return result, nil
+}
+
+// InviteMember emails an invitation link; any pending invitation for the same
+// address is replaced.
+func (s *MemberService) InviteMember(projectID, userID string, req *dtos.InviteMemberRequest) (*models.ProjectInvitation, error) {
+	project, err := s.authorize(projectID, userID, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	role := req.Role
+	if role == "" {
+		role = models.RoleViewer
+	}
+	if _, ok := roleLevels[role]; !ok {
+		return nil, errors.New("role must be owner, editor or viewer")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	var invitee models.User
+	if err := s.userColl.FindOne(s.ctx, bson.M{"email": email}, &invitee); err == nil {
+		if projectRole(project, invitee.ID) != "" {
+			return nil, errors.New("user is already a member of this project")
+		}
+	}
+
+	inviter, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.invitationColl.DeleteMany(s.ctx, bson.M{
+		"projectId": project.ID,
+		"email":     email,
+		"status":    models.InvitationPending,
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation := &models.ProjectInvitation{
+		ID:          primitive.NewObjectID(),
+		ProjectID:   project.ID,
+		ProjectName: project.Name,
+		Email:       email,
+		Role:        role,
+		Token:       utils.GenerateVerificationToken(),
+		InvitedBy:   inviter.ID,
+		Status:      models.InvitationPending,
+		ExpiresAt:   now.Add(invitationTTL),
+		CreatedAt:   now,
+	}
+	if err := s.invitationColl.InsertOne(s.ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	s.sendInvitationEmail(invitation, inviter.FullName)
+
+	return invitation, nil
+}
+
+// ListInvitations returns the invitations of a project
+func (s *MemberService) ListInvitations(projectID, userID string) ([]models.ProjectInvitation, error) {
+	project, err := s.authorize(projectID, userID, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := []models.ProjectInvitation{}
+	if err := s.invitationColl.Find(s.ctx, bson.M{"projectId": project.ID}, &invitations, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// CancelInvitation withdraws a pending invitation
+func (s *MemberService) CancelInvitation(projectID, invitationID, userID string) error {
+	project, err := s.authorize(projectID, userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	iid, err := primitive.ObjectIDFromHex(invitationID)
+	if err != nil {
+		return errors.New("invalid invitation id")
+	}
+
+	deleted, err := s.invitationColl.DeleteOne(s.ctx, bson.M{
+		"_id":       iid,
+		"projectId": project.ID,
+		"status":    models.InvitationPending,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return notFoundError("invitation not found")
+	}
+
+	return nil
+}
+
+// MyInvitations lists the pending invitations addressed to the user's email
+func (s *MemberService) MyInvitations(userID string) ([]models.ProjectInvitation, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := []models.ProjectInvitation{}
+	err = s.invitationColl.Find(s.ctx, bson.M{
+		"email":     strings.ToLower(user.Email),
+		"status":    models.InvitationPending,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}, &invitations)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// AcceptInvitation adds the user to the project with the invited role
+func (s *MemberService) AcceptInvitation(token, userID string) (*models.Project, error) {
+	invitation, user, err := s.pendingInvitation(token, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var project models.Project
+	if err := s.projectColl.FindOne(s.ctx, notDeleted(bson.M{"_id": invitation.ProjectID}), &project); err != nil {
+		return nil, notFoundError("project not found")
+	}
+
+	if projectRole(&project, user.ID) == "" {
+		member := models.ProjectMember{UserID: user.ID, Role: invitation.Role, AddedAt: time.Now()}
+		if _, err := s.projectColl.UpdateOne(s.ctx,
+			bson.M{"_id": project.ID},
+			bson.M{"$push": bson.M{"members": member}, "$set": bson.M{"updatedAt": time.Now()}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.respond(invitation, models.InvitationAccepted); err != nil {
+		return nil, err
+	}
+
+	var updated models.Project
+	if err := s.projectColl.FindOne(s.ctx, bson.M{"_id": project.ID}, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeclineInvitation rejects an invitation addressed to the user
+func (s *MemberService) DeclineInvitation(token, userID string) error {
+	invitation, _, err := s.pendingInvitation(token, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.respond(invitation, models.InvitationDeclined)
+}
+
+// UpdateMemberRole changes a collaborator's role. The project creator always
+// stays owner.
+func (s *MemberService) UpdateMemberRole(projectID, memberID, userID, role string) error {
+	project, err := s.authorize(projectID, userID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if _, ok := roleLevels[role]; !ok {
+		return errors.New("role must be owner, editor or viewer")
+	}
+	mid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return errors.New("invalid member id")
+	}
+	if mid == project.UserID {
+		return errors.New("the project creator's role cannot be changed")
+	}
+
+	// Rewrite the whole list so every storage driver can apply the change
+	members := project.Members
+	found := false
+	for i := range members {
+		if members[i].UserID == mid {
+			members[i].Role = role
+			found = true
+		}
+	}
+	if !found {
+		return notFoundError("member not found")
+	}
+
+	_, err = s.projectColl.UpdateOne(s.ctx,
+		bson.M{"_id": project.ID},
+		bson.M{"$set": bson.M{"members": members, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// RemoveMember removes a collaborator. Owners can remove anyone but the
+// project creator; any member can remove themselves to leave the project.
+func (s *MemberService) RemoveMember(projectID, memberID, userID string) error {
+	minRole := models.RoleOwner
+	if memberID == userID {
+		minRole = models.RoleViewer
+	}
+
+	project, err := s.authorize(projectID, userID, minRole)
+	if err != nil {
+		return err
+	}
+	mid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return errors.New("invalid member id")
+	}
+	if mid == project.UserID {
+		return errors.New("the project creator cannot be removed")
+	}
+
+	matched, err := s.projectColl.UpdateOne(s.ctx,
+		bson.M{"_id": project.ID, "members.userId": mid},
+		bson.M{"$pull": bson.M{"members": bson.M{"userId": mid}}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return notFoundError("member not found")
+	}
+
+	return nil
+}
+
+func (s *MemberService) authorize(projectID, userID, minRole string) (*models.Project, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	if userID == "" {
+		return nil, errors.New("invalid user id")
+	}
+
+	return s.access.authorizeProject(pid, userID, minRole)
+}
+
+func (s *MemberService) findUser(userID string) (*models.User, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	var user models.User
+	if err := s.userColl.FindOne(s.ctx, bson.M{"_id": uid}, &user); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return &user, nil
+}
+
+// pendingInvitation loads a usable invitation addressed to the user
+func (s *MemberService) pendingInvitation(token, userID string) (*models.ProjectInvitation, *models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var invitation models.ProjectInvitation
+	if err := s.invitationColl.FindOne(s.ctx, bson.M{"token": token}, &invitation); err != nil {
+		return nil, nil, notFoundError("invitation not found")
+	}
+	if invitation.Status != models.InvitationPending {
+		return nil, nil, fmt.Errorf("invitation already %s", invitation.Status)
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, nil, errors.New("invitation expired")
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, nil, forbiddenError("this invitation was sent to a different email address")
+	}
+
+	return &invitation, user, nil
+}
+
+func (s *MemberService) respond(invitation *models.ProjectInvitation, status string) error {
+	now := time.Now()
+	_, err := s.invitationColl.UpdateOne(s.ctx,
+		bson.M{"_id": invitation.ID},
+		bson.M{"$set": bson.M{"status": status, "respondedAt": now}},
+	)
+	return err
+}
+
+func (s *MemberService) sendInvitationEmail(invitation *models.ProjectInvitation, inviterName string) {
+	if inviterName == "" {
+		inviterName = "A teammate"
+	}
+
+	link := fmt.Sprintf("%s/invitations/%s", s.cfg.AppBaseURL, invitation.Token)
+	log.Printf("[EMAIL MOCK] Invitation link for %s: %s\n", invitation.Email, link)
+
+	subject := fmt.Sprintf("You're invited to %s - MockNode", invitation.ProjectName)
+	html := utils.BuildEmailTemplate(
+		"MockNode",
+		"You've been invited 🤝",
+		fmt.Sprintf(`%s invited you to join <strong>%s</strong> on MockNode as <strong>%s</strong>.<br><br>
+	Sign in (or create an account with this email address) and accept the invitation to start collaborating.<br><br>
+	This invitation expires in 7 days.`, inviterName, invitation.ProjectName, invitation.Role),
+		"Accept Invitation",
+		link,
+	)
+
+	if err := utils.SendEmail(s.cfg, invitation.Email, subject, html); err != nil {
+		log.Printf("❌ Failed to send invitation email: %v\n", err)
+	} else {
+		log.Printf("✅ Invitation email sent to %s\n", invitation.Email)
+	}
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MockError carries the HTTP status a failed mock request should answer with.
+type MockError struct {
+	Status  int
+	Message string
+}
+
+func (e *MockError) Error() string {
+	return e.Message
+}
+
+func mockError(status int, message string) *MockError {
+	return &MockError{Status: status, Message: message}
+}
+
+// MockService resolves public mock requests against custom endpoints and
+// collection routes of a project.
+type MockService struct {
+	projectColl   store.Repository
+	endpointSvc   *EndpointService
+	collectionSvc *CollectionService
+	recordSvc     *RecordService
+	sessionSvc    *SessionService
+	proxySvc      *ProxyService
+	ctx           context.Context
+	cfg           *config.Config
+}
+
+func NewMockService(db store.Store, cfg *config.Config, endpointSvc *EndpointService, collectionSvc *CollectionService, recordSvc *RecordService, sessionSvc *SessionService, proxySvc *ProxyService) *MockService {
+	projectcollection := db.Repository(database.Collections.Projects)
+	return &MockService{
+		projectColl:   projectcollection,
+		endpointSvc:   endpointSvc,
+		collectionSvc: collectionSvc,
+		recordSvc:     recordSvc,
+		sessionSvc:    sessionSvc,
+		proxySvc:      proxySvc,
+		ctx:           context.Background(),
+		cfg:           cfg,
+	}
+}
+
+// Handle resolves a mock request. Custom endpoints win over collection routes;
+// within each, the first matching rule wins and otherwise the default applies.
+// Projects in record mode forward the request to their upstream instead.
+func (s *MockService) Handle(projectID string, req *MockRequest) (*models.MockResponse, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, mockError(http.StatusNotFound, "project not found")
+	}
+
+	var project models.Project
+	if err := s.projectColl.FindOne(s.ctx, notDeleted(bson.M{"_id": pid}), &project); err != nil {
+		if err == store.ErrNotFound {
+			return nil, mockError(http.StatusNotFound, "project not found")
+		}
+		return nil, err
+	}
+	req.Resolved = true
+	if isRestoring(&project) {
+		return nil, mockError(http.StatusConflict, errProjectRestoring.Message)
+	}
+
+	if err := s.sessionSvc.ConsumeRequest(&project); err != nil {
+		switch {
+		case errors.Is(err, ErrSessionQuotaExceeded):
+			return nil, mockError(http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, errSessionProjectExpired):
+			return nil, mockError(http.StatusGone, err.Error())
+		case errors.Is(err, ErrSessionsUnavailable):
+			return nil, mockError(http.StatusServiceUnavailable, err.Error())
+		}
+		return nil, err
+	}
+
+	// 🌐 Pick the environment: an /@name path segment wins over the header
+	name := req.Headers.Get(dtos.EnvironmentHeader)
+	if rest, ok := strings.CutPrefix(req.Path, "/@"); ok {
+		name, rest, _ = strings.Cut(rest, "/")
+		req.Path = "/" + rest
+	}
+	if req.Environment, err = resolveEnvironment(&project, name); err != nil {
+		return nil, mockError(http.StatusNotFound, err.Error())
+	}
+
+	// 🔁 Record mode answers from the upstream; replay mode tries the recordings first
+	if project.Proxy != nil {
+		switch project.Proxy.Mode {
+		case models.ProxyModeRecord:
+			return s.proxySvc.forward(&project, req)
+		case models.ProxyModeReplay:
+			if resp, err := s.proxySvc.replay(&project, req); err != nil || resp != nil {
+				return resp, err
+			}
+		}
+	}
+
+	endpoint, params, err := s.endpointSvc.MatchEndpoint(pid, req.Method, req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint != nil {
+		req.PathParams = params
+		if rule := matchRules(endpoint.Rules, req); rule != nil {
+			return &rule.Response, nil
+		}
+		return &endpoint.Response, nil
+	}
+
+	return s.handleCollectionRoute(pid, req)
+}
+
+func (s *MockService) handleCollectionRoute(pid primitive.ObjectID, req *MockRequest) (*models.MockResponse, error) {
+	segments := strings.Split(strings.Trim(req.Path, "/"), "/")
+	if len(segments) == 0 || segments[0] == "" || len(segments) > 2 {
+		return nil, mockError(http.StatusNotFound, "no mock route matches "+req.Path)
+	}
+
+	collection, err := s.collectionSvc.GetCollectionByName(pid, segments[0])
+	if err != nil {
+		return nil, mockError(http.StatusNotFound, "no mock route matches "+req.Path)
+	}
+
+	req.PathParams = map[string]string{}
+	if len(segments) == 2 {
+		req.PathParams["id"] = segments[1]
+	}
+
+	if rule := matchRules(collection.Rules, req); rule != nil {
+		return &rule.Response, nil
+	}
+
+	if len(segments) == 1 {
+		switch req.Method {
+		case http.MethodGet:
+			return s.listRecords(collection, req.Environment)
+		case http.MethodPost:
+			return s.createRecord(collection, req)
+		}
+		return nil, mockError(http.StatusMethodNotAllowed, "method not allowed")
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		return s.getRecord(collection, segments[1], req)
+	case http.MethodPut, http.MethodPatch:
+		return s.updateRecord(collection, segments[1], req)
+	case http.MethodDelete:
+		return s.deleteRecord(collection, segments[1], req)
+	}
+	return nil, mockError(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func (s *MockService) listRecords(collection *models.Collection, env string) (*models.MockResponse, error) {
+	if cached := s.cachedResponse(collection, cacheField(env, "list")); cached != nil {
+		return cached, nil
+	}
+
+	records, err := s.recordSvc.findRecords(collection.ID, env)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]any, 0, len(records))
+	for i := range records {
+		items = append(items, flattenRecord(&records[i]))
+	}
+	s.cacheResponse(collection, cacheField(env, "list"), items)
+
+	return &models.MockResponse{Status: http.StatusOK, Body: items}, nil
+}
+
+func (s *MockService) createRecord(collection *models.Collection, req *MockRequest) (*models.MockResponse, error) {
+	data, ok := req.Body.(map[string]any)
+	if !ok {
+		return nil, mockError(http.StatusBadRequest, "request body must be a JSON object")
+	}
+
+	record, err := s.recordSvc.insertRecord(collection, req.Environment, data, req.actor())
+	if err != nil {
+		return nil, mockError(http.StatusBadRequest, err.Error())
+	}
+
+	return recordResponse(http.StatusCreated, record), nil
+}
+
+// getRecord answers 304 without a body when If-None-Match lists the current
+// version of the record
+func (s *MockService) getRecord(collection *models.Collection, id string, req *MockRequest) (*models.MockResponse, error) {
+	field := cacheField(req.Environment, "item:"+id)
+	resp := s.cachedRecord(collection, field)
+	if resp == nil {
+		record, err := s.recordInCollection(collection, id, req.Environment)
+		if err != nil {
+			return nil, err
+		}
+
+		resp = recordResponse(http.StatusOK, record)
+		s.cacheResponse(collection, field, recordCacheEntry{ETag: resp.Headers["ETag"], Body: resp.Body})
+	}
+
+	if ifNoneMatch := req.Headers.Get("If-None-Match"); ifNoneMatch != "" && utils.ETagMatches(ifNoneMatch, resp.Headers["ETag"], true) {
+		resp.Status, resp.Body = http.StatusNotModified, nil
+	}
+	return resp, nil
+}
+
+// recordCacheEntry is the cache entry of a single record route, which keeps
+// the ETag along with the body
+type recordCacheEntry struct {
+	ETag string `json:"etag"`
+	Body any    `json:"body"`
+}
+
+// cachedRecord returns the cached GET response of a single record, if any
+func (s *MockService) cachedRecord(collection *models.Collection, field string) *models.MockResponse {
+	var cached recordCacheEntry
+	key := redisClient.ProjectDataKey(collection.ProjectID.Hex(), collection.Name)
+	if !redisClient.GetCachedField(key, field, &cached) {
+		return nil
+	}
+
+	return &models.MockResponse{
+		Status:  http.StatusOK,
+		Headers: map[string]string{"X-Cache": "HIT", "ETag": cached.ETag},
+		Body:    cached.Body,
+	}
+}
+
+// cachedResponse returns a cached GET response of a collection route, if any.
+// Entries live in one hash per collection so any write can drop them at once.
+func (s *MockService) cachedResponse(collection *models.Collection, field string) *models.MockResponse {
+	var body json.RawMessage
+	key := redisClient.ProjectDataKey(collection.ProjectID.Hex(), collection.Name)
+	if !redisClient.GetCachedField(key, field, &body) {
+		return nil
+	}
+
+	return &models.MockResponse{
+		Status:  http.StatusOK,
+		Headers: map[string]string{"X-Cache": "HIT"},
+		Body:    body,
+	}
+}
+
+// cacheField scopes a cache entry of a collection route to an environment
+func cacheField(env, field string) string {
+	if env == "" {
+		return field
+	}
+	return "@" + env + ":" + field
+}
+
+func (s *MockService) cacheResponse(collection *models.Collection, field string, body any) {
+	key := redisClient.ProjectDataKey(collection.ProjectID.Hex(), collection.Name)
+	redisClient.SetCachedField(key, field, body, s.cfg.ResponseCacheTTL)
+}
+
+func (s *MockService) updateRecord(collection *models.Collection, id string, req *MockRequest) (*models.MockResponse, error) {
+	data, ok := req.Body.(map[string]any)
+	if !ok {
+		return nil, mockError(http.StatusBadRequest, "request body must be a JSON object")
+	}
+
+	existing, err := s.recordInCollection(collection, id, req.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	// PATCH merges into the stored document; PUT replaces it
+	if req.Method == http.MethodPatch {
+		data = mergeRecordData(existing.Data, data)
+	}
+
+	record, err := s.recordSvc.replaceRecord(existing, data, req.actor(), req.Headers.Get("If-Match"))
+	if err != nil {
+		return nil, mockWriteError(err)
+	}
+
+	return recordResponse(http.StatusOK, record), nil
+}
+
+func (s *MockService) deleteRecord(collection *models.Collection, id string, req *MockRequest) (*models.MockResponse, error) {
+	record, err := s.recordInCollection(collection, id, req.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordSvc.removeRecord(collection, record, req.actor(), req.Headers.Get("If-Match")); err != nil {
+		return nil, mockWriteError(err)
+	}
+
+	return &models.MockResponse{Status: http.StatusNoContent}, nil
+}
+
+// recordInCollection finds a record of a collection route; records of other
+// environments are not found
+func (s *MockService) recordInCollection(collection *models.Collection, id, env string) (*models.Record, error) {
+	record, err := s.recordSvc.findRecord(id)
+	if err != nil || record.CollectionID != collection.ID || record.Environment != env {
+		return nil, mockError(http.StatusNotFound, "record not found")
+	}
+	return record, nil
+}
+
+// mockWriteError answers a failed record write with the status of an access
+// error (a stale If-Match, a concurrent change), and 400 otherwise
+func mockWriteError(err error) *MockError {
+	var accessErr *AccessError
+	if errors.As(err, &accessErr) {
+		return mockError(accessErr.Status, accessErr.Message)
+	}
+	return mockError(http.StatusBadRequest, err.Error())
+}
+
+// recordResponse answers with a record and its version as the ETag
+func recordResponse(status int, record *models.Record) *models.MockResponse {
+	return &models.MockResponse{
+		Status:  status,
+		Headers: map[string]string{"ETag": utils.VersionETag(record.Version)},
+		Body:    flattenRecord(record),
+	}
+}
+
+// flattenRecord exposes a record the way a REST API would: its data plus id and timestamps
+func flattenRecord(record *models.Record) map[string]any {
+	out := make(map[string]any, len(record.Data)+3)
+	for k, v := range record.Data {
+		out[k] = v
+	}
+	out["id"] = record.ID.Hex()
+	out["createdAt"] = record.CreatedAt
+	out["updatedAt"] = record.UpdatedAt
+	return out
+}
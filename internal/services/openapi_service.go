@@ -0,0 +1,1016 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxOpenAPIBytes  = 5 << 20
+	maxSchemaDepth   = 16 // bounds $ref chains and nested examples
+	preferCodeHeader = "Prefer"
+)
+
+var jsonMediaType = regexp.MustCompile(`(?i)^application/([a-z0-9.+-]+\+)?json`)
+
+// The subset of OpenAPI 3.x read by the import. Specs are converted to JSON
+// first, so YAML and JSON specs decode the same way.
+type openAPISpec struct {
+	OpenAPI    string                              `json:"openapi"`
+	Swagger    string                              `json:"swagger"`
+	Info       struct{ Title, Description string } `json:"info"`
+	Paths      orderedMap[*openAPIPathItem]        `json:"paths"`
+	Webhooks   map[string]any                      `json:"webhooks"`
+	Security   []any                               `json:"security"`
+	Components struct {
+		Schemas       map[string]*openAPISchema      `json:"schemas"`
+		Responses     map[string]*openAPIResponse    `json:"responses"`
+		RequestBodies map[string]*openAPIRequestBody `json:"requestBodies"`
+		Examples      map[string]*openAPIExample     `json:"examples"`
+	} `json:"components"`
+}
+
+type openAPIPathItem struct {
+	Ref     string            `json:"$ref"`
+	Get     *openAPIOperation `json:"get"`
+	Put     *openAPIOperation `json:"put"`
+	Post    *openAPIOperation `json:"post"`
+	Delete  *openAPIOperation `json:"delete"`
+	Options *openAPIOperation `json:"options"`
+	Head    *openAPIOperation `json:"head"`
+	Patch   *openAPIOperation `json:"patch"`
+	Trace   *openAPIOperation `json:"trace"`
+}
+
+type openAPIOperation struct {
+	OperationID string                       `json:"operationId"`
+	Summary     string                       `json:"summary"`
+	RequestBody *openAPIRequestBody          `json:"requestBody"`
+	Responses   orderedMap[*openAPIResponse] `json:"responses"`
+	Callbacks   map[string]any               `json:"callbacks"`
+	Security    []any                        `json:"security"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                        `json:"$ref"`
+	Content orderedMap[*openAPIMediaType] `json:"content"`
+}
+
+type openAPIResponse struct {
+	Ref     string                        `json:"$ref"`
+	Content orderedMap[*openAPIMediaType] `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema   *openAPISchema              `json:"schema"`
+	Example  any                         `json:"example"`
+	Examples orderedMap[*openAPIExample] `json:"examples"`
+}
+
+type openAPIExample struct {
+	Ref           string `json:"$ref"`
+	Value         any    `json:"value"`
+	ExternalValue string `json:"externalValue"`
+}
+
+type openAPISchema struct {
+	Ref              string                     `json:"$ref"`
+	Type             any                        `json:"type"` // a name, or a list of names in OpenAPI 3.1
+	Format           string                     `json:"format"`
+	Description      string                     `json:"description"`
+	Enum             []any                      `json:"enum"`
+	Properties       orderedMap[*openAPISchema] `json:"properties"`
+	Required         []string                   `json:"required"`
+	Items            *openAPISchema             `json:"items"`
+	AllOf            []*openAPISchema           `json:"allOf"`
+	OneOf            []*openAPISchema           `json:"oneOf"`
+	AnyOf            []*openAPISchema           `json:"anyOf"`
+	Not              *openAPISchema             `json:"not"`
+	MinLength        *int                       `json:"minLength"`
+	MaxLength        *int                       `json:"maxLength"`
+	Minimum          *float64                   `json:"minimum"`
+	Maximum          *float64                   `json:"maximum"`
+	ExclusiveMinimum any                        `json:"exclusiveMinimum"`
+	ExclusiveMaximum any                        `json:"exclusiveMaximum"`
+	Pattern          string                     `json:"pattern"`
+	Default          any                        `json:"default"`
+	Example          any                        `json:"example"`
+}
+
+// orderedMap is a JSON object that remembers the order of its keys, so
+// collections get their fields, and projects their endpoints, in spec order
+type orderedMap[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (m *orderedMap[T]) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.values); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		m.keys = append(m.keys, key.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openAPIOp is one operation of a path
+type openAPIOp struct {
+	method string
+	path   string
+	op     *openAPIOperation
+}
+
+func (o openAPIOp) location() string {
+	return "paths." + o.path + "." + strings.ToLower(o.method)
+}
+
+// openAPIResource gathers the operations that collection routes serve:
+// list and create on /name, read, replace, update and delete on /name/{id}
+type openAPIResource struct {
+	name string
+	ops  []openAPIOp
+}
+
+// OpenAPIService generates mock projects from OpenAPI 3.x specifications.
+type OpenAPIService struct {
+	projectColl store.Repository
+	orgColl     store.Repository
+	templates   *TemplateService
+	audit       *auditLog
+	ctx         context.Context
+	cfg         *config.Config
+}
+
+func NewOpenAPIService(db store.Store, cfg *config.Config, templates *TemplateService) *OpenAPIService {
+	return &OpenAPIService{
+		projectColl: db.Repository(database.Collections.Projects),
+		orgColl:     db.Repository(database.Collections.Organizations),
+		templates:   templates,
+		audit:       newAuditLog(db),
+		ctx:         context.Background(),
+		cfg:         cfg,
+	}
+}
+
+// ImportOpenAPI creates a project from a YAML or JSON spec: a collection per
+// resource schema, a custom endpoint per other operation, and optionally
+// records. What could not be mapped is listed in the report.
+func (s *OpenAPIService) ImportOpenAPI(actor dtos.Actor, orgID string, raw []byte, query *dtos.OpenAPIImportQuery) (*dtos.OpenAPIImportResult, error) {
+	if len(raw) > maxOpenAPIBytes {
+		return nil, fmt.Errorf("specs are limited to %d bytes", maxOpenAPIBytes)
+	}
+
+	// 📄 Step 1: Read the spec
+	converted, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	var spec openAPISpec
+	if err := json.Unmarshal(converted, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	if spec.Swagger != "" {
+		return nil, errors.New("Swagger 2.0 specs are not supported, convert them to OpenAPI 3 first")
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, errors.New("not an OpenAPI 3.x spec: the openapi field is missing or unsupported")
+	}
+
+	// 🧩 Step 2: Map it onto collections, endpoints and records
+	converter := &openAPIConverter{spec: &spec, report: []dtos.OpenAPIIssue{}, seen: map[dtos.OpenAPIIssue]bool{}}
+	content := converter.blueprint(query.Records)
+
+	// 🏗️ Step 3: Create the project
+	scope, err := importScope(s.orgColl, actor, orgID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(query.Name)
+	if name == "" {
+		name = strings.TrimSpace(spec.Info.Title)
+	}
+	if name == "" {
+		name = "Imported API"
+	}
+	if name, err = freeProjectName(s.projectColl, scope, name); err != nil {
+		return nil, err
+	}
+	project, err := s.templates.instantiate(actor, orgID, name, spec.Info.Description, content)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dtos.OpenAPIImportResult{
+		Project:     project,
+		Collections: []string{},
+		Endpoints:   len(content.endpoints),
+		Records:     len(content.records),
+		Report:      converter.report,
+	}
+	for _, collection := range content.collections {
+		result.Collections = append(result.Collections, collection.Name)
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectImport,
+		TargetType: "project",
+		TargetID:   project.ID.Hex(),
+		ProjectID:  &project.ID,
+		Changes: diffFields(nil, map[string]any{
+			"source":      "openapi",
+			"collections": len(content.collections),
+			"records":     result.Records,
+			"endpoints":   result.Endpoints,
+		}),
+	})
+
+	return result, nil
+}
+
+// openAPIConverter maps a spec onto project content and reports what it could not map
+type openAPIConverter struct {
+	spec   *openAPISpec
+	report []dtos.OpenAPIIssue
+	seen   map[dtos.OpenAPIIssue]bool
+}
+
+func (c *openAPIConverter) issue(location, format string, args ...any) {
+	issue := dtos.OpenAPIIssue{Location: location, Message: fmt.Sprintf(format, args...)}
+	if !c.seen[issue] {
+		c.seen[issue] = true
+		c.report = append(c.report, issue)
+	}
+}
+
+func (c *openAPIConverter) blueprint(records int) *blueprint {
+	content := &blueprint{ownerID: primitive.NewObjectID()}
+
+	if len(c.spec.Webhooks) > 0 {
+		c.issue("webhooks", "webhooks are not supported and were skipped")
+	}
+	if len(c.spec.Security) > 0 {
+		c.issue("security", "security requirements are not enforced by mock routes")
+	}
+
+	// 🔀 Split operations between collection routes and custom endpoints
+	resources := map[string]*openAPIResource{}
+	var order []string
+	var others []openAPIOp
+	for _, path := range c.spec.Paths.keys {
+		item := c.spec.Paths.values[path]
+		if item == nil {
+			continue
+		}
+		if item.Ref != "" {
+			c.issue("paths."+path, "$ref path items are not supported; the path was skipped")
+			continue
+		}
+		for _, op := range item.operations(path) {
+			if op.method == http.MethodTrace {
+				c.issue(op.location(), "TRACE operations are not supported and were skipped")
+				continue
+			}
+			if len(op.op.Callbacks) > 0 {
+				c.issue(op.location()+".callbacks", "callbacks are not supported and were skipped")
+			}
+			if len(op.op.Security) > 0 {
+				c.issue(op.location()+".security", "security requirements are not enforced by mock routes")
+			}
+
+			name, ok := resourceName(path, op.method)
+			if !ok {
+				others = append(others, op)
+				continue
+			}
+			if resources[name] == nil {
+				resources[name] = &openAPIResource{name: name}
+				order = append(order, name)
+			}
+			resources[name].ops = append(resources[name].ops, op)
+		}
+	}
+
+	// 🗂️ Resources with an object schema become collections
+	for _, name := range order {
+		resource := resources[name]
+		// A lone GET /name is an ordinary endpoint rather than a resource
+		// worth reporting about
+		lone := len(resource.ops) == 1
+		// A list that does not answer an array (a paginated envelope, say)
+		// keeps its documented shape as a custom endpoint
+		resource.ops = slices.DeleteFunc(resource.ops, func(op openAPIOp) bool {
+			if op.method != http.MethodGet || strings.Contains(op.path[1:], "/") {
+				return false
+			}
+			schema, loc := c.resolve(c.responseSchema(op), op.location())
+			if schema != nil && schemaType(schema) != "array" {
+				if !lone {
+					c.issue(loc, "the list of %s is not an array; it is served as a custom endpoint", name)
+				}
+				others = append(others, op)
+				return true
+			}
+			return false
+		})
+		if len(resource.ops) == 0 {
+			continue
+		}
+
+		schema, loc := c.resourceSchema(resource)
+		if schema == nil {
+			if !lone {
+				c.issue("paths./"+name, "no object schema found for %s; its operations are served as custom endpoints", name)
+			}
+			others = append(others, resource.ops...)
+			continue
+		}
+
+		collection := models.Collection{ID: primitive.NewObjectID(), Name: name, Fields: c.fields(schema, loc)}
+		content.collections = append(content.collections, collection)
+		for _, data := range c.records(&collection, c.resourceExamples(resource), records) {
+			content.records = append(content.records, models.Record{ID: primitive.NewObjectID(), CollectionID: collection.ID, Data: data})
+		}
+	}
+
+	// 🔧 Everything else answers its documented examples
+	for _, op := range others {
+		if endpoint, ok := c.endpoint(op); ok {
+			content.endpoints = append(content.endpoints, *endpoint)
+		}
+	}
+
+	return content
+}
+
+func (item *openAPIPathItem) operations(path string) []openAPIOp {
+	var ops []openAPIOp
+	for _, candidate := range []struct {
+		method string
+		op     *openAPIOperation
+	}{
+		{http.MethodGet, item.Get}, {http.MethodPost, item.Post}, {http.MethodPut, item.Put}, {http.MethodPatch, item.Patch},
+		{http.MethodDelete, item.Delete}, {http.MethodHead, item.Head}, {http.MethodOptions, item.Options}, {http.MethodTrace, item.Trace},
+	} {
+		if candidate.op != nil {
+			ops = append(ops, openAPIOp{method: candidate.method, path: path, op: candidate.op})
+		}
+	}
+	return ops
+}
+
+// resourceName tells whether collection routes serve an operation, and of which collection
+func resourceName(path, method string) (string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] == "" || isPathParam(segments[0]) || strings.ContainsAny(segments[0], "{}@") {
+		return "", false
+	}
+	switch {
+	case len(segments) == 1:
+		return segments[0], method == http.MethodGet || method == http.MethodPost
+	case len(segments) == 2 && isPathParam(segments[1]):
+		return segments[0], slices.Contains([]string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete}, method)
+	}
+	return "", false
+}
+
+func isPathParam(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// resourceSchema picks the schema of a resource's records: what it is created
+// with, read as, listed as, or replaced with, in that order
+func (c *openAPIConverter) resourceSchema(resource *openAPIResource) (*openAPISchema, string) {
+	pick := func(method string, item bool) (*openAPISchema, string) {
+		for _, op := range resource.ops {
+			if op.method != method || strings.Contains(op.path[1:], "/") != item {
+				continue
+			}
+			var schema *openAPISchema
+			if method == http.MethodGet {
+				schema = c.responseSchema(op)
+			} else {
+				schema = c.requestSchema(op)
+			}
+			resolved, loc := c.resolve(schema, op.location())
+			if resolved != nil && schemaType(resolved) == "array" {
+				resolved, loc = c.resolve(resolved.Items, loc+".items")
+			}
+			if resolved != nil && schemaType(resolved) == "object" && len(resolved.Properties.keys) > 0 {
+				return resolved, loc
+			}
+		}
+		return nil, ""
+	}
+
+	for _, candidate := range []struct {
+		method string
+		item   bool
+	}{{http.MethodPost, false}, {http.MethodGet, true}, {http.MethodGet, false}, {http.MethodPut, true}, {http.MethodPatch, true}} {
+		if schema, loc := pick(candidate.method, candidate.item); schema != nil {
+			return schema, loc
+		}
+	}
+	return nil, ""
+}
+
+// resourceExamples collects the documented records of a resource
+func (c *openAPIConverter) resourceExamples(resource *openAPIResource) []map[string]any {
+	var examples []map[string]any
+	for _, op := range resource.ops {
+		var example any
+		var ok bool
+		switch op.method {
+		case http.MethodGet:
+			_, media, loc := c.successMedia(op)
+			example, ok = c.mediaExample(media, loc)
+		case http.MethodPost, http.MethodPut:
+			media, loc := c.requestMedia(op)
+			example, ok = c.mediaExample(media, loc)
+		}
+		if !ok {
+			continue
+		}
+		switch value := example.(type) {
+		case map[string]any:
+			examples = append(examples, value)
+		case []any:
+			for _, item := range value {
+				if data, ok := item.(map[string]any); ok {
+					examples = append(examples, data)
+				}
+			}
+		}
+	}
+	return examples
+}
+
+// fields maps the properties of an object schema onto field definitions.
+// The id property is left out: records have their own ids.
+func (c *openAPIConverter) fields(schema *openAPISchema, loc string) []models.FieldDefinition {
+	fields := []models.FieldDefinition{}
+	for _, name := range schema.Properties.keys {
+		if name == "id" {
+			continue
+		}
+		if field, ok := c.field(name, schema.Properties.values[name], slices.Contains(schema.Required, name), loc+".properties."+name); ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func (c *openAPIConverter) field(name string, prop *openAPISchema, required bool, loc string) (models.FieldDefinition, bool) {
+	field := models.FieldDefinition{Name: name, Required: required}
+	if prop == nil {
+		c.issue(loc, "the property has no schema; the field was skipped")
+		return field, false
+	}
+	if construct := composition(prop); construct != "" {
+		c.issue(loc, "%s is not supported; the field was skipped", construct)
+		return field, false
+	}
+	schema, _ := c.resolve(prop, loc)
+	if schema == nil {
+		return field, false
+	}
+
+	field.Description = schema.Description
+	field.Default = schema.Default
+	switch schemaType(schema) {
+	case "string":
+		switch {
+		case len(schema.Enum) > 0:
+			field.Type = "enum"
+			for _, value := range schema.Enum {
+				if str, ok := value.(string); ok {
+					field.EnumValues = append(field.EnumValues, str)
+				} else {
+					c.issue(loc, "enum value %v is not a string and was left out", value)
+				}
+			}
+		case schema.Format == "email":
+			field.Type = "email"
+		case schema.Format == "uri" || schema.Format == "url":
+			field.Type = "url"
+		case schema.Format == "date" || schema.Format == "date-time":
+			field.Type = "date"
+		default:
+			field.Type = "string"
+		}
+		field.MinLength, field.MaxLength = schema.MinLength, schema.MaxLength
+		if schema.Pattern != "" {
+			if _, err := regexp.Compile(schema.Pattern); err != nil {
+				c.issue(loc, "pattern %q is not a valid Go regular expression and was left out", schema.Pattern)
+			} else {
+				field.Pattern = &schema.Pattern
+			}
+		}
+	case "integer", "number":
+		field.Type = "number"
+		field.MinValue, field.MaxValue = schema.Minimum, schema.Maximum
+		if schema.ExclusiveMinimum != nil || schema.ExclusiveMaximum != nil {
+			c.issue(loc, "exclusive bounds are not supported; only minimum and maximum are checked")
+		}
+	case "boolean":
+		field.Type = "boolean"
+	case "array":
+		field.Type = "array"
+	case "object":
+		field.Type = "object"
+	case "":
+		c.issue(loc, "the property has no type; the field was skipped")
+		return field, false
+	default:
+		c.issue(loc, "type %q is not supported; the field was skipped", schemaType(schema))
+		return field, false
+	}
+	return field, true
+}
+
+// records takes up to n records from the examples, then generates the rest
+func (c *openAPIConverter) records(collection *models.Collection, examples []map[string]any, n int) []map[string]any {
+	var records []map[string]any
+	keys := map[string]bool{}
+	loc := "collections." + collection.Name
+	for _, data := range examples {
+		if len(records) >= n {
+			break
+		}
+		delete(data, "id")
+		if err := validateRecordData(collection.Fields, data); err != nil {
+			c.issue(loc, "an example does not match the schema and was not imported: %v", err)
+			continue
+		}
+		if key := recordKey("", data); !keys[key] {
+			keys[key] = true
+			records = append(records, data)
+		}
+	}
+	for i := len(records); i < n; i++ {
+		data := sampleRecord(collection.Fields, i)
+		if err := validateRecordData(collection.Fields, data); err != nil {
+			c.issue(loc, "records could not be generated: %v", err)
+			break
+		}
+		records = append(records, data)
+	}
+	return records
+}
+
+// endpoint turns an operation into a custom endpoint answering its first
+// success example. Other documented responses are picked with a
+// "Prefer: code=404" request header.
+func (c *openAPIConverter) endpoint(op openAPIOp) (*models.Endpoint, bool) {
+	loc := op.location()
+	path, ok := endpointPath(op.path)
+	if !ok {
+		c.issue(loc, "path parameters must fill whole segments; the operation was skipped")
+		return nil, false
+	}
+
+	input := dtos.EndpointRequestDto{
+		Method:      op.method,
+		Path:        path,
+		Description: op.op.Summary,
+		Rules:       []models.ResponseRule{},
+		Response:    models.MockResponse{Status: http.StatusOK},
+	}
+	if input.Description == "" {
+		input.Description = op.op.OperationID
+	}
+
+	main, _, _ := c.successMedia(op)
+	for _, code := range op.op.Responses.keys {
+		response := c.response(code, op.op.Responses.values[code], loc+".responses."+code)
+		if code == main {
+			input.Response = response
+			continue
+		}
+		input.Rules = append(input.Rules, models.ResponseRule{
+			Name:       code,
+			Conditions: []models.RuleCondition{{Source: "header", Key: preferCodeHeader, Operator: "equals", Value: "code=" + code}},
+			Response:   response,
+		})
+	}
+
+	if err := normalizeEndpointInput(&input); err != nil {
+		c.issue(loc, "the operation could not be mapped: %v", err)
+		return nil, false
+	}
+	return &models.Endpoint{
+		ID:          primitive.NewObjectID(),
+		Method:      input.Method,
+		Path:        input.Path,
+		Description: input.Description,
+		Rules:       input.Rules,
+		Response:    input.Response,
+	}, true
+}
+
+// endpointPath turns /users/{id} into /users/:id
+func endpointPath(path string) (string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if isPathParam(segment) {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		} else if strings.ContainsAny(segment, "{}") {
+			return "", false
+		}
+	}
+	return "/" + strings.Join(segments, "/"), true
+}
+
+func (c *openAPIConverter) response(code string, response *openAPIResponse, loc string) models.MockResponse {
+	out := models.MockResponse{Status: responseStatus(code)}
+	response, loc = c.resolveResponse(response, loc)
+	if response == nil {
+		return out
+	}
+
+	mediaType := pickMediaType(response.Content.keys)
+	if mediaType == "" {
+		return out
+	}
+	media := response.Content.values[mediaType]
+	mediaLoc := loc + ".content." + mediaType
+	body, ok := c.mediaExample(media, mediaLoc)
+	if !ok && media != nil {
+		body = c.sample(media.Schema, mediaLoc+".schema", 0)
+	}
+	if jsonMediaType.MatchString(mediaType) {
+		out.Body = body
+		return out
+	}
+	out.Headers = map[string]string{"Content-Type": mediaType}
+	if body != nil {
+		out.Body = stringify(body)
+	}
+	return out
+}
+
+// responseStatus reads a response key: 404, 4XX or default
+func responseStatus(code string) int {
+	if status, err := strconv.Atoi(code); err == nil {
+		return status
+	}
+	if len(code) == 3 && strings.HasSuffix(strings.ToUpper(code), "XX") && code[0] >= '1' && code[0] <= '5' {
+		return int(code[0]-'0') * 100
+	}
+	return http.StatusOK
+}
+
+// successMedia finds the first 2xx response of an operation, or its default
+// one, and the media type its examples are read from
+func (c *openAPIConverter) successMedia(op openAPIOp) (string, *openAPIMediaType, string) {
+	main := ""
+	for _, code := range op.op.Responses.keys {
+		if status := responseStatus(code); status >= 200 && status < 300 && code != "default" {
+			main = code
+			break
+		}
+	}
+	if main == "" && slices.Contains(op.op.Responses.keys, "default") {
+		main = "default"
+	}
+	if main == "" {
+		return "", nil, ""
+	}
+
+	loc := op.location() + ".responses." + main
+	response, loc := c.resolveResponse(op.op.Responses.values[main], loc)
+	if response == nil {
+		return main, nil, loc
+	}
+	mediaType := pickMediaType(response.Content.keys)
+	return main, response.Content.values[mediaType], loc + ".content." + mediaType
+}
+
+func (c *openAPIConverter) responseSchema(op openAPIOp) *openAPISchema {
+	if _, media, _ := c.successMedia(op); media != nil {
+		return media.Schema
+	}
+	return nil
+}
+
+func (c *openAPIConverter) requestMedia(op openAPIOp) (*openAPIMediaType, string) {
+	body, loc := op.op.RequestBody, op.location()+".requestBody"
+	if body != nil && body.Ref != "" {
+		name, ok := strings.CutPrefix(body.Ref, "#/components/requestBodies/")
+		body = c.spec.Components.RequestBodies[name]
+		if !ok || body == nil {
+			c.issue(loc, "unsupported or unresolved $ref %q", op.op.RequestBody.Ref)
+			return nil, loc
+		}
+		loc = "components.requestBodies." + name
+	}
+	if body == nil {
+		return nil, loc
+	}
+	mediaType := pickMediaType(body.Content.keys)
+	return body.Content.values[mediaType], loc + ".content." + mediaType
+}
+
+func (c *openAPIConverter) requestSchema(op openAPIOp) *openAPISchema {
+	if media, _ := c.requestMedia(op); media != nil {
+		return media.Schema
+	}
+	return nil
+}
+
+func (c *openAPIConverter) resolveResponse(response *openAPIResponse, loc string) (*openAPIResponse, string) {
+	if response == nil || response.Ref == "" {
+		return response, loc
+	}
+	name, ok := strings.CutPrefix(response.Ref, "#/components/responses/")
+	resolved := c.spec.Components.Responses[name]
+	if !ok || resolved == nil {
+		c.issue(loc, "unsupported or unresolved $ref %q", response.Ref)
+		return nil, loc
+	}
+	return resolved, "components.responses." + name
+}
+
+// pickMediaType prefers JSON, then whatever is documented first
+func pickMediaType(types []string) string {
+	for _, mediaType := range types {
+		if jsonMediaType.MatchString(mediaType) {
+			return mediaType
+		}
+	}
+	if len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// mediaExample reads the example of a media type: example, then the first of
+// examples, then the example of its schema
+func (c *openAPIConverter) mediaExample(media *openAPIMediaType, loc string) (any, bool) {
+	if media == nil {
+		return nil, false
+	}
+	if media.Example != nil {
+		return media.Example, true
+	}
+	for _, name := range media.Examples.keys {
+		example := media.Examples.values[name]
+		if example != nil && example.Ref != "" {
+			ref, ok := strings.CutPrefix(example.Ref, "#/components/examples/")
+			if example = c.spec.Components.Examples[ref]; !ok || example == nil {
+				c.issue(loc+".examples."+name, "unsupported or unresolved $ref %q", media.Examples.values[name].Ref)
+				continue
+			}
+		}
+		if example == nil {
+			continue
+		}
+		if example.ExternalValue != "" {
+			c.issue(loc+".examples."+name, "external examples are not fetched")
+			continue
+		}
+		if example.Value != nil {
+			return example.Value, true
+		}
+	}
+	if schema, _ := c.resolve(media.Schema, loc+".schema"); schema != nil && schema.Example != nil {
+		return schema.Example, true
+	}
+	return nil, false
+}
+
+// resolve follows $ref to component schemas and merges allOf, returning the
+// schema and where it is defined
+func (c *openAPIConverter) resolve(schema *openAPISchema, loc string) (*openAPISchema, string) {
+	for depth := 0; schema != nil && schema.Ref != ""; depth++ {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		target := c.spec.Components.Schemas[name]
+		if !ok || target == nil || depth >= maxSchemaDepth {
+			c.issue(loc, "unsupported or unresolved $ref %q", schema.Ref)
+			return nil, loc
+		}
+		schema, loc = target, "components.schemas."+name
+	}
+	if schema != nil && len(schema.AllOf) > 0 {
+		return c.mergeAllOf(schema, loc, 0), loc
+	}
+	return schema, loc
+}
+
+// mergeAllOf combines the properties and required lists of allOf parts into one object schema
+func (c *openAPIConverter) mergeAllOf(schema *openAPISchema, loc string, depth int) *openAPISchema {
+	merged := *schema
+	merged.AllOf = nil
+	merged.Properties = orderedMap[*openAPISchema]{keys: slices.Clone(schema.Properties.keys), values: map[string]*openAPISchema{}}
+	for k, v := range schema.Properties.values {
+		merged.Properties.values[k] = v
+	}
+	merged.Required = slices.Clone(schema.Required)
+
+	for i, part := range schema.AllOf {
+		partLoc := fmt.Sprintf("%s.allOf.%d", loc, i)
+		part, partLoc = c.resolve(part, partLoc)
+		if part == nil || depth >= maxSchemaDepth {
+			continue
+		}
+		if len(part.OneOf) > 0 || len(part.AnyOf) > 0 {
+			c.issue(partLoc, "oneOf and anyOf inside allOf are not supported; only the common properties were kept")
+		}
+		for _, name := range part.Properties.keys {
+			if _, ok := merged.Properties.values[name]; !ok {
+				merged.Properties.keys = append(merged.Properties.keys, name)
+			}
+			merged.Properties.values[name] = part.Properties.values[name]
+		}
+		merged.Required = append(merged.Required, part.Required...)
+		if merged.Type == nil {
+			merged.Type = part.Type
+		}
+	}
+	return &merged
+}
+
+// composition names the unsupported composition keyword a schema uses, if any
+func composition(schema *openAPISchema) string {
+	switch {
+	case len(schema.OneOf) > 0:
+		return "oneOf"
+	case len(schema.AnyOf) > 0:
+		return "anyOf"
+	case schema.Not != nil:
+		return "not"
+	}
+	return ""
+}
+
+// schemaType returns the type of a schema, guessing it when left out
+func schemaType(schema *openAPISchema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for _, name := range t {
+			if str, ok := name.(string); ok && str != "null" {
+				return str
+			}
+		}
+	}
+	switch {
+	case len(schema.Properties.keys) > 0:
+		return "object"
+	case schema.Items != nil:
+		return "array"
+	case len(schema.Enum) > 0:
+		return "string"
+	}
+	return ""
+}
+
+// sample builds an example value from a schema, for operations that document none
+func (c *openAPIConverter) sample(schema *openAPISchema, loc string, depth int) any {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	schema, loc = c.resolve(schema, loc)
+	if schema == nil {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if alternatives := append(schema.OneOf, schema.AnyOf...); len(alternatives) > 0 {
+		construct := composition(schema)
+		c.issue(loc, "%s is not supported; the generated example uses its first alternative", construct)
+		return c.sample(alternatives[0], fmt.Sprintf("%s.%s.0", loc, construct), depth+1)
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		out := map[string]any{}
+		for _, name := range schema.Properties.keys {
+			if value := c.sample(schema.Properties.values[name], loc+".properties."+name, depth+1); value != nil {
+				out[name] = value
+			}
+		}
+		return out
+	case "array":
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{c.sample(schema.Items, loc+".items", depth+1)}
+	case "string":
+		if len(schema.Enum) > 0 {
+			return schema.Enum[0]
+		}
+		switch schema.Format {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		case "uuid":
+			return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		}
+		return "string"
+	case "integer", "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return float64(0)
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// sampleRecord generates the i-th record of a collection from its fields
+func sampleRecord(fields []models.FieldDefinition, i int) map[string]any {
+	data := map[string]any{}
+	for _, field := range fields {
+		switch field.Type {
+		case "string":
+			value := fmt.Sprintf("%s %d", field.Name, i+1)
+			if field.MinLength != nil && len(value) < *field.MinLength {
+				value += strings.Repeat("x", *field.MinLength-len(value))
+			}
+			if field.MaxLength != nil && len(value) > *field.MaxLength {
+				value = value[:*field.MaxLength]
+			}
+			if field.Pattern != nil {
+				if matched, _ := regexp.MatchString(*field.Pattern, value); !matched {
+					if str, ok := field.Default.(string); ok {
+						value = str
+					} else if !field.Required {
+						continue
+					}
+				}
+			}
+			data[field.Name] = value
+		case "email":
+			data[field.Name] = fmt.Sprintf("user%d@example.com", i+1)
+		case "url":
+			data[field.Name] = fmt.Sprintf("https://example.com/%s/%d", field.Name, i+1)
+		case "date":
+			data[field.Name] = time.Now().UTC().AddDate(0, 0, -i).Format(time.RFC3339)
+		case "number":
+			low, high := 1.0, 1000.0
+			if field.MinValue != nil {
+				low = *field.MinValue
+			}
+			if field.MaxValue != nil {
+				high = *field.MaxValue
+			}
+			if high < low+1 {
+				data[field.Name] = low
+			} else {
+				data[field.Name] = low + float64((i*37+1)%int(high-low+1))
+			}
+		case "boolean":
+			data[field.Name] = i%2 == 0
+		case "enum":
+			if len(field.EnumValues) > 0 {
+				data[field.Name] = field.EnumValues[i%len(field.EnumValues)]
+			}
+		case "array":
+			data[field.Name] = []any{}
+		case "object":
+			data[field.Name] = map[string]any{}
+		}
+	}
+	return data
+}
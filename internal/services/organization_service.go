@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits of the free plan, for personal accounts and organizations alike
+const (
+	freeProjectLimit    = 2
+	freeCollectionLimit = 3
+)
+
+var orgRoleLevels = map[string]int{
+	models.OrgRoleMember: 1,
+	models.OrgRoleAdmin:  2,
+	models.OrgRoleOwner:  3,
+}
+
+var errInsufficientOrgRole = forbiddenError("your role in this organization does not allow this action")
+
+// orgRole returns the role of a user in an organization, or "" if they are not a member
+func orgRole(org *models.Organization, uid primitive.ObjectID) string {
+	for _, member := range org.Members {
+		if member.UserID == uid {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// inheritedRole maps an organization role to the role it grants on the
+// organization's projects
+func inheritedRole(role string) string {
+	switch role {
+	case models.OrgRoleOwner, models.OrgRoleAdmin:
+		return models.RoleOwner
+	case models.OrgRoleMember:
+		return models.RoleEditor
+	}
+	return ""
+}
+
+// OrganizationService manages team workspaces, their members and plan.
+type OrganizationService struct {
+	coll        store.Repository
+	projectColl store.Repository
+	userColl    store.Repository
+	ctx         context.Context
+	cfg         *config.Config
+}
+
+func NewOrganizationService(db store.Store, cfg *config.Config) *OrganizationService {
+	return &OrganizationService{
+		coll:        db.Repository(database.Collections.Organizations),
+		projectColl: db.Repository(database.Collections.Projects),
+		userColl:    db.Repository(database.Collections.Users),
+		ctx:         context.Background(),
+		cfg:         cfg,
+	}
+}
+
+// CreateOrganization creates a free organization owned by the user
+func (s *OrganizationService) CreateOrganization(userID, name string) (*models.Organization, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	now := time.Now()
+	org := &models.Organization{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		Plan:      PlanFree,
+		CreatedBy: uid,
+		Members:   []models.OrgMember{{UserID: uid, Role: models.OrgRoleOwner, AddedAt: now}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.coll.InsertOne(s.ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// GetUserOrganizations lists the organizations the user belongs to
+func (s *OrganizationService) GetUserOrganizations(userID string) ([]models.Organization, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	orgs := []models.Organization{}
+	if err := s.coll.Find(s.ctx, bson.M{"members.userId": uid}, &orgs); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+// GetOrganization returns an organization the user belongs to
+func (s *OrganizationService) GetOrganization(orgID, userID string) (*models.Organization, error) {
+	org, _, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleMember)
+	return org, err
+}
+
+// RenameOrganization changes the name of an organization
+func (s *OrganizationService) RenameOrganization(orgID, userID, name string) error {
+	org, _, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.coll.UpdateOne(s.ctx, bson.M{"_id": org.ID}, bson.M{"$set": bson.M{"name": name, "updatedAt": time.Now()}})
+	return err
+}
+
+// UpdatePlan switches the plan that limits the organization's projects
+func (s *OrganizationService) UpdatePlan(orgID, userID, plan string) error {
+	org, _, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if plan != PlanFree && plan != PlanPro {
+		return errors.New("plan must be free or pro")
+	}
+
+	if _, err := s.coll.UpdateOne(s.ctx, bson.M{"_id": org.ID}, bson.M{"$set": bson.M{"plan": plan, "updatedAt": time.Now()}}); err != nil {
+		return err
+	}
+
+	// Rate limits are cached per project
+	var projects []models.Project
+	if err := s.projectColl.Find(s.ctx, bson.M{"orgId": org.ID}, &projects); err == nil {
+		keys := make([]string, 0, len(projects))
+		for _, p := range projects {
+			keys = append(keys, redisClient.ProjectPlanKey(p.ID.Hex()))
+		}
+		redisClient.InvalidateCache(keys...)
+	}
+
+	return nil
+}
+
+// DeleteOrganization removes an organization that no longer owns projects
+func (s *OrganizationService) DeleteOrganization(orgID, userID string) error {
+	org, _, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.projectColl.CountDocuments(s.ctx, bson.M{"orgId": org.ID})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("delete or move the organization's projects first; projects in the trash count until they are purged")
+	}
+
+	_, err = s.coll.DeleteOne(s.ctx, bson.M{"_id": org.ID})
+	return err
+}
+
+// ListMembers returns the members of an organization
+func (s *OrganizationService) ListMembers(orgID, userID string) ([]dtos.OrgMemberResponse, error) {
+	org, _, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(org.Members))
+	for _, m := range org.Members {
+		ids = append(ids, m.UserID)
+	}
+	var users []models.User
+	if err := s.userColl.Find(s.ctx, bson.M{"_id": bson.M{"$in": ids}}, &users); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	result := make([]dtos.OrgMemberResponse, 0, len(org.Members))
+	for _, m := range org.Members {
+		user := byID[m.UserID]
+		result = append(result, dtos.OrgMemberResponse{
+			UserID:   m.UserID.Hex(),
+			FullName: user.FullName,
+			Email:    user.Email,
+			Role:     m.Role,
+			AddedAt:  m.AddedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// AddMember adds a registered user to the organization
+func (s *OrganizationService) AddMember(orgID, userID string, req *dtos.AddOrgMemberRequest) (*models.OrgMember, error) {
+	org, callerRole, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.OrgRoleMember
+	}
+	if _, ok := orgRoleLevels[role]; !ok {
+		return nil, errors.New("role must be owner, admin or member")
+	}
+	if role == models.OrgRoleOwner && callerRole != models.OrgRoleOwner {
+		return nil, errInsufficientOrgRole
+	}
+
+	var user models.User
+	if err := s.userColl.FindOne(s.ctx, bson.M{"email": strings.ToLower(strings.TrimSpace(req.Email))}, &user); err != nil {
+		return nil, notFoundError("no account uses this email")
+	}
+	if orgRole(org, user.ID) != "" {
+		return nil, errors.New("user is already a member of this organization")
+	}
+
+	member := models.OrgMember{UserID: user.ID, Role: role, AddedAt: time.Now()}
+	if _, err := s.coll.UpdateOne(s.ctx,
+		bson.M{"_id": org.ID},
+		bson.M{"$push": bson.M{"members": member}, "$set": bson.M{"updatedAt": time.Now()}},
+	); err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// UpdateMemberRole changes a member's role. Only owners grant or take away
+// ownership, and an organization always keeps one owner.
+func (s *OrganizationService) UpdateMemberRole(orgID, memberID, userID, role string) error {
+	org, callerRole, err := authorizeOrg(s.coll, orgID, userID, models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+	if _, ok := orgRoleLevels[role]; !ok {
+		return errors.New("role must be owner, admin or member")
+	}
+	mid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return errors.New("invalid member id")
+	}
+
+	current := orgRole(org, mid)
+	if current == "" {
+		return notFoundError("member not found")
+	}
+	if (role == models.OrgRoleOwner || current == models.OrgRoleOwner) && callerRole != models.OrgRoleOwner {
+		return errInsufficientOrgRole
+	}
+	if current == models.OrgRoleOwner && role != models.OrgRoleOwner && countOwners(org) == 1 {
+		return errors.New("an organization needs at least one owner")
+	}
+
+	// Rewrite the whole list so every storage driver can apply the change
+	members := org.Members
+	for i := range members {
+		if members[i].UserID == mid {
+			members[i].Role = role
+		}
+	}
+
+	_, err = s.coll.UpdateOne(s.ctx,
+		bson.M{"_id": org.ID},
+		bson.M{"$set": bson.M{"members": members, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// RemoveMember removes a member. Admins can remove members and admins, owners
+// anyone; every member can leave. The last owner cannot leave.
+func (s *OrganizationService) RemoveMember(orgID, memberID, userID string) error {
+	minRole := models.OrgRoleAdmin
+	if memberID == userID {
+		minRole = models.OrgRoleMember
+	}
+
+	org, callerRole, err := authorizeOrg(s.coll, orgID, userID, minRole)
+	if err != nil {
+		return err
+	}
+	mid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return errors.New("invalid member id")
+	}
+
+	current := orgRole(org, mid)
+	if current == "" {
+		return notFoundError("member not found")
+	}
+	if current == models.OrgRoleOwner {
+		if memberID != userID && callerRole != models.OrgRoleOwner {
+			return errInsufficientOrgRole
+		}
+		if countOwners(org) == 1 {
+			return errors.New("an organization needs at least one owner")
+		}
+	}
+
+	_, err = s.coll.UpdateOne(s.ctx,
+		bson.M{"_id": org.ID},
+		bson.M{"$pull": bson.M{"members": bson.M{"userId": mid}}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// authorizeOrg loads an organization and checks the user's role in it, which
+// it also returns. Non-members get a 404 so other organizations stay hidden.
+func authorizeOrg(coll store.Repository, orgID, userID, minRole string) (*models.Organization, string, error) {
+	oid, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, "", errors.New("invalid organization id")
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, "", errors.New("invalid user id")
+	}
+
+	var org models.Organization
+	if err := coll.FindOne(context.Background(), bson.M{"_id": oid}, &org); err != nil {
+		if err == store.ErrNotFound {
+			return nil, "", notFoundError("organization not found")
+		}
+		return nil, "", err
+	}
+
+	role := orgRole(&org, uid)
+	if role == "" {
+		return nil, "", notFoundError("organization not found")
+	}
+	if orgRoleLevels[role] < orgRoleLevels[minRole] {
+		return nil, "", errInsufficientOrgRole
+	}
+
+	return &org, role, nil
+}
+
+func countOwners(org *models.Organization) int {
+	owners := 0
+	for _, member := range org.Members {
+		if member.Role == models.OrgRoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Requests the server sends to URLs chosen by users (proxy upstreams, webhook
+// receivers) must not reach its own network: loopback, link-local (cloud
+// metadata) and private addresses are refused unless the config allows them.
+
+// outboundTransport dials with a timeout and, unless allowPrivate is set,
+// checks addresses once resolved, so host names pointing inside the network
+// are refused too. Environment proxies are ignored, as they would hide the
+// real address.
+func outboundTransport(timeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivateDial
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// privateURL reports URLs naming a local host or an address inside the
+// network; host names are checked again once resolved
+func privateURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && privateIP(ip)
+}
+
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// refusePrivateDial stops connections to the addresses privateIP reports
+func refusePrivateDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+		return fmt.Errorf("address %s is not allowed", host)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var roleLevels = map[string]int{
+	models.RoleViewer: 1,
+	models.RoleEditor: 2,
+	models.RoleOwner:  3,
+}
+
+// AccessError is returned when a resource does not exist, the caller may not
+// act on it or it is busy, with the HTTP status handlers should answer.
+type AccessError struct {
+	Status  int
+	Message string
+}
+
+func (e *AccessError) Error() string {
+	return e.Message
+}
+
+// notFoundError is also used when the caller has no access at all, so the
+// existence of other users' resources is not revealed.
+func notFoundError(message string) *AccessError {
+	return &AccessError{Status: http.StatusNotFound, Message: message}
+}
+
+func forbiddenError(message string) *AccessError {
+	return &AccessError{Status: http.StatusForbidden, Message: message}
+}
+
+func conflictError(message string) *AccessError {
+	return &AccessError{Status: http.StatusConflict, Message: message}
+}
+
+// preconditionError rejects a conditional request whose If-Match no longer
+// matches the resource
+func preconditionError(message string) *AccessError {
+	return &AccessError{Status: http.StatusPreconditionFailed, Message: message}
+}
+
+var errInsufficientRole = forbiddenError("your role on this project does not allow this action")
+
+// projectRole returns the direct role of a user on a project, or "" if they have none
+func projectRole(project *models.Project, uid primitive.ObjectID) string {
+	if project.UserID == uid {
+		return models.RoleOwner
+	}
+	for _, member := range project.Members {
+		if member.UserID == uid {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// memberFilter matches the user's personal projects and the projects shared
+// with them directly. Organization projects are listed in the org context.
+func memberFilter(uid primitive.ObjectID) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"userId": uid, "orgId": bson.M{"$exists": false}},
+		bson.M{"members.userId": uid},
+	}}
+}
+
+// notDeleted narrows a filter to the documents that are not in the trash
+func notDeleted(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+// projectAccess resolves a caller's role on projects, including the role
+// inherited from the organization that owns a project.
+type projectAccess struct {
+	projectColl store.Repository
+	orgColl     store.Repository
+	userColl    store.Repository
+}
+
+func newProjectAccess(db store.Store) *projectAccess {
+	return &projectAccess{
+		projectColl: db.Repository(database.Collections.Projects),
+		orgColl:     db.Repository(database.Collections.Organizations),
+		userColl:    db.Repository(database.Collections.Users),
+	}
+}
+
+// plan returns the plan that limits a project: the plan of its organization,
+// or of its owner's account for personal projects. Sandbox projects are anonymous.
+func (a *projectAccess) plan(project *models.Project) string {
+	if project.SessionID != "" || project.UserID.IsZero() {
+		return PlanAnonymous
+	}
+
+	if project.OrgID != nil {
+		var org models.Organization
+		if err := a.orgColl.FindOne(context.Background(), bson.M{"_id": *project.OrgID}, &org); err != nil {
+			return PlanFree
+		}
+		return org.Plan
+	}
+
+	var user models.User
+	if err := a.userColl.FindOne(context.Background(), bson.M{"_id": project.UserID}, &user); err != nil {
+		return PlanAnonymous
+	}
+	if user.IsUpgraded {
+		return PlanPro
+	}
+	return PlanFree
+}
+
+// role returns the highest of the user's direct and organization roles on a project
+func (a *projectAccess) role(project *models.Project, uid primitive.ObjectID) string {
+	role := projectRole(project, uid)
+	if project.OrgID == nil || role == models.RoleOwner {
+		return role
+	}
+
+	var org models.Organization
+	if err := a.orgColl.FindOne(context.Background(), bson.M{"_id": *project.OrgID}, &org); err != nil {
+		return role
+	}
+	if inherited := inheritedRole(orgRole(&org, uid)); roleLevels[inherited] > roleLevels[role] {
+		return inherited
+	}
+	return role
+}
+
+// authorizeProject loads a project and checks that the user holds at least
+// minRole on it. Sandbox projects are reached through their session id, so
+// session routes pass an empty userID and are let through.
+func (a *projectAccess) authorizeProject(pid primitive.ObjectID, userID, minRole string) (*models.Project, error) {
+	var project models.Project
+	if err := a.projectColl.FindOne(context.Background(), notDeleted(bson.M{"_id": pid}), &project); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("project not found")
+		}
+		return nil, err
+	}
+
+	if userID == "" && project.SessionID != "" {
+		return &project, nil
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	role := a.role(&project, uid)
+	if role == "" {
+		return nil, notFoundError("project not found")
+	}
+	if roleLevels[role] < roleLevels[minRole] {
+		return nil, errInsufficientRole
+	}
+	if isRestoring(&project) {
+		return nil, errProjectRestoring
+	}
+
+	return &project, nil
+}
+
+// authorizeCollection loads a collection and checks the caller's role on its
+// project. When projectID is set (nested routes) the collection must belong
+// to it.
+func (a *projectAccess) authorizeCollection(collectionColl store.Repository, cfg *config.Config, projectID, collectionID, userID, minRole string) (*models.Collection, error) {
+	cid, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return nil, errors.New("invalid collection id")
+	}
+
+	collection, err := findCollection(collectionColl, cfg, cid)
+	if err != nil {
+		return nil, err
+	}
+	if projectID != "" && collection.ProjectID.Hex() != projectID {
+		return nil, notFoundError("collection not found")
+	}
+	if _, err := a.authorizeProject(collection.ProjectID, userID, minRole); err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
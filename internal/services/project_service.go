@@ -3,55 +3,89 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/saifwork/mock-service/internal/core/config"
 	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
 	"github.com/saifwork/mock-service/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProjectService struct {
-	coll     *mongo.Collection
-	usercoll *mongo.Collection
-	ctx      context.Context
-	cfg      *config.Config
+	coll           store.Repository
+	usercoll       store.Repository
+	collectionColl store.Repository
+	recordColl     store.Repository
+	orgColl        store.Repository
+	access         *projectAccess
+	audit          *auditLog
+	ctx            context.Context
+	cfg            *config.Config
 }
 
-func NewProjectService(client *mongo.Client, cfg *config.Config) *ProjectService {
-	collection := client.Database(cfg.MongoDBName).Collection(database.Collections.Projects)
-	usercollection := client.Database(cfg.MongoDBName).Collection(database.Collections.Users)
+func NewProjectService(db store.Store, cfg *config.Config) *ProjectService {
+	collection := db.Repository(database.Collections.Projects)
+	usercollection := db.Repository(database.Collections.Users)
 	return &ProjectService{
-		coll:     collection,
-		usercoll: usercollection,
-		ctx:      context.Background(),
-		cfg:      cfg,
+		coll:           collection,
+		usercoll:       usercollection,
+		collectionColl: db.Repository(database.Collections.Collection),
+		recordColl:     db.Repository(database.Collections.Records),
+		orgColl:        db.Repository(database.Collections.Organizations),
+		access:         newProjectAccess(db),
+		audit:          newAuditLog(db),
+		ctx:            context.Background(),
+		cfg:            cfg,
 	}
 }
 
-func (s *ProjectService) CreateProject(userID, name, desc string) (*models.Project, error) {
+// CreateProject creates a personal project, or an organization project when
+// orgID is set. The project limit follows the plan of the account or the
+// organization that owns the project.
+func (s *ProjectService) CreateProject(actor dtos.Actor, orgID, name, desc string) (*models.Project, error) {
+	userID := actor.UserID
 	uid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user id")
 	}
 
-	// 🧠 Step 1: Fetch user info
-	var user models.User
-	err = s.usercoll.FindOne(context.Background(), bson.M{"_id": uid}).Decode(&user)
-	if err != nil {
-		return nil, errors.New("user not found")
+	// 🧠 Step 1: Resolve the owner (user or organization) and its plan
+	plan := PlanFree
+	countFilter := bson.M{"userId": uid, "orgId": bson.M{"$exists": false}}
+	var org *models.Organization
+	if orgID != "" {
+		org, _, err = authorizeOrg(s.orgColl, orgID, userID, models.OrgRoleMember)
+		if err != nil {
+			return nil, err
+		}
+		plan = org.Plan
+		countFilter = bson.M{"orgId": org.ID}
+	} else {
+		var user models.User
+		err = s.usercoll.FindOne(context.Background(), bson.M{"_id": uid}, &user)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if user.IsUpgraded {
+			plan = PlanPro
+		}
 	}
 
-	// 🧩 Step 2: If not upgraded, check project count
-	if !user.IsUpgraded {
-		count, err := s.coll.CountDocuments(context.Background(), bson.M{"userId": uid})
+	// 🧩 Step 2: On the free plan, check project count
+	if plan != PlanPro {
+		count, err := s.coll.CountDocuments(context.Background(), notDeleted(countFilter))
 		if err != nil {
 			return nil, err
 		}
-		if count >= 2 {
-			return nil, errors.New("upgrade required — you can only create 2 projects with a free account")
+		if count >= freeProjectLimit {
+			if org != nil {
+				return nil, fmt.Errorf("upgrade required — free organizations can only have %d projects", freeProjectLimit)
+			}
+			return nil, fmt.Errorf("upgrade required — you can only create %d projects with a free account", freeProjectLimit)
 		}
 	}
 
@@ -64,12 +98,23 @@ func (s *ProjectService) CreateProject(userID, name, desc string) (*models.Proje
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
+	if org != nil {
+		project.OrgID = &org.ID
+	}
 
-	_, err = s.coll.InsertOne(context.Background(), project)
+	err = s.coll.InsertOne(context.Background(), project)
 	if err != nil {
 		return nil, err
 	}
 
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectCreate,
+		TargetType: "project",
+		TargetID:   project.ID.Hex(),
+		ProjectID:  &project.ID,
+		Changes:    diffFields(nil, projectSnapshot(project)),
+	})
+
 	return project, nil
 }
 
@@ -78,51 +123,43 @@ func (s *ProjectService) GetProjectByID(pid, userID string) (*models.Project, er
 	if err != nil {
 		return nil, errors.New("invalid project id")
 	}
-	uid, err := primitive.ObjectIDFromHex(userID)
-	if err != nil {
-		return nil, errors.New("invalid user id")
-	}
-
-	var project models.Project
-	err = s.coll.FindOne(context.Background(), bson.M{"_id": oid, "userId": uid}).Decode(&project)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("project not found or unauthorized")
-		}
-		return nil, err
-	}
 
-	return &project, nil
+	return s.access.authorizeProject(oid, userID, models.RoleViewer)
 }
 
-func (s *ProjectService) GetUserProjects(userID string) ([]models.Project, error) {
+// GetUserProjects lists the projects of the current context: the user's own
+// and shared projects, or every project of the organization when orgID is set.
+func (s *ProjectService) GetUserProjects(userID, orgID string) ([]models.Project, error) {
 	uid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user id")
 	}
 
-	cur, err := s.coll.Find(context.Background(), bson.M{"userId": uid})
-	if err != nil {
-		return nil, err
+	filter := memberFilter(uid)
+	if orgID != "" {
+		org, _, err := authorizeOrg(s.orgColl, orgID, userID, models.OrgRoleMember)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"orgId": org.ID}
 	}
-	defer cur.Close(context.Background())
 
 	var projects []models.Project
-	if err := cur.All(context.Background(), &projects); err != nil {
+	if err := s.coll.Find(context.Background(), notDeleted(filter), &projects); err != nil {
 		return nil, err
 	}
 
 	return projects, nil
 }
 
-func (s *ProjectService) UpdateProject(pid, userID, name, desc string) error {
+func (s *ProjectService) UpdateProject(pid string, actor dtos.Actor, name, desc string) error {
 	oid, err := primitive.ObjectIDFromHex(pid)
 	if err != nil {
 		return errors.New("invalid project id")
 	}
-	uid, err := primitive.ObjectIDFromHex(userID)
+	existing, err := s.access.authorizeProject(oid, actor.UserID, models.RoleEditor)
 	if err != nil {
-		return errors.New("invalid user id")
+		return err
 	}
 
 	update := bson.M{
@@ -133,35 +170,94 @@ func (s *ProjectService) UpdateProject(pid, userID, name, desc string) error {
 		},
 	}
 
-	res, err := s.coll.UpdateOne(context.Background(), bson.M{"_id": oid, "userId": uid}, update)
+	matched, err := s.coll.UpdateOne(context.Background(), bson.M{"_id": oid}, update)
 	if err != nil {
 		return err
 	}
-	if res.MatchedCount == 0 {
-		return errors.New("no project found or unauthorized")
+	if matched == 0 {
+		return notFoundError("project not found")
 	}
 
+	updated := *existing
+	updated.Name, updated.Description = name, desc
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectUpdate,
+		TargetType: "project",
+		TargetID:   oid.Hex(),
+		ProjectID:  &oid,
+		Changes:    diffFields(projectSnapshot(existing), projectSnapshot(&updated)),
+	})
+
 	return nil
 }
 
-func (s *ProjectService) DeleteProject(pid, userID string) error {
+func (s *ProjectService) DeleteProject(pid string, actor dtos.Actor) error {
 	oid, err := primitive.ObjectIDFromHex(pid)
 	if err != nil {
 		return errors.New("invalid project id")
 	}
-	uid, err := primitive.ObjectIDFromHex(userID)
+	project, err := s.access.authorizeProject(oid, actor.UserID, models.RoleOwner)
 	if err != nil {
-		return errors.New("invalid user id")
+		return err
 	}
 
-	res, err := s.coll.DeleteOne(context.Background(), bson.M{"_id": oid, "userId": uid})
+	now := time.Now()
+	matched, err := s.coll.UpdateOne(context.Background(), notDeleted(bson.M{"_id": oid}), bson.M{"$set": bson.M{"deletedAt": now}})
 	if err != nil {
 		return err
 	}
 
-	if res.DeletedCount == 0 {
-		return errors.New("no project found or unauthorized")
+	if matched == 0 {
+		return notFoundError("project not found")
 	}
 
+	// 🗑️ Cascade to the collections and records still live; API keys,
+	// webhooks and snapshots stay until the project is purged, and stop
+	// working meanwhile since the project can no longer be found
+	if err := s.trashContents(oid, now); err != nil {
+		return err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectDelete,
+		TargetType: "project",
+		TargetID:   oid.Hex(),
+		ProjectID:  &oid,
+		Changes:    diffFields(projectSnapshot(project), nil),
+	})
+
 	return nil
 }
+
+// trashContents moves the live collections of a deleted project and their
+// live records to the trash, marked so that restoring the project brings them back
+func (s *ProjectService) trashContents(pid primitive.ObjectID, now time.Time) error {
+	var collections []models.Collection
+	if err := s.collectionColl.Find(context.Background(), notDeleted(bson.M{"projectId": pid}), &collections); err != nil {
+		return err
+	}
+	if len(collections) == 0 {
+		return nil
+	}
+
+	ids := bson.A{}
+	for i := range collections {
+		ids = append(ids, collections[i].ID)
+		invalidateCollectionCache(&collections[i])
+	}
+
+	trash := bson.M{"$set": bson.M{"deletedAt": now, "deletedWith": pid}}
+	if _, err := s.recordColl.UpdateMany(context.Background(), notDeleted(bson.M{"collectionId": bson.M{"$in": ids}}), trash); err != nil {
+		return err
+	}
+	_, err := s.collectionColl.UpdateMany(context.Background(), notDeleted(bson.M{"_id": bson.M{"$in": ids}}), trash)
+	return err
+}
+
+// projectSnapshot holds the audited fields of a project
+func projectSnapshot(project *models.Project) map[string]any {
+	return map[string]any{
+		"name":        project.Name,
+		"description": project.Description,
+	}
+}
@@ -0,0 +1,535 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	proxyTimeout               = 30 * time.Second
+	maxProxyBodyBytes          = 5 << 20 // larger upstream responses are refused, not recorded
+	defaultRecordingCollection = "recordings"
+	redactedValue              = "[REDACTED]"
+)
+
+// defaultRedactHeaders are kept out of recordings unless a project says otherwise
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// errPrivateUpstream keeps projects from reaching the server's own network
+var errPrivateUpstream = errors.New("upstreamUrl must not point to a loopback, link-local or private address")
+
+// hopHeaders only make sense on one connection and are never passed along
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length"}
+
+// ProxyService forwards the mock requests of projects in record mode to their
+// upstream and keeps the exchanges, as custom endpoints or as records, so that
+// replay mode can serve them offline.
+type ProxyService struct {
+	projectColl   store.Repository
+	endpointColl  store.Repository
+	recordColl    store.Repository
+	collectionSvc *CollectionService
+	recordSvc     *RecordService
+	access        *projectAccess
+	audit         *auditLog
+	client        *http.Client
+	ctx           context.Context
+	cfg           *config.Config
+}
+
+func NewProxyService(db store.Store, cfg *config.Config, collectionSvc *CollectionService, recordSvc *RecordService) *ProxyService {
+	return &ProxyService{
+		projectColl:   db.Repository(database.Collections.Projects),
+		endpointColl:  db.Repository(database.Collections.Endpoints),
+		recordColl:    db.Repository(database.Collections.Records),
+		collectionSvc: collectionSvc,
+		recordSvc:     recordSvc,
+		access:        newProjectAccess(db),
+		audit:         newAuditLog(db),
+		client: &http.Client{
+			Transport: outboundTransport(proxyTimeout, cfg.ProxyAllowPrivate),
+			Timeout:   proxyTimeout,
+			// Redirects are recorded as they are
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		ctx: context.Background(),
+		cfg: cfg,
+	}
+}
+
+// GetProxy returns the proxy setup of a project, defaults included
+func (s *ProxyService) GetProxy(projectID, userID string) (*models.ProxyConfig, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return proxyConfig(project), nil
+}
+
+// ConfigureProxy switches the proxy mode of a project and changes its setup.
+// The records target creates its collection when the project lacks it.
+func (s *ProxyService) ConfigureProxy(projectID string, actor dtos.Actor, input *dtos.ProxyRequest) (*models.ProxyConfig, error) {
+	project, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := proxyConfig(project)
+	proxy := *existing
+	proxy.Mode = input.Mode
+	if input.UpstreamURL != "" {
+		proxy.UpstreamURL = strings.TrimRight(strings.TrimSpace(input.UpstreamURL), "/")
+	}
+	if input.Target != "" {
+		proxy.Target = input.Target
+	}
+	if input.Collection != "" {
+		proxy.Collection = strings.TrimSpace(input.Collection)
+	}
+	if input.Match != nil {
+		proxy.Match = *input.Match
+	}
+	if input.RedactHeaders != nil {
+		proxy.RedactHeaders = input.RedactHeaders
+	}
+	if err := normalizeProxy(&proxy); err != nil {
+		return nil, err
+	}
+	if proxy.UpstreamURL != "" && !s.cfg.ProxyAllowPrivate && privateURL(proxy.UpstreamURL) {
+		return nil, errPrivateUpstream
+	}
+
+	// 🗂️ Recordings kept as records need their collection
+	if proxy.Target == models.ProxyTargetRecords {
+		_, err := s.collectionSvc.GetCollectionByName(project.ID, proxy.Collection)
+		var accessErr *AccessError
+		if errors.As(err, &accessErr) && accessErr.Status == http.StatusNotFound {
+			_, err = s.collectionSvc.CreateCollection(projectID, actor, proxy.Collection, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	proxy.UpdatedAt = &now
+	if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, bson.M{"$set": bson.M{"proxy": proxy}}); err != nil {
+		return nil, err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProxyUpdate,
+		TargetType: "proxy",
+		TargetID:   project.ID.Hex(),
+		ProjectID:  &project.ID,
+		Changes:    diffFields(proxySnapshot(existing), proxySnapshot(&proxy)),
+	})
+
+	return &proxy, nil
+}
+
+// forward sends a mock request to the upstream of a project in record mode,
+// records the exchange and answers with what the upstream sent
+func (s *ProxyService) forward(project *models.Project, req *MockRequest) (*models.MockResponse, error) {
+	proxy := project.Proxy
+
+	target := proxy.UpstreamURL + req.Path
+	if len(req.Query) > 0 {
+		target += "?" + req.Query.Encode()
+	}
+	ctx := req.Context
+	if ctx == nil {
+		ctx = s.ctx
+	}
+	upstreamReq, err := http.NewRequestWithContext(ctx, req.Method, target, bytes.NewReader(req.RawBody))
+	if err != nil {
+		return nil, mockError(http.StatusBadGateway, "invalid upstream request: "+err.Error())
+	}
+	upstreamReq.Header = forwardHeaders(req.Headers)
+
+	resp, err := s.client.Do(upstreamReq)
+	if err != nil {
+		return nil, mockError(http.StatusBadGateway, "upstream request failed: "+err.Error())
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyBodyBytes+1))
+	if err != nil {
+		return nil, mockError(http.StatusBadGateway, "unable to read upstream response: "+err.Error())
+	}
+	if len(raw) > maxProxyBodyBytes {
+		return nil, mockError(http.StatusBadGateway, fmt.Sprintf("upstream response is larger than %d bytes", maxProxyBodyBytes))
+	}
+
+	response := &models.MockResponse{Status: resp.StatusCode, Headers: flattenHeaders(resp.Header, "Content-Encoding", "Date")}
+	if len(raw) > 0 {
+		contentType := resp.Header.Get("Content-Type")
+		var body any
+		if strings.Contains(contentType, "json") && json.Unmarshal(raw, &body) == nil {
+			response.Body = body
+		} else {
+			// Text is sent back verbatim only under a non-JSON content type
+			if contentType == "" {
+				response.Headers["Content-Type"] = "text/plain; charset=utf-8"
+			}
+			response.Body = string(raw)
+		}
+	}
+
+	// A failed recording must not fail the proxied call
+	if err := s.save(project, req, response); err != nil {
+		log.Printf("[PROXY] Failed to record %s %s of project %s: %v", req.Method, req.Path, project.ID.Hex(), err)
+	}
+
+	return response, nil
+}
+
+// replay answers a mock request from the recordings kept as records. Recorded
+// endpoints need no help: they are served like any custom endpoint.
+func (s *ProxyService) replay(project *models.Project, req *MockRequest) (*models.MockResponse, error) {
+	proxy := project.Proxy
+	if proxy.Target != models.ProxyTargetRecords {
+		return nil, nil
+	}
+
+	collection, err := s.collectionSvc.GetCollectionByName(project.ID, proxy.Collection)
+	if err != nil {
+		return nil, nil
+	}
+
+	var record models.Record
+	filter := notDeleted(inEnvironment(bson.M{"collectionId": collection.ID, "data.key": recordingKey(proxy, req)}, req.Environment))
+	if err := s.recordColl.FindOne(s.ctx, filter, &record); err != nil {
+		if err == store.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	response := &models.MockResponse{Headers: map[string]string{}, Body: utils.NormalizeBSON(record.Data["body"])}
+	if status, ok := toFloat(record.Data["status"]); ok {
+		response.Status = int(status)
+	}
+	if headers, ok := utils.NormalizeBSON(record.Data["headers"]).(map[string]any); ok {
+		for k, v := range headers {
+			// Recordings made before redacted headers were left out
+			if value := stringify(v); value != redactedValue {
+				response.Headers[k] = value
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// save keeps an exchange where the project wants its recordings, replacing an
+// earlier recording of the same request. Redacted response headers are left
+// out, as recorded responses are replayed to clients.
+func (s *ProxyService) save(project *models.Project, req *MockRequest, response *models.MockResponse) error {
+	proxy := project.Proxy
+	recorded := *response
+	recorded.Headers = stripHeaders(response.Headers, proxy.RedactHeaders)
+
+	if proxy.Target == models.ProxyTargetRecords {
+		return s.saveRecord(project, req, &recorded)
+	}
+	return s.saveEndpoint(project, req, &recorded)
+}
+
+// saveEndpoint records into the custom endpoint of the method and path: as its
+// response when nothing else is matched, or as a rule of the matched values
+func (s *ProxyService) saveEndpoint(project *models.Project, req *MockRequest, recorded *models.MockResponse) error {
+	proxy := project.Proxy
+	path := "/" + strings.Trim(req.Path, "/")
+	conditions := proxyConditions(proxy, req)
+
+	var endpoint models.Endpoint
+	err := s.endpointColl.FindOne(s.ctx, bson.M{"projectId": project.ID, "method": req.Method, "path": path}, &endpoint)
+	if err == store.ErrNotFound {
+		endpoint = models.Endpoint{
+			ID:          primitive.NewObjectID(),
+			ProjectID:   project.ID,
+			Method:      req.Method,
+			Path:        path,
+			Description: "Recorded from " + proxy.UpstreamURL,
+			Rules:       []models.ResponseRule{},
+			Response:    *recorded,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if len(conditions) > 0 {
+			endpoint.Rules = append(endpoint.Rules, models.ResponseRule{Name: "recorded", Conditions: conditions, Response: *recorded})
+		}
+		return s.endpointColl.InsertOne(s.ctx, &endpoint)
+	}
+	if err != nil {
+		return err
+	}
+	normalizeEndpoint(&endpoint)
+
+	set := bson.M{"updatedAt": time.Now()}
+	if len(conditions) == 0 {
+		set["response"] = *recorded
+	} else {
+		set["rules"] = withRecordedRule(endpoint.Rules, models.ResponseRule{Name: "recorded", Conditions: conditions, Response: *recorded})
+	}
+	_, err = s.endpointColl.UpdateOne(s.ctx, bson.M{"_id": endpoint.ID}, bson.M{"$set": set})
+	return err
+}
+
+// saveRecord records into the recordings collection, in the environment of the request
+func (s *ProxyService) saveRecord(project *models.Project, req *MockRequest, recorded *models.MockResponse) error {
+	proxy := project.Proxy
+	collection, err := s.collectionSvc.GetCollectionByName(project.ID, proxy.Collection)
+	if err != nil {
+		return err
+	}
+
+	key := recordingKey(proxy, req)
+	var requestBody any = req.Body
+	if requestBody == nil && len(req.RawBody) > 0 {
+		requestBody = string(req.RawBody)
+	}
+	data := map[string]any{
+		"key":            key,
+		"method":         req.Method,
+		"path":           "/" + strings.Trim(req.Path, "/"),
+		"query":          req.Query.Encode(),
+		"requestHeaders": redactHeaders(flattenHeaders(forwardHeaders(req.Headers)), proxy.RedactHeaders),
+		"requestBody":    requestBody,
+		"status":         recorded.Status,
+		"headers":        recorded.Headers,
+		"body":           recorded.Body,
+	}
+
+	var existing models.Record
+	err = s.recordColl.FindOne(s.ctx, notDeleted(inEnvironment(bson.M{"collectionId": collection.ID, "data.key": key}, req.Environment)), &existing)
+	switch {
+	case err == nil:
+		_, err = s.recordSvc.replaceRecord(&existing, data, req.actor(), "")
+	case err == store.ErrNotFound:
+		_, err = s.recordSvc.insertRecord(collection, req.Environment, data, req.actor())
+	}
+	return err
+}
+
+func (s *ProxyService) authorize(projectID, userID, minRole string) (*models.Project, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	return s.access.authorizeProject(pid, userID, minRole)
+}
+
+// proxyConfig returns the proxy setup of a project, or the defaults of one never configured
+func proxyConfig(project *models.Project) *models.ProxyConfig {
+	if project.Proxy != nil {
+		return project.Proxy
+	}
+	return &models.ProxyConfig{
+		Mode:          models.ProxyModeOff,
+		Target:        models.ProxyTargetEndpoints,
+		Match:         models.ProxyMatch{Headers: []string{}},
+		RedactHeaders: slices.Clone(defaultRedactHeaders),
+	}
+}
+
+func normalizeProxy(proxy *models.ProxyConfig) error {
+	if !slices.Contains(models.ProxyModes, proxy.Mode) {
+		return fmt.Errorf("mode must be one of %v", models.ProxyModes)
+	}
+	if !slices.Contains(models.ProxyTargets, proxy.Target) {
+		return fmt.Errorf("target must be one of %v", models.ProxyTargets)
+	}
+	if proxy.UpstreamURL != "" {
+		u, err := url.Parse(proxy.UpstreamURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return errors.New("upstreamUrl must be an absolute http or https URL without query or fragment")
+		}
+	}
+	if proxy.Mode == models.ProxyModeRecord && proxy.UpstreamURL == "" {
+		return errors.New("record mode needs an upstreamUrl")
+	}
+	if proxy.Target == models.ProxyTargetRecords && proxy.Collection == "" {
+		proxy.Collection = defaultRecordingCollection
+	}
+	if strings.Contains(proxy.Collection, "/") {
+		return errors.New("collection names cannot contain '/'")
+	}
+
+	proxy.Match.Headers = canonicalHeaders(proxy.Match.Headers)
+	proxy.RedactHeaders = canonicalHeaders(proxy.RedactHeaders)
+	for _, name := range proxy.Match.Headers {
+		if slices.Contains(proxy.RedactHeaders, name) {
+			return fmt.Errorf("header %s is redacted and cannot be matched on", name)
+		}
+	}
+	return nil
+}
+
+func canonicalHeaders(names []string) []string {
+	out := []string{}
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name != "" && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func proxySnapshot(proxy *models.ProxyConfig) map[string]any {
+	return map[string]any{
+		"mode":          proxy.Mode,
+		"upstreamUrl":   proxy.UpstreamURL,
+		"target":        proxy.Target,
+		"collection":    proxy.Collection,
+		"match":         proxy.Match,
+		"redactHeaders": proxy.RedactHeaders,
+	}
+}
+
+// forwardHeaders copies the headers of a mock request that the upstream should see.
+// Accept-Encoding is left out so that recorded bodies are never compressed.
+func forwardHeaders(in http.Header) http.Header {
+	out := in.Clone()
+	for _, name := range append(hopHeaders, "Host", "Accept-Encoding", dtos.EnvironmentHeader) {
+		out.Del(name)
+	}
+	return out
+}
+
+// flattenHeaders joins multi-valued headers, leaving out hop-by-hop ones and skip
+func flattenHeaders(in http.Header, skip ...string) map[string]string {
+	out := make(map[string]string, len(in))
+	for name, values := range in {
+		name = http.CanonicalHeaderKey(name)
+		if slices.Contains(hopHeaders, name) || slices.Contains(skip, name) {
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func redactHeaders(headers map[string]string, names []string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if slices.Contains(names, http.CanonicalHeaderKey(name)) {
+			value = redactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// stripHeaders returns the headers without the named ones
+func stripHeaders(headers map[string]string, names []string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if !slices.Contains(names, http.CanonicalHeaderKey(name)) {
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// recordingKey identifies a request by its method, path and the matched parts
+func recordingKey(proxy *models.ProxyConfig, req *MockRequest) string {
+	var b strings.Builder
+	b.WriteString(req.Method + " /" + strings.Trim(req.Path, "/"))
+	if proxy.Match.Query {
+		b.WriteString("?" + req.Query.Encode())
+	}
+	for _, name := range proxy.Match.Headers {
+		b.WriteString("\n" + name + ": " + req.Headers.Get(name))
+	}
+	if proxy.Match.Body {
+		b.WriteString("\n\n")
+		if req.Body != nil {
+			body, _ := json.Marshal(req.Body) // map keys come out sorted
+			b.Write(body)
+		} else {
+			b.Write(req.RawBody)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// proxyConditions turns the matched parts of a request into rule conditions.
+// Only JSON object bodies can be matched, field by field.
+func proxyConditions(proxy *models.ProxyConfig, req *MockRequest) []models.RuleCondition {
+	conditions := []models.RuleCondition{}
+	if proxy.Match.Query {
+		keys := make([]string, 0, len(req.Query))
+		for key := range req.Query {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			conditions = append(conditions, models.RuleCondition{Source: "query", Key: key, Operator: "equals", Value: req.Query.Get(key)})
+		}
+	}
+	for _, name := range proxy.Match.Headers {
+		if value := req.Headers.Get(name); value != "" {
+			conditions = append(conditions, models.RuleCondition{Source: "header", Key: name, Operator: "equals", Value: value})
+		} else {
+			conditions = append(conditions, models.RuleCondition{Source: "header", Key: name, Operator: "notExists"})
+		}
+	}
+	if body, ok := req.Body.(map[string]any); proxy.Match.Body && ok {
+		keys := make([]string, 0, len(body))
+		for key := range body {
+			if !strings.Contains(key, ".") {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			conditions = append(conditions, models.RuleCondition{Source: "body", Key: key, Operator: "equals", Value: body[key]})
+		}
+	}
+	return conditions
+}
+
+// withRecordedRule replaces the rule recorded for the same conditions, or adds
+// the rule ahead of rules with fewer conditions so the most specific one wins
+func withRecordedRule(rules []models.ResponseRule, rule models.ResponseRule) []models.ResponseRule {
+	for i := range rules {
+		if fmt.Sprint(rules[i].Conditions) == fmt.Sprint(rule.Conditions) {
+			rules[i] = rule
+			return rules
+		}
+	}
+	at := len(rules)
+	for i := range rules {
+		if len(rules[i].Conditions) < len(rule.Conditions) {
+			at = i
+			break
+		}
+	}
+	return slices.Insert(rules, at, rule)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Plan tiers used for rate limiting
+const (
+	PlanAnonymous = "anonymous"
+	PlanFree      = "free"
+	PlanPro       = "pro"
+)
+
+// planCacheTTL bounds how long a plan change (upgrade) takes to apply to limits
+const planCacheTTL = 5 * time.Minute
+
+// slidingWindowScript implements a sliding-window log on a sorted set:
+// drop entries older than the window, then admit the request if there is room.
+// Returns {allowed, count, oldest score}.
+var slidingWindowScript = goredis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+local count = redis.call("ZCARD", key)
+local allowed = 0
+if count < limit then
+	redis.call("ZADD", key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call("PEXPIRE", key, window)
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+local oldestScore = now
+if oldest[2] then
+	oldestScore = tonumber(oldest[2])
+end
+return {allowed, count, oldestScore}
+`)
+
+// RateLimitResult describes the state of a client's window after a request
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Window    time.Duration
+	Reset     time.Duration // until the oldest request leaves the window
+}
+
+// RateLimitService enforces per-plan request limits shared by all instances
+// through Redis.
+type RateLimitService struct {
+	userColl    store.Repository
+	projectColl store.Repository
+	apiKeyColl  store.Repository
+	access      *projectAccess
+	ctx         context.Context
+	cfg         *config.Config
+}
+
+func NewRateLimitService(db store.Store, cfg *config.Config) *RateLimitService {
+	return &RateLimitService{
+		userColl:    db.Repository(database.Collections.Users),
+		projectColl: db.Repository(database.Collections.Projects),
+		apiKeyColl:  db.Repository(database.Collections.APIKeys),
+		access:      newProjectAccess(db),
+		ctx:         context.Background(),
+		cfg:         cfg,
+	}
+}
+
+// Enabled reports whether limits can be enforced (they need Redis)
+func (s *RateLimitService) Enabled() bool {
+	return redisClient.GetClient() != nil
+}
+
+// LimitFor returns the number of requests per window allowed for a plan
+func (s *RateLimitService) LimitFor(plan string) int {
+	switch plan {
+	case PlanPro:
+		return s.cfg.RateLimitPro
+	case PlanFree:
+		return s.cfg.RateLimitFree
+	}
+	return s.cfg.RateLimitAnonymous
+}
+
+// Allow records a request for the identity and reports whether it fits in
+// the plan's window. It fails open: without Redis every request is allowed.
+func (s *RateLimitService) Allow(identity, plan string) (*RateLimitResult, error) {
+	limit := s.LimitFor(plan)
+	window := s.cfg.RateLimitWindow
+	result := &RateLimitResult{Allowed: true, Limit: limit, Remaining: limit, Window: window}
+
+	rdb := redisClient.GetClient()
+	if rdb == nil || limit <= 0 || window <= 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	member := fmt.Sprintf("%d-%s", now.UnixNano(), primitive.NewObjectID().Hex())
+	res, err := slidingWindowScript.Run(s.ctx, rdb,
+		[]string{redisClient.RateLimitKey(identity)},
+		now.UnixMilli(), window.Milliseconds(), limit, member,
+	).Int64Slice()
+	if err != nil {
+		return result, err
+	}
+
+	result.Allowed = res[0] == 1
+	result.Remaining = max(limit-int(res[1]), 0)
+	result.Reset = max(time.Duration(res[2]+window.Milliseconds()-now.UnixMilli())*time.Millisecond, 0)
+	return result, nil
+}
+
+// UserPlan returns the plan of a user, cached in Redis
+func (s *RateLimitService) UserPlan(userID string) string {
+	cacheKey := redisClient.UserPlanKey(userID)
+
+	var plan string
+	if redisClient.GetCachedJSON(cacheKey, &plan) {
+		return plan
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return PlanAnonymous
+	}
+
+	var user models.User
+	if err := s.userColl.FindOne(s.ctx, bson.M{"_id": uid}, &user); err != nil {
+		return PlanAnonymous
+	}
+
+	plan = PlanFree
+	if user.IsUpgraded {
+		plan = PlanPro
+	}
+	redisClient.SetCachedJSON(cacheKey, plan, planCacheTTL)
+	return plan
+}
+
+// APIKeyPlan resolves a plain API key to its id and the plan of the project it
+// belongs to. Unknown, revoked and expired keys are not found, so made-up keys
+// cannot open fresh buckets.
+func (s *RateLimitService) APIKeyPlan(plain string) (string, string, bool) {
+	var key models.APIKey
+	if err := s.apiKeyColl.FindOne(s.ctx, bson.M{"keyHash": hashAPIKey(plain)}, &key); err != nil {
+		return "", "", false
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return "", "", false
+	}
+
+	return key.ID.Hex(), s.ProjectPlan(key.ProjectID.Hex()), true
+}
+
+// ProjectPlan returns the plan of a project's organization or owner; sandbox
+// projects are anonymous
+func (s *RateLimitService) ProjectPlan(projectID string) string {
+	cacheKey := redisClient.ProjectPlanKey(projectID)
+
+	var plan string
+	if redisClient.GetCachedJSON(cacheKey, &plan) {
+		return plan
+	}
+
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return PlanAnonymous
+	}
+
+	var project models.Project
+	if err := s.projectColl.FindOne(s.ctx, bson.M{"_id": pid}, &project); err != nil {
+		return PlanAnonymous
+	}
+
+	plan = s.access.plan(&project)
+	redisClient.SetCachedJSON(cacheKey, plan, planCacheTTL)
+	return plan
+}
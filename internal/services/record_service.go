@@ -11,180 +11,387 @@ import (
 
 	"github.com/saifwork/mock-service/internal/core/config"
 	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
 	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type RecordService struct {
-	coll           *mongo.Collection
-	collectioncoll *mongo.Collection
+	coll           store.Repository
+	collectioncoll store.Repository
+	access         *projectAccess
+	audit          *auditLog
+	webhooks       *webhookDispatcher
+	revisions      *revisionTrail
+	violations     *violationLog
 	ctx            context.Context
 	cfg            *config.Config
 }
 
-func NewRecordService(client *mongo.Client, cfg *config.Config) *RecordService {
-	collection := client.Database(cfg.MongoDBName).Collection(database.Collections.Records)
-	collectioncoll := client.Database(cfg.MongoDBName).Collection(database.Collections.Collection)
+func NewRecordService(db store.Store, cfg *config.Config) *RecordService {
+	collection := db.Repository(database.Collections.Records)
+	collectioncoll := db.Repository(database.Collections.Collection)
 	return &RecordService{
 		coll:           collection,
 		collectioncoll: collectioncoll,
+		access:         newProjectAccess(db),
+		audit:          newAuditLog(db),
+		webhooks:       newWebhookDispatcher(db),
+		revisions:      newRevisionTrail(db),
+		violations:     newViolationLog(db),
 		ctx:            context.Background(),
 		cfg:            cfg,
 	}
 }
 
-// CreateRecord adds a new record under a specific collection
-func (s *RecordService) CreateRecord(collectionID string, data map[string]interface{}) (*models.Record, error) {
-	cid, err := primitive.ObjectIDFromHex(collectionID)
+// CreateRecord adds a new record under a specific collection, in the given
+// environment of its project ("" for the default one)
+func (s *RecordService) CreateRecord(collectionID, environment string, actor dtos.Actor, data map[string]interface{}) (*models.Record, error) {
+	// Ensure the collection exists and the caller may edit it
+	collection, err := s.access.authorizeCollection(s.collectioncoll, s.cfg, "", collectionID, actor.UserID, models.RoleEditor)
 	if err != nil {
-		return nil, errors.New("invalid collection id")
+		return nil, err
 	}
-
-	// Ensure the collection exists
-	var collection models.Collection
-	err = s.collectioncoll.FindOne(context.Background(), bson.M{"_id": cid}).Decode(&collection)
+	env, err := s.access.environment(collection.ProjectID, environment)
 	if err != nil {
-		return nil, errors.New("collection not found")
+		return nil, err
 	}
 
-	if err := validateRecordData(collection.Fields, data); err != nil {
+	return s.insertRecord(collection, env, data, actor)
+}
+
+// GetRecordsByCollection fetches the records of a collection in one environment
+func (s *RecordService) GetRecordsByCollection(collectionID, environment, userID string) ([]models.Record, error) {
+	collection, err := s.access.authorizeCollection(s.collectioncoll, s.cfg, "", collectionID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	env, err := s.access.environment(collection.ProjectID, environment)
+	if err != nil {
 		return nil, err
 	}
 
-	record := &models.Record{
-		ID:           primitive.NewObjectID(),
-		CollectionID: cid,
-		Data:         data,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+	return s.findRecords(collection.ID, env)
+}
+
+// GetRecordByID returns a single record of a collection
+func (s *RecordService) GetRecordByID(collectionID, id, userID string) (*models.Record, error) {
+	_, record, err := s.authorizeRecord(collectionID, id, userID, models.RoleViewer)
+	return record, err
+}
+
+// UpdateRecord updates record data after validating it against collection fields.
+// ifMatch is the If-Match header of the caller, if any; the update is refused
+// when it does not list the current version of the record.
+func (s *RecordService) UpdateRecord(collectionID, id string, actor dtos.Actor, data map[string]interface{}, ifMatch string) (*models.Record, error) {
+	_, record, err := s.authorizeRecord(collectionID, id, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = s.coll.InsertOne(context.Background(), record)
+	return s.replaceRecord(record, data, actor, ifMatch)
+}
+
+// PatchRecord merges fields into the record data, on the same If-Match
+// condition as UpdateRecord
+func (s *RecordService) PatchRecord(collectionID, id string, actor dtos.Actor, data map[string]interface{}, ifMatch string) (*models.Record, error) {
+	_, record, err := s.authorizeRecord(collectionID, id, actor.UserID, models.RoleEditor)
 	if err != nil {
 		return nil, err
 	}
 
-	return record, nil
+	return s.replaceRecord(record, mergeRecordData(record.Data, data), actor, ifMatch)
+}
+
+// DeleteRecord removes a record, on the same If-Match condition as UpdateRecord
+func (s *RecordService) DeleteRecord(collectionID, id string, actor dtos.Actor, ifMatch string) error {
+	collection, record, err := s.authorizeRecord(collectionID, id, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	return s.removeRecord(collection, record, actor, ifMatch)
 }
 
-// GetRecordsByCollection fetches all records of a collection
-func (s *RecordService) GetRecordsByCollection(collectionID string) ([]models.Record, error) {
-	cid, err := primitive.ObjectIDFromHex(collectionID)
+// authorizeRecord resolves record → collection → project and checks the
+// caller's role. A record addressed through another collection is not found.
+func (s *RecordService) authorizeRecord(collectionID, id, userID, minRole string) (*models.Collection, *models.Record, error) {
+	collection, err := s.access.authorizeCollection(s.collectioncoll, s.cfg, "", collectionID, userID, minRole)
 	if err != nil {
-		return nil, errors.New("invalid collection id")
+		return nil, nil, err
 	}
 
-	cursor, err := s.coll.Find(context.Background(), bson.M{"collectionId": cid})
+	record, err := s.findRecord(id)
 	if err != nil {
+		return nil, nil, err
+	}
+	if record.CollectionID != collection.ID {
+		return nil, nil, notFoundError("record not found")
+	}
+
+	return collection, record, nil
+}
+
+// The helpers below skip role checks; they back the public mock routes,
+// which are reachable by anyone who knows the project id. Writes are still
+// audited with whatever is known about the caller.
+
+func (s *RecordService) insertRecord(collection *models.Collection, env string, data map[string]interface{}, actor dtos.Actor) (*models.Record, error) {
+	observed, err := s.checkRecordData(collection, data)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.Record{
+		ID:           primitive.NewObjectID(),
+		CollectionID: collection.ID,
+		Data:         data,
+		Version:      1,
+		Environment:  env,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.coll.InsertOne(context.Background(), record); err != nil {
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	invalidateRecordCache(collection)
+	s.violations.record(actor, collection, record, "create", observed)
+	s.recordAudit(actor, models.AuditRecordCreate, collection, record.ID, nil, record.Data)
+	s.webhooks.emit(collection.ProjectID, models.WebhookRecordCreated, recordEventData(collection, record, nil))
+	publishRecordChange(models.WebhookRecordCreated, collection, record)
 
+	return record, nil
+}
+
+func (s *RecordService) findRecords(cid primitive.ObjectID, env string) ([]models.Record, error) {
 	var records []models.Record
-	if err := cursor.All(context.Background(), &records); err != nil {
+	if err := s.coll.Find(context.Background(), notDeleted(inEnvironment(bson.M{"collectionId": cid}, env)), &records); err != nil {
 		return nil, err
 	}
 
 	return records, nil
 }
 
-// GetRecordByID returns a single record
-func (s *RecordService) GetRecordByID(id string) (*models.Record, error) {
+func (s *RecordService) findRecord(id string) (*models.Record, error) {
 	rid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("invalid record id")
 	}
 
 	var record models.Record
-	err = s.coll.FindOne(context.Background(), bson.M{"_id": rid}).Decode(&record)
-	if err != nil {
-		return nil, errors.New("record not found")
+	if err := s.coll.FindOne(context.Background(), notDeleted(bson.M{"_id": rid}), &record); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("record not found")
+		}
+		return nil, err
 	}
 
 	return &record, nil
 }
 
-// UpdateRecord updates record data after validating it against collection fields
-func (s *RecordService) UpdateRecord(id string, data map[string]interface{}) (*models.Record, error) {
-	rid, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, errors.New("invalid record id")
+func (s *RecordService) replaceRecord(existing *models.Record, data map[string]interface{}, actor dtos.Actor, ifMatch string) (*models.Record, error) {
+	if err := checkIfMatch(existing, ifMatch); err != nil {
+		return nil, err
 	}
 
-	// Fetch the existing record
-	var existingRecord models.Record
-	err = s.coll.FindOne(context.Background(), bson.M{"_id": rid}).Decode(&existingRecord)
-	if err != nil {
-		return nil, errors.New("record not found")
+	updated, err := s.reviseRecord(existing, data, actor, 0)
+	if err == errRecordChanged && ifMatch != "" {
+		return nil, errRecordPrecondition
 	}
+	return updated, err
+}
 
+// mergeRecordData returns the stored data with the patched fields set. The
+// stored data is BSON-decoded and is turned back into plain JSON types first,
+// so that nested arrays and objects pass validation.
+func mergeRecordData(existing, patch map[string]interface{}) map[string]interface{} {
+	stored, _ := utils.NormalizeBSON(existing).(map[string]any)
+	merged := make(map[string]interface{}, len(stored)+len(patch))
+	for k, v := range stored {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		merged[k] = v
+	}
+	return merged
+}
+
+// reviseRecord replaces the data of a record and keeps the previous data as a
+// revision; revertedFrom is the revision a revert goes back to
+func (s *RecordService) reviseRecord(existing *models.Record, data map[string]interface{}, actor dtos.Actor, revertedFrom int) (*models.Record, error) {
 	// Fetch the associated collection
-	var collection models.Collection
-	err = s.collectioncoll.FindOne(context.Background(), bson.M{"_id": existingRecord.CollectionID}).Decode(&collection)
+	collection, err := findCollection(s.collectioncoll, s.cfg, existing.CollectionID)
 	if err != nil {
 		return nil, errors.New("associated collection not found")
 	}
 
 	// Validate updated data
-	if err := validateRecordData(collection.Fields, data); err != nil {
+	observed, err := s.checkRecordData(collection, data)
+	if err != nil {
 		return nil, err
 	}
 
-	// Apply update
+	// Apply update, unless the record changed since it was read
 	update := bson.M{
 		"$set": bson.M{
 			"data":      data,
 			"updatedAt": time.Now(),
 		},
-	}
-
-	res := s.coll.FindOneAndUpdate(
-		context.Background(),
-		bson.M{"_id": rid},
-		update,
-		options.FindOneAndUpdate().SetReturnDocument(options.After),
-	)
-	if res.Err() != nil {
-		return nil, res.Err()
+		"$inc": bson.M{"version": 1},
 	}
 
 	var updated models.Record
-	if err := res.Decode(&updated); err != nil {
+	if err := s.coll.FindOneAndUpdate(context.Background(), atVersion(bson.M{"_id": existing.ID}, existing.Version), update, &updated); err != nil {
+		if err == store.ErrNotFound {
+			return nil, s.staleRecord(existing.ID)
+		}
 		return nil, err
 	}
+	invalidateRecordCache(collection)
+	s.violations.record(actor, collection, &updated, "update", observed)
+	s.revisions.save(collection, existing, actor, revertedFrom)
+	s.recordAudit(actor, models.AuditRecordUpdate, collection, existing.ID, existing.Data, updated.Data)
+	s.webhooks.emit(collection.ProjectID, models.WebhookRecordUpdated, recordEventData(collection, &updated, existing))
+	publishRecordChange(models.WebhookRecordUpdated, collection, &updated)
 
 	return &updated, nil
 }
 
-// DeleteRecord removes a record
-func (s *RecordService) DeleteRecord(id string) error {
-	rid, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return errors.New("invalid record id")
+// checkRecordData validates record data in the validation mode of the project:
+// enforce rejects the first violation, observe accepts the data and returns
+// every violation for the caller to log once the write succeeded
+func (s *RecordService) checkRecordData(collection *models.Collection, data map[string]interface{}) ([]models.FieldViolation, error) {
+	violations := recordViolations(collection.Fields, data)
+	if len(violations) == 0 {
+		return nil, nil
+	}
+	if !s.violations.observing(collection.ProjectID) {
+		return nil, errors.New(violations[0].Message)
 	}
+	return violations, nil
+}
 
-	res, err := s.coll.DeleteOne(context.Background(), bson.M{"_id": rid})
+// removeRecord moves a record to the trash; it is purged after the trash retention
+func (s *RecordService) removeRecord(collection *models.Collection, record *models.Record, actor dtos.Actor, ifMatch string) error {
+	if err := checkIfMatch(record, ifMatch); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filter := atVersion(notDeleted(bson.M{"_id": record.ID}), record.Version)
+	matched, err := s.coll.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"deletedAt": now}})
 	if err != nil {
 		return err
 	}
-	if res.DeletedCount == 0 {
-		return errors.New("record not found")
+	if matched == 0 {
+		err := s.staleRecord(record.ID)
+		if err == errRecordChanged && ifMatch != "" {
+			return errRecordPrecondition
+		}
+		return err
 	}
+	record.DeletedAt = &now
+	invalidateRecordCache(collection)
+	s.recordAudit(actor, models.AuditRecordDelete, collection, record.ID, record.Data, nil)
+	s.webhooks.emit(collection.ProjectID, models.WebhookRecordDeleted, recordEventData(collection, record, nil))
+	publishRecordChange(models.WebhookRecordDeleted, collection, record)
 
 	return nil
 }
 
+var (
+	errRecordChanged      = conflictError("the record was changed by another request, fetch it and retry")
+	errRecordPrecondition = preconditionError("the record does not match If-Match, it was changed since it was fetched")
+)
+
+// checkIfMatch refuses a write whose If-Match header, when sent, does not
+// list the current version of the record
+func checkIfMatch(record *models.Record, ifMatch string) error {
+	if ifMatch != "" && !utils.ETagMatches(ifMatch, utils.VersionETag(record.Version), false) {
+		return errRecordPrecondition
+	}
+	return nil
+}
+
+// atVersion narrows a record filter to the version that was read, so a write
+// racing another one fails instead of overwriting it. Records older than
+// versions have no version field.
+func atVersion(filter bson.M, version int64) bson.M {
+	if version == 0 {
+		filter["version"] = bson.M{"$exists": false}
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// staleRecord explains why a versioned write matched nothing: the record was
+// either changed or deleted since it was read
+func (s *RecordService) staleRecord(id primitive.ObjectID) error {
+	var current models.Record
+	if err := s.coll.FindOne(context.Background(), notDeleted(bson.M{"_id": id}), &current); err != nil {
+		if err == store.ErrNotFound {
+			return notFoundError("record not found")
+		}
+		return err
+	}
+	return errRecordChanged
+}
+
+// recordAudit logs a record change with the diff of its data
+func (s *RecordService) recordAudit(actor dtos.Actor, action string, collection *models.Collection, recordID primitive.ObjectID, before, after map[string]any) {
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     action,
+		TargetType: "record",
+		TargetID:   recordID.Hex(),
+		ProjectID:  &collection.ProjectID,
+		Changes:    diffFields(before, after),
+	})
+}
+
+// recordEventData is the webhook payload data of a record event; previous is
+// the record before an update
+func recordEventData(collection *models.Collection, record, previous *models.Record) map[string]any {
+	data := map[string]any{
+		"collectionId": collection.ID.Hex(),
+		"collection":   collection.Name,
+		"record":       record,
+	}
+	if previous != nil {
+		data["previous"] = previous
+	}
+	return data
+}
+
+// invalidateRecordCache drops the cached mock GET responses of a collection
+func invalidateRecordCache(collection *models.Collection) {
+	redisClient.InvalidateCache(redisClient.ProjectDataKey(collection.ProjectID.Hex(), collection.Name))
+}
+
 var emailRegex = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
 
 func validateRecordData(fields []models.FieldDefinition, data map[string]interface{}) error {
+	if violations := recordViolations(fields, data); len(violations) > 0 {
+		return errors.New(violations[0].Message)
+	}
+	return nil
+}
+
+// recordViolations checks record data against collection fields and lists
+// every broken rule, at most one per field
+func recordViolations(fields []models.FieldDefinition, data map[string]interface{}) []models.FieldViolation {
+	violations := []models.FieldViolation{}
 	for _, field := range fields {
 		val, exists := data[field.Name]
 
 		if field.Required && !exists {
-			return fmt.Errorf("missing required field: %s", field.Name)
+			violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "required", Message: fmt.Sprintf("missing required field: %s", field.Name)})
+			continue
 		}
 
 		if !exists {
@@ -195,69 +402,77 @@ func validateRecordData(fields []models.FieldDefinition, data map[string]interfa
 		case "string":
 			str, ok := val.(string)
 			if !ok {
-				return fmt.Errorf("field %s must be a string", field.Name)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "type", Message: fmt.Sprintf("field %s must be a string", field.Name)})
+				continue
 			}
 			if field.MinLength != nil && len(str) < *field.MinLength {
-				return fmt.Errorf("field %s must be at least %d characters", field.Name, *field.MinLength)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "minLength", Message: fmt.Sprintf("field %s must be at least %d characters", field.Name, *field.MinLength)})
+				continue
 			}
 			if field.MaxLength != nil && len(str) > *field.MaxLength {
-				return fmt.Errorf("field %s must be at most %d characters", field.Name, *field.MaxLength)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "maxLength", Message: fmt.Sprintf("field %s must be at most %d characters", field.Name, *field.MaxLength)})
+				continue
 			}
 			if field.Pattern != nil {
 				match, _ := regexp.MatchString(*field.Pattern, str)
 				if !match {
-					return fmt.Errorf("field %s does not match required pattern", field.Name)
+					violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "pattern", Message: fmt.Sprintf("field %s does not match required pattern", field.Name)})
+					continue
 				}
 			}
 			if field.Type == "email" {
 				if !emailRegex.MatchString(str) {
-					return fmt.Errorf("field %s must be a valid email", field.Name)
+					violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "email", Message: fmt.Sprintf("field %s must be a valid email", field.Name)})
+					continue
 				}
 			}
 			if field.Type == "url" {
 				_, err := url.ParseRequestURI(str)
 				if err != nil {
-					return fmt.Errorf("field %s must be a valid URL", field.Name)
+					violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "url", Message: fmt.Sprintf("field %s must be a valid URL", field.Name)})
 				}
 			}
 
 		case "number":
 			num, ok := val.(float64)
 			if !ok {
-				return fmt.Errorf("field %s must be a number", field.Name)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "type", Message: fmt.Sprintf("field %s must be a number", field.Name)})
+				continue
 			}
 			if field.MinValue != nil && num < *field.MinValue {
-				return fmt.Errorf("field %s must be >= %f", field.Name, *field.MinValue)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "minValue", Message: fmt.Sprintf("field %s must be >= %f", field.Name, *field.MinValue)})
+				continue
 			}
 			if field.MaxValue != nil && num > *field.MaxValue {
-				return fmt.Errorf("field %s must be <= %f", field.Name, *field.MaxValue)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "maxValue", Message: fmt.Sprintf("field %s must be <= %f", field.Name, *field.MaxValue)})
 			}
 
 		case "boolean":
 			if _, ok := val.(bool); !ok {
-				return fmt.Errorf("field %s must be a boolean", field.Name)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "type", Message: fmt.Sprintf("field %s must be a boolean", field.Name)})
 			}
 
 		case "array":
 			if _, ok := val.([]interface{}); !ok {
-				return fmt.Errorf("field %s must be an array", field.Name)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "type", Message: fmt.Sprintf("field %s must be an array", field.Name)})
 			}
 
 		case "object":
 			if _, ok := val.(map[string]interface{}); !ok {
-				return fmt.Errorf("field %s must be an object", field.Name)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "type", Message: fmt.Sprintf("field %s must be an object", field.Name)})
 			}
 
 		case "enum":
 			str, ok := val.(string)
 			if !ok {
-				return fmt.Errorf("field %s must be a string for enum type", field.Name)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "type", Message: fmt.Sprintf("field %s must be a string for enum type", field.Name)})
+				continue
 			}
 			valid := slices.Contains(field.EnumValues, str)
 			if !valid {
-				return fmt.Errorf("field %s must be one of %v", field.Name, field.EnumValues)
+				violations = append(violations, models.FieldViolation{Field: field.Name, Rule: "enum", Message: fmt.Sprintf("field %s must be one of %v", field.Name, field.EnumValues)})
 			}
 		}
 	}
-	return nil
+	return violations
 }
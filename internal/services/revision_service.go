@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultRevisionLimit = 20
+	maxRevisionLimit     = 100
+	currentRevision      = "current"
+)
+
+// revisionTrail keeps the prior data of changed records. Saving is best
+// effort: a failure is logged and never fails the record change.
+type revisionTrail struct {
+	coll store.Repository
+}
+
+func newRevisionTrail(db store.Store) *revisionTrail {
+	return &revisionTrail{coll: db.Repository(database.Collections.Revisions)}
+}
+
+// revisionLimit is how many revisions a collection keeps per record
+func revisionLimit(collection *models.Collection) int {
+	if collection.RevisionLimit == nil {
+		return defaultRevisionLimit
+	}
+	return *collection.RevisionLimit
+}
+
+// save stores the data previous had before actor changed it, then drops the
+// revisions beyond the limit of the collection
+func (t *revisionTrail) save(collection *models.Collection, previous *models.Record, actor dtos.Actor, revertedFrom int) {
+	ctx := context.Background()
+	limit := revisionLimit(collection)
+	if limit == 0 {
+		t.drop(previous.ID)
+		return
+	}
+
+	var latest []models.RecordRevision
+	err := t.coll.Find(ctx, bson.M{"recordId": previous.ID}, &latest, store.FindOptions{
+		Sort:  bson.D{{Key: "revision", Value: -1}},
+		Limit: 1,
+	})
+	if err != nil {
+		log.Printf("[REVISIONS] Failed to number revision of record %s: %v", previous.ID.Hex(), err)
+		return
+	}
+
+	revision := &models.RecordRevision{
+		ID:           primitive.NewObjectID(),
+		RecordID:     previous.ID,
+		CollectionID: collection.ID,
+		ProjectID:    collection.ProjectID,
+		Revision:     1,
+		Data:         previous.Data,
+		APIKeyID:     actor.APIKeyID,
+		RevertedFrom: revertedFrom,
+		CreatedAt:    time.Now(),
+	}
+	if len(latest) > 0 {
+		revision.Revision = latest[0].Revision + 1
+	}
+	if uid, err := primitive.ObjectIDFromHex(actor.UserID); err == nil {
+		revision.ActorID = &uid
+	}
+
+	if err := t.coll.InsertOne(ctx, revision); err != nil {
+		log.Printf("[REVISIONS] Failed to save revision %d of record %s: %v", revision.Revision, previous.ID.Hex(), err)
+		return
+	}
+
+	// Revisions of a record are numbered without gaps, so the ones over the
+	// limit are the lowest numbers
+	filter := bson.M{"recordId": previous.ID, "revision": bson.M{"$lte": revision.Revision - limit}}
+	if _, err := t.coll.DeleteMany(ctx, filter); err != nil {
+		log.Printf("[REVISIONS] Failed to prune revisions of record %s: %v", previous.ID.Hex(), err)
+	}
+}
+
+// prune applies a lowered limit to every record of a collection
+func (t *revisionTrail) prune(collectionID primitive.ObjectID, limit int) error {
+	ctx := context.Background()
+	if limit == 0 {
+		_, err := t.coll.DeleteMany(ctx, bson.M{"collectionId": collectionID})
+		return err
+	}
+
+	var revisions []models.RecordRevision
+	err := t.coll.Find(ctx, bson.M{"collectionId": collectionID}, &revisions, store.FindOptions{
+		Sort: bson.D{{Key: "revision", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	kept := map[primitive.ObjectID]int{}
+	var expired []primitive.ObjectID
+	for _, revision := range revisions {
+		kept[revision.RecordID]++
+		if kept[revision.RecordID] > limit {
+			expired = append(expired, revision.ID)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	_, err = t.coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": expired}})
+	return err
+}
+
+// drop removes every revision of a record
+func (t *revisionTrail) drop(recordID primitive.ObjectID) {
+	if _, err := t.coll.DeleteMany(context.Background(), bson.M{"recordId": recordID}); err != nil {
+		log.Printf("[REVISIONS] Failed to drop revisions of record %s: %v", recordID.Hex(), err)
+	}
+}
+
+// find loads one revision of a record
+func (t *revisionTrail) find(recordID primitive.ObjectID, revision string) (*models.RecordRevision, error) {
+	number, err := strconv.Atoi(revision)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q", revision)
+	}
+
+	var found models.RecordRevision
+	if err := t.coll.FindOne(context.Background(), bson.M{"recordId": recordID, "revision": number}, &found); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("revision not found")
+		}
+		return nil, err
+	}
+	found.Data, _ = utils.NormalizeBSON(found.Data).(map[string]any)
+
+	return &found, nil
+}
+
+// ListRevisions returns the kept revisions of a record, newest first
+func (s *RecordService) ListRevisions(collectionID, id, userID string) ([]models.RecordRevision, error) {
+	_, record, err := s.authorizeRecord(collectionID, id, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []models.RecordRevision{}
+	err = s.revisions.coll.Find(context.Background(), bson.M{"recordId": record.ID}, &revisions, store.FindOptions{
+		Sort: bson.D{{Key: "revision", Value: -1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		revisions[i].Data, _ = utils.NormalizeBSON(revisions[i].Data).(map[string]any)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns one revision of a record
+func (s *RecordService) GetRevision(collectionID, id, revision, userID string) (*models.RecordRevision, error) {
+	_, record, err := s.authorizeRecord(collectionID, id, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.revisions.find(record.ID, revision)
+}
+
+// DiffRevisions compares the data of two revisions of a record; either side
+// may be "current"
+func (s *RecordService) DiffRevisions(collectionID, id, userID string, query *dtos.RevisionDiffQuery) (*dtos.RevisionDiff, error) {
+	_, record, err := s.authorizeRecord(collectionID, id, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	to := query.To
+	if to == "" {
+		to = currentRevision
+	}
+
+	side := func(revision string) (map[string]any, error) {
+		if revision == currentRevision {
+			return record.Data, nil
+		}
+		found, err := s.revisions.find(record.ID, revision)
+		if err != nil {
+			return nil, err
+		}
+		return found.Data, nil
+	}
+
+	before, err := side(query.From)
+	if err != nil {
+		return nil, err
+	}
+	after, err := side(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dtos.RevisionDiff{
+		RecordID: record.ID.Hex(),
+		From:     query.From,
+		To:       to,
+		Changes:  diffFields(before, after),
+	}, nil
+}
+
+// RevertRecord sets the data of a record back to one of its revisions. The
+// reverted data must still be valid for the current schema, unless the project
+// observes it; the revert is itself a change and keeps a revision of the data
+// it replaces.
+func (s *RecordService) RevertRecord(collectionID, id, revision string, actor dtos.Actor) (*models.Record, error) {
+	collection, record, err := s.authorizeRecord(collectionID, id, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.revisions.find(record.ID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if found.Data == nil {
+		return nil, errors.New("revision has no data")
+	}
+	if !s.violations.observing(collection.ProjectID) {
+		if err := validateRecordData(collection.Fields, found.Data); err != nil {
+			return nil, fmt.Errorf("revision %d does not match the current schema: %w", found.Revision, err)
+		}
+	}
+
+	return s.reviseRecord(record, found.Data, actor, found.Revision)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+)
+
+// MockRequest is the normalised view of an incoming request used by rule matching.
+type MockRequest struct {
+	Method     string
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Headers    http.Header
+	Body       any // decoded JSON body, nil when absent or not JSON
+	RawBody    []byte
+	ClientIP   string
+	// Context of the incoming request: proxied calls stop when the client
+	// goes away
+	Context context.Context
+	// Environment whose records collection routes use, "" for the default
+	// one; set by MockService.Handle
+	Environment string
+	// Resolved is set by MockService.Handle once the project is found; only
+	// requests to existing projects are captured by the inspector
+	Resolved bool
+}
+
+// actor describes the anonymous caller of a mock route for the audit log
+func (r *MockRequest) actor() dtos.Actor {
+	return dtos.Actor{IP: r.ClientIP, UserAgent: r.Headers.Get("User-Agent")}
+}
+
+var ruleOperators = []string{"equals", "notEquals", "regex", "exists", "notExists", "gt", "gte", "lt", "lte"}
+var ruleSources = []string{"method", "param", "query", "header", "body"}
+
+// validateRules checks rule definitions before they are persisted.
+func validateRules(rules []models.ResponseRule) error {
+	for i, rule := range rules {
+		if err := validateMockResponse(rule.Response); err != nil {
+			return fmt.Errorf("rule %d: %v", i+1, err)
+		}
+		for _, cond := range rule.Conditions {
+			if !slices.Contains(ruleSources, cond.Source) {
+				return fmt.Errorf("rule %d: unsupported source %q", i+1, cond.Source)
+			}
+			if !slices.Contains(ruleOperators, cond.Operator) {
+				return fmt.Errorf("rule %d: unsupported operator %q", i+1, cond.Operator)
+			}
+			if cond.Source != "method" && cond.Key == "" {
+				return fmt.Errorf("rule %d: key is required for source %q", i+1, cond.Source)
+			}
+			if cond.Operator == "regex" {
+				pattern, ok := cond.Value.(string)
+				if !ok {
+					return fmt.Errorf("rule %d: regex value must be a string", i+1)
+				}
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("rule %d: invalid regex: %v", i+1, err)
+				}
+			}
+			if isNumericOperator(cond.Operator) {
+				if _, ok := toFloat(cond.Value); !ok {
+					return fmt.Errorf("rule %d: %s requires a numeric value", i+1, cond.Operator)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateMockResponse(resp models.MockResponse) error {
+	if resp.Status < 100 || resp.Status > 599 {
+		return fmt.Errorf("invalid response status %d", resp.Status)
+	}
+	if resp.DelayMs < 0 || resp.DelayMs > maxMockDelayMs {
+		return fmt.Errorf("delayMs must be between 0 and %d", maxMockDelayMs)
+	}
+	return nil
+}
+
+// matchRules returns the first rule whose conditions all match, or nil.
+func matchRules(rules []models.ResponseRule, req *MockRequest) *models.ResponseRule {
+	for i := range rules {
+		if ruleMatches(&rules[i], req) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func ruleMatches(rule *models.ResponseRule, req *MockRequest) bool {
+	for _, cond := range rule.Conditions {
+		if !conditionMatches(cond, req) {
+			return false
+		}
+	}
+	return true
+}
+
+func conditionMatches(cond models.RuleCondition, req *MockRequest) bool {
+	actual, found := lookupRequestValue(cond, req)
+
+	switch cond.Operator {
+	case "exists":
+		return found
+	case "notExists":
+		return !found
+	}
+
+	if !found {
+		return cond.Operator == "notEquals"
+	}
+
+	switch cond.Operator {
+	case "equals":
+		return valuesEqual(actual, cond.Value)
+	case "notEquals":
+		return !valuesEqual(actual, cond.Value)
+	case "regex":
+		pattern, ok := cond.Value.(string)
+		if !ok {
+			return false
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(stringify(actual))
+	case "gt", "gte", "lt", "lte":
+		a, ok1 := toFloat(actual)
+		b, ok2 := toFloat(cond.Value)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch cond.Operator {
+		case "gt":
+			return a > b
+		case "gte":
+			return a >= b
+		case "lt":
+			return a < b
+		default:
+			return a <= b
+		}
+	}
+	return false
+}
+
+func lookupRequestValue(cond models.RuleCondition, req *MockRequest) (any, bool) {
+	switch cond.Source {
+	case "method":
+		return req.Method, true
+	case "param":
+		v, ok := req.PathParams[cond.Key]
+		return v, ok
+	case "query":
+		if !req.Query.Has(cond.Key) {
+			return nil, false
+		}
+		return req.Query.Get(cond.Key), true
+	case "header":
+		values := req.Headers.Values(cond.Key)
+		if len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	case "body":
+		return lookupJSONPath(req.Body, cond.Key)
+	}
+	return nil, false
+}
+
+// lookupJSONPath resolves a dotted path such as "items.0.price" inside decoded JSON.
+func lookupJSONPath(doc any, path string) (any, bool) {
+	current := doc
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			v, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func valuesEqual(actual, expected any) bool {
+	if a, ok := toFloat(actual); ok {
+		if b, ok := toFloat(expected); ok {
+			return a == b
+		}
+	}
+	return stringify(actual) == stringify(expected)
+}
+
+func isNumericOperator(op string) bool {
+	return op == "gt" || op == "gte" || op == "lt" || op == "lte"
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func stringify(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
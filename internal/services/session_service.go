@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrSessionsUnavailable   = errors.New("sandbox sessions require Redis")
+	ErrSessionNotFound       = errors.New("session not found or expired")
+	ErrSessionQuotaExceeded  = errors.New("session request limit reached")
+	errSessionProjectExpired = errors.New("session expired")
+)
+
+// SessionService manages anonymous, expiring sandbox projects.
+// Session metadata and quotas live in Redis; the sandbox data itself is
+// stored like any other project and purged once the session expires.
+type SessionService struct {
+	projectColl store.Repository
+	userColl    store.Repository
+	// trash purges sandbox projects with everything that belongs to them
+	trash *TrashService
+	ctx   context.Context
+	cfg   *config.Config
+}
+
+func NewSessionService(db store.Store, cfg *config.Config) *SessionService {
+	return &SessionService{
+		projectColl: db.Repository(database.Collections.Projects),
+		userColl:    db.Repository(database.Collections.Users),
+		trash:       NewTrashService(db, cfg),
+		ctx:         context.Background(),
+		cfg:         cfg,
+	}
+}
+
+// CreateSession creates a throwaway project reachable by the returned session id
+func (s *SessionService) CreateSession(req *dtos.CreateSessionRequest) (*dtos.CreateSessionResponse, error) {
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return nil, ErrSessionsUnavailable
+	}
+
+	ttl := s.cfg.SessionTTL
+	if req.ExpirySeconds > 0 {
+		requested := time.Duration(req.ExpirySeconds) * time.Second
+		if requested < ttl {
+			ttl = requested
+		}
+	}
+
+	sessionID, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	project := &models.Project{
+		ID:          primitive.NewObjectID(),
+		Name:        "Sandbox project",
+		Description: "Anonymous sandbox session",
+		SessionID:   sessionID,
+		ExpiresAt:   &expiresAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.projectColl.InsertOne(s.ctx, project); err != nil {
+		return nil, err
+	}
+
+	projectID := project.ID.Hex()
+	meta := dtos.SessionMeta{
+		SessionID: sessionID,
+		ProjectID: projectID,
+		CreatedAt: now.UTC().Format(time.RFC3339),
+		Expiry:    ttl / time.Second,
+		ReqLimit:  s.cfg.SessionReqLimit,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+	}
+	payload, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Set(s.ctx, redisClient.SessionMetaKey(sessionID), payload, ttl)
+	pipe.Set(s.ctx, redisClient.SessionProjectKey(sessionID), projectID, ttl)
+	pipe.Set(s.ctx, redisClient.ProjectSessionKey(projectID), sessionID, ttl)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		_ = s.trash.purgeProject(project.ID)
+		return nil, err
+	}
+
+	return &dtos.CreateSessionResponse{
+		SessionID:     sessionID,
+		ProjectID:     projectID,
+		ExpirySeconds: int(ttl / time.Second),
+		RequestLimit:  s.cfg.SessionReqLimit,
+		MockBaseURL:   fmt.Sprintf("%s/mock/%s", s.cfg.AppBaseURL, projectID),
+		Message:       "Sandbox session created",
+	}, nil
+}
+
+// GetSession returns the metadata and current usage of a session
+func (s *SessionService) GetSession(sessionID string) (*dtos.SessionMeta, error) {
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return nil, ErrSessionsUnavailable
+	}
+
+	raw, err := rdb.Get(s.ctx, redisClient.SessionMetaKey(sessionID)).Result()
+	if err != nil {
+		if err == goredis.Nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	var meta dtos.SessionMeta
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil, err
+	}
+
+	count, err := rdb.Get(s.ctx, redisClient.SessionRequestCountKey(sessionID)).Int()
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+	meta.ReqCount = count
+
+	return &meta, nil
+}
+
+// ResolveProject returns the sandbox project id behind a session
+func (s *SessionService) ResolveProject(sessionID string) (string, error) {
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return "", ErrSessionsUnavailable
+	}
+
+	projectID, err := rdb.Get(s.ctx, redisClient.SessionProjectKey(sessionID)).Result()
+	if err != nil {
+		if err == goredis.Nil {
+			return "", ErrSessionNotFound
+		}
+		return "", err
+	}
+
+	return projectID, nil
+}
+
+// ConsumeRequest counts a mock request against the session quota of a
+// sandbox project. Projects without a session are not limited here.
+func (s *SessionService) ConsumeRequest(project *models.Project) error {
+	if project.SessionID == "" {
+		return nil
+	}
+	if project.ExpiresAt != nil && time.Now().After(*project.ExpiresAt) {
+		return errSessionProjectExpired
+	}
+
+	rdb := redisClient.GetClient()
+	if rdb == nil {
+		return ErrSessionsUnavailable
+	}
+
+	countKey := redisClient.SessionRequestCountKey(project.SessionID)
+	count, err := rdb.Incr(s.ctx, countKey).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 && project.ExpiresAt != nil {
+		rdb.ExpireAt(s.ctx, countKey, *project.ExpiresAt)
+	}
+
+	if s.cfg.SessionReqLimit > 0 && count > int64(s.cfg.SessionReqLimit) {
+		return ErrSessionQuotaExceeded
+	}
+
+	return nil
+}
+
+// DeleteSession ends a session immediately and removes its data
+func (s *SessionService) DeleteSession(sessionID string) error {
+	projectID, err := s.ResolveProject(sessionID)
+	if err != nil {
+		return err
+	}
+
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return errors.New("invalid project id")
+	}
+
+	if err := s.trash.purgeProject(pid); err != nil {
+		return err
+	}
+
+	s.clearSessionKeys(sessionID, projectID)
+	return nil
+}
+
+// ConvertSession hands the sandbox project over to a signed-up user and
+// stops it from expiring.
+func (s *SessionService) ConvertSession(sessionID, userID string, req *dtos.ConvertSessionRequest) (*models.Project, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	projectID, err := s.ResolveProject(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+
+	var user models.User
+	if err := s.userColl.FindOne(s.ctx, bson.M{"_id": uid}, &user); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	// Same limit as ProjectService.CreateProject
+	if !user.IsUpgraded {
+		count, err := s.projectColl.CountDocuments(s.ctx, notDeleted(bson.M{"userId": uid, "orgId": bson.M{"$exists": false}}))
+		if err != nil {
+			return nil, err
+		}
+		if count >= freeProjectLimit {
+			return nil, fmt.Errorf("upgrade required — you can only create %d projects with a free account", freeProjectLimit)
+		}
+	}
+
+	set := bson.M{
+		"userId":    uid,
+		"updatedAt": time.Now(),
+	}
+	if req.Name != "" {
+		set["name"] = req.Name
+	}
+	if req.Description != "" {
+		set["description"] = req.Description
+	}
+
+	matched, err := s.projectColl.UpdateOne(s.ctx,
+		bson.M{"_id": pid, "sessionId": sessionID},
+		bson.M{
+			"$set":   set,
+			"$unset": bson.M{"sessionId": "", "expiresAt": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if matched == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	s.clearSessionKeys(sessionID, projectID)
+
+	var project models.Project
+	if err := s.projectColl.FindOne(s.ctx, bson.M{"_id": pid}, &project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// StartCleanup periodically purges sandbox projects whose session expired.
+// Redis drops the session keys on its own through their TTL.
+func (s *SessionService) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeExpired()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *SessionService) purgeExpired() {
+	var projects []models.Project
+	err := s.projectColl.Find(s.ctx, bson.M{
+		"sessionId": bson.M{"$exists": true},
+		"expiresAt": bson.M{"$lte": time.Now()},
+	}, &projects)
+	if err != nil {
+		log.Printf("[SESSION] Failed to look up expired sessions: %v", err)
+		return
+	}
+
+	for _, project := range projects {
+		if err := s.trash.purgeProject(project.ID); err != nil {
+			log.Printf("[SESSION] Failed to purge project %s: %v", project.ID.Hex(), err)
+			continue
+		}
+		s.clearSessionKeys(project.SessionID, project.ID.Hex())
+		log.Printf("[SESSION] Purged expired session %s", project.SessionID)
+	}
+}
+
+func (s *SessionService) clearSessionKeys(sessionID, projectID string) {
+	rdb := redisClient.GetClient()
+	if rdb == nil || sessionID == "" {
+		return
+	}
+
+	rdb.Del(s.ctx,
+		redisClient.SessionMetaKey(sessionID),
+		redisClient.SessionProjectKey(sessionID),
+		redisClient.SessionRequestCountKey(sessionID),
+		redisClient.ProjectSessionKey(projectID),
+	)
+}
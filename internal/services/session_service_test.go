@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// An expired sandbox goes away with everything stored under it, while other
+// projects keep their data
+func TestSessionExpiryPurgesProjectData(t *testing.T) {
+	db := store.NewMemoryStore()
+	svc := NewSessionService(db, &config.Config{AppName: "test"})
+	ctx := context.Background()
+
+	expired := time.Now().Add(-time.Minute)
+	sandbox := models.Project{ID: primitive.NewObjectID(), Name: "Sandbox project", SessionID: "expired", ExpiresAt: &expired}
+	kept := models.Project{ID: primitive.NewObjectID(), UserID: primitive.NewObjectID(), Name: "Shop"}
+
+	byProject := []string{
+		database.Collections.Endpoints,
+		database.Collections.APIKeys,
+		database.Collections.Invitations,
+		database.Collections.Webhooks,
+		database.Collections.Deliveries,
+		database.Collections.Snapshots,
+		database.Collections.SnapRecords,
+		database.Collections.Revisions,
+		database.Collections.Violations,
+	}
+
+	for _, project := range []models.Project{sandbox, kept} {
+		if err := db.Repository(database.Collections.Projects).InsertOne(ctx, project); err != nil {
+			t.Fatal(err)
+		}
+		cid := primitive.NewObjectID()
+		docs := map[string]bson.M{
+			database.Collections.Collection: {"_id": cid, "projectId": project.ID, "name": "orders"},
+			database.Collections.Records:    {"_id": primitive.NewObjectID(), "collectionId": cid, "data": bson.M{}},
+		}
+		for _, name := range byProject {
+			docs[name] = bson.M{"_id": primitive.NewObjectID(), "projectId": project.ID, "collectionId": cid}
+		}
+		for name, doc := range docs {
+			if err := db.Repository(name).InsertOne(ctx, doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	svc.purgeExpired()
+
+	count := func(name string, filter bson.M) int64 {
+		t.Helper()
+		n, err := db.Repository(name).CountDocuments(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(database.Collections.Projects, bson.M{"_id": sandbox.ID}); n != 0 {
+		t.Error("expired sandbox project still stored")
+	}
+	if n := count(database.Collections.Projects, bson.M{"_id": kept.ID}); n != 1 {
+		t.Error("other project was purged")
+	}
+	for _, name := range append(byProject, database.Collections.Collection) {
+		if n := count(name, bson.M{"projectId": sandbox.ID}); n != 0 {
+			t.Errorf("%s: %d documents of the expired sandbox left", name, n)
+		}
+		if n := count(name, bson.M{"projectId": kept.ID}); n != 1 {
+			t.Errorf("%s: %d documents of the other project, want 1", name, n)
+		}
+	}
+	if n := count(database.Collections.Records, bson.M{}); n != 1 {
+		t.Errorf("records: %d left, want only the other project's", n)
+	}
+}
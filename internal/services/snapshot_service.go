@@ -0,0 +1,579 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxProjectSnapshots = 20
+	// restoreLockTTL bounds how long a crashed restore can keep a project locked
+	restoreLockTTL = 5 * time.Minute
+)
+
+// Collection states reported by a snapshot diff
+const (
+	diffUnchanged = "unchanged"
+	diffModified  = "modified"
+	diffCreated   = "created"
+	diffDeleted   = "deleted"
+)
+
+var errProjectRestoring = conflictError("project is being restored from a snapshot, retry shortly")
+
+// isRestoring reports whether a snapshot restore currently holds the project
+func isRestoring(project *models.Project) bool {
+	return project.RestoringUntil != nil && time.Now().Before(*project.RestoringUntil)
+}
+
+// SnapshotService captures the collections and records of a project and
+// restores them later.
+type SnapshotService struct {
+	coll           store.Repository
+	recordCopyColl store.Repository
+	projectColl    store.Repository
+	collectionColl store.Repository
+	recordColl     store.Repository
+	access         *projectAccess
+	audit          *auditLog
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewSnapshotService(db store.Store, cfg *config.Config) *SnapshotService {
+	return &SnapshotService{
+		coll:           db.Repository(database.Collections.Snapshots),
+		recordCopyColl: db.Repository(database.Collections.SnapRecords),
+		projectColl:    db.Repository(database.Collections.Projects),
+		collectionColl: db.Repository(database.Collections.Collection),
+		recordColl:     db.Repository(database.Collections.Records),
+		access:         newProjectAccess(db),
+		audit:          newAuditLog(db),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// CreateSnapshot captures every collection of a project with its records
+func (s *SnapshotService) CreateSnapshot(projectID string, actor dtos.Actor, req *dtos.CreateSnapshotRequest) (*models.Snapshot, error) {
+	pid, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	count, err := s.coll.CountDocuments(s.ctx, bson.M{"projectId": pid})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxProjectSnapshots {
+		return nil, fmt.Errorf("a project can keep at most %d snapshots, delete an old one first", maxProjectSnapshots)
+	}
+
+	collections, records, err := s.currentState(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.Snapshot{
+		ID:          primitive.NewObjectID(),
+		ProjectID:   pid,
+		Name:        req.Name,
+		Description: req.Description,
+		Collections: collections,
+		RecordCount: len(records),
+		CreatedBy:   uid,
+		CreatedAt:   time.Now(),
+	}
+
+	copies := make([]any, 0, len(records))
+	for _, record := range records {
+		copies = append(copies, models.SnapshotRecord{
+			ID:         primitive.NewObjectID(),
+			SnapshotID: snapshot.ID,
+			ProjectID:  pid,
+			Record:     record,
+		})
+	}
+	if len(copies) > 0 {
+		if err := s.recordCopyColl.InsertMany(s.ctx, copies); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.coll.InsertOne(s.ctx, snapshot); err != nil {
+		_, _ = s.recordCopyColl.DeleteMany(s.ctx, bson.M{"snapshotId": snapshot.ID})
+		return nil, err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditSnapshotCreate,
+		TargetType: "snapshot",
+		TargetID:   snapshot.ID.Hex(),
+		ProjectID:  &pid,
+		Changes:    diffFields(nil, map[string]any{"name": snapshot.Name, "records": snapshot.RecordCount}),
+	})
+
+	normalizeSnapshot(snapshot)
+	return snapshot, nil
+}
+
+// ListSnapshots returns the snapshots of a project, newest first
+func (s *SnapshotService) ListSnapshots(projectID, userID string) ([]models.Snapshot, error) {
+	pid, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []models.Snapshot{}
+	if err := s.coll.Find(s.ctx, bson.M{"projectId": pid}, &snapshots, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}); err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		normalizeSnapshot(&snapshots[i])
+	}
+
+	return snapshots, nil
+}
+
+// GetSnapshot returns a snapshot with its collection schemas
+func (s *SnapshotService) GetSnapshot(projectID, snapshotID, userID string) (*models.Snapshot, error) {
+	pid, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findSnapshot(pid, snapshotID)
+}
+
+// DeleteSnapshot removes a snapshot and its record copies
+func (s *SnapshotService) DeleteSnapshot(projectID, snapshotID string, actor dtos.Actor) error {
+	pid, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	snapshot, err := s.findSnapshot(pid, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.coll.DeleteOne(s.ctx, bson.M{"_id": snapshot.ID})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return notFoundError("snapshot not found")
+	}
+	if _, err := s.recordCopyColl.DeleteMany(s.ctx, bson.M{"snapshotId": snapshot.ID}); err != nil {
+		return err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditSnapshotDelete,
+		TargetType: "snapshot",
+		TargetID:   snapshot.ID.Hex(),
+		ProjectID:  &pid,
+		Changes:    diffFields(map[string]any{"name": snapshot.Name, "records": snapshot.RecordCount}, nil),
+	})
+
+	return nil
+}
+
+// DiffSnapshot compares a snapshot with the current collections and records
+func (s *SnapshotService) DiffSnapshot(projectID, snapshotID, userID string) (*dtos.SnapshotDiff, error) {
+	pid, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.findSnapshot(pid, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	savedRecords, err := s.snapshotRecords(snapshot.ID)
+	if err != nil {
+		return nil, err
+	}
+	collections, records, err := s.currentState(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[primitive.ObjectID]*models.Collection{}
+	for i := range collections {
+		current[collections[i].ID] = &collections[i]
+	}
+
+	diff := &dtos.SnapshotDiff{SnapshotID: snapshot.ID.Hex(), Collections: []dtos.CollectionDiff{}}
+	for i := range snapshot.Collections {
+		saved := &snapshot.Collections[i]
+		now, exists := current[saved.ID]
+		entry := newCollectionDiff(saved)
+		if !exists {
+			entry.Status = diffDeleted
+			entry.RecordsDeleted = recordIDs(recordsOf(savedRecords, saved.ID))
+		} else {
+			entry.SchemaChanges = diffFields(collectionSnapshot(saved), collectionSnapshot(now))
+			diffRecords(&entry, recordsOf(savedRecords, saved.ID), recordsOf(records, saved.ID))
+			if len(entry.SchemaChanges) > 0 || len(entry.RecordsCreated)+len(entry.RecordsUpdated)+len(entry.RecordsDeleted) > 0 {
+				entry.Status = diffModified
+			}
+			delete(current, saved.ID)
+		}
+		diff.Collections = append(diff.Collections, entry)
+	}
+
+	// Whatever is left was created after the snapshot
+	for i := range collections {
+		if _, created := current[collections[i].ID]; !created {
+			continue
+		}
+		entry := newCollectionDiff(&collections[i])
+		entry.Status = diffCreated
+		entry.RecordsCreated = recordIDs(recordsOf(records, collections[i].ID))
+		diff.Collections = append(diff.Collections, entry)
+	}
+
+	return diff, nil
+}
+
+// RestoreSnapshot puts the project back in the captured state: collections
+// keep their ids and records are restored with their original ids. Without
+// names, collections created after the snapshot are removed too.
+//
+// While the restore runs the project answers 409 to every other request, so
+// callers never observe a half-restored project; if a write fails the
+// previous state is put back.
+func (s *SnapshotService) RestoreSnapshot(projectID, snapshotID string, actor dtos.Actor, names []string) (*dtos.SnapshotRestoreResult, error) {
+	pid, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.findSnapshot(pid, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🧭 Step 1: Work out what the restore touches, before locking anything
+	targets, err := snapshotTargets(snapshot, names)
+	if err != nil {
+		return nil, err
+	}
+	savedRecords, err := s.snapshotRecords(snapshot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🔒 Step 2: Lock the project; a concurrent restore fails here
+	if err := s.lockProject(pid); err != nil {
+		return nil, err
+	}
+	defer s.unlockProject(pid)
+
+	// 📸 Step 3: Keep the state being replaced, to roll back on failure
+	collections, records, err := s.currentState(pid)
+	if err != nil {
+		return nil, err
+	}
+	replaced := replacedCollections(collections, targets, len(names) == 0)
+	var replacedRecords []models.Record
+	for _, collection := range replaced {
+		replacedRecords = append(replacedRecords, recordsOf(records, collection.ID)...)
+	}
+	var restoredRecords []models.Record
+	for _, collection := range targets {
+		restoredRecords = append(restoredRecords, recordsOf(savedRecords, collection.ID)...)
+	}
+	// Restored records move past any version clients may hold, so their
+	// stale ETags fail rather than match the restored data
+	versions := make(map[primitive.ObjectID]int64, len(records))
+	for _, record := range records {
+		versions[record.ID] = record.Version
+	}
+	for i := range restoredRecords {
+		restoredRecords[i].Version = max(restoredRecords[i].Version, versions[restoredRecords[i].ID]) + 1
+	}
+
+	// ♻️ Step 4: Swap the collections and records
+	if err := s.replaceState(replaced, targets, restoredRecords); err != nil {
+		log.Printf("[SNAPSHOT] Restore of %s failed, rolling back: %v", snapshot.ID.Hex(), err)
+		if rollbackErr := s.replaceState(targets, replaced, replacedRecords); rollbackErr != nil {
+			log.Printf("[SNAPSHOT] Rollback of project %s failed: %v", pid.Hex(), rollbackErr)
+		}
+		return nil, fmt.Errorf("restore failed, the project was left unchanged: %w", err)
+	}
+
+	result := &dtos.SnapshotRestoreResult{Restored: []string{}, Removed: []string{}, Records: len(restoredRecords)}
+	for _, collection := range targets {
+		result.Restored = append(result.Restored, collection.Name)
+	}
+	for _, collection := range replaced {
+		if !slices.ContainsFunc(targets, func(c models.Collection) bool { return c.ID == collection.ID || c.Name == collection.Name }) {
+			result.Removed = append(result.Removed, collection.Name)
+		}
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditSnapshotRestore,
+		TargetType: "snapshot",
+		TargetID:   snapshot.ID.Hex(),
+		ProjectID:  &pid,
+		Changes: diffFields(nil, map[string]any{
+			"name":     snapshot.Name,
+			"restored": result.Restored,
+			"removed":  result.Removed,
+			"records":  result.Records,
+		}),
+	})
+
+	return result, nil
+}
+
+// replaceState deletes the removed collections with their records, then
+// inserts the added ones. Caches of both sides are dropped.
+func (s *SnapshotService) replaceState(removed, added []models.Collection, addedRecords []models.Record) error {
+	defer func() {
+		for i := range removed {
+			invalidateCollectionCache(&removed[i])
+		}
+		for i := range added {
+			invalidateCollectionCache(&added[i])
+		}
+	}()
+
+	for _, collection := range removed {
+		if _, err := s.recordColl.DeleteMany(s.ctx, bson.M{"collectionId": collection.ID}); err != nil {
+			return err
+		}
+		if _, err := s.collectionColl.DeleteOne(s.ctx, bson.M{"_id": collection.ID}); err != nil {
+			return err
+		}
+	}
+
+	for _, collection := range added {
+		// A partly applied earlier attempt may have left documents behind
+		if _, err := s.recordColl.DeleteMany(s.ctx, bson.M{"collectionId": collection.ID}); err != nil {
+			return err
+		}
+		if _, err := s.collectionColl.DeleteOne(s.ctx, bson.M{"_id": collection.ID}); err != nil {
+			return err
+		}
+		if err := s.collectionColl.InsertOne(s.ctx, collection); err != nil {
+			return err
+		}
+	}
+
+	if len(addedRecords) > 0 {
+		docs := make([]any, 0, len(addedRecords))
+		for _, record := range addedRecords {
+			docs = append(docs, record)
+		}
+		if err := s.recordColl.InsertMany(s.ctx, docs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SnapshotService) lockProject(pid primitive.ObjectID) error {
+	now := time.Now()
+	matched, err := s.projectColl.UpdateOne(s.ctx,
+		bson.M{"_id": pid, "$or": bson.A{
+			bson.M{"restoringUntil": bson.M{"$exists": false}},
+			bson.M{"restoringUntil": bson.M{"$lte": now}},
+		}},
+		bson.M{"$set": bson.M{"restoringUntil": now.Add(restoreLockTTL)}},
+	)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return conflictError("another restore of this project is in progress")
+	}
+	return nil
+}
+
+func (s *SnapshotService) unlockProject(pid primitive.ObjectID) {
+	if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": pid}, bson.M{"$unset": bson.M{"restoringUntil": ""}}); err != nil {
+		log.Printf("[SNAPSHOT] Failed to unlock project %s, it unlocks after %v: %v", pid.Hex(), restoreLockTTL, err)
+	}
+}
+
+// currentState loads the collections of a project and all their records
+func (s *SnapshotService) currentState(pid primitive.ObjectID) ([]models.Collection, []models.Record, error) {
+	collections := []models.Collection{}
+	if err := s.collectionColl.Find(s.ctx, notDeleted(bson.M{"projectId": pid}), &collections, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+		return nil, nil, err
+	}
+	if len(collections) == 0 {
+		return collections, nil, nil
+	}
+
+	ids := bson.A{}
+	for _, collection := range collections {
+		ids = append(ids, collection.ID)
+	}
+	var records []models.Record
+	if err := s.recordColl.Find(s.ctx, notDeleted(bson.M{"collectionId": bson.M{"$in": ids}}), &records, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+		return nil, nil, err
+	}
+
+	return collections, records, nil
+}
+
+func (s *SnapshotService) snapshotRecords(snapshotID primitive.ObjectID) ([]models.Record, error) {
+	var copies []models.SnapshotRecord
+	if err := s.recordCopyColl.Find(s.ctx, bson.M{"snapshotId": snapshotID}, &copies); err != nil {
+		return nil, err
+	}
+
+	records := make([]models.Record, 0, len(copies))
+	for _, saved := range copies {
+		saved.Record.Data, _ = utils.NormalizeBSON(saved.Record.Data).(map[string]any)
+		records = append(records, saved.Record)
+	}
+	return records, nil
+}
+
+func (s *SnapshotService) findSnapshot(pid primitive.ObjectID, snapshotID string) (*models.Snapshot, error) {
+	sid, err := primitive.ObjectIDFromHex(snapshotID)
+	if err != nil {
+		return nil, errors.New("invalid snapshot id")
+	}
+
+	var snapshot models.Snapshot
+	if err := s.coll.FindOne(s.ctx, bson.M{"_id": sid, "projectId": pid}, &snapshot); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("snapshot not found")
+		}
+		return nil, err
+	}
+	normalizeSnapshot(&snapshot)
+
+	return &snapshot, nil
+}
+
+// normalizeSnapshot turns BSON-decoded rule values of the saved collections
+// back into plain JSON types
+func normalizeSnapshot(snapshot *models.Snapshot) {
+	for i := range snapshot.Collections {
+		normalizeRules(snapshot.Collections[i].Rules)
+	}
+}
+
+func (s *SnapshotService) authorize(projectID, userID, minRole string) (primitive.ObjectID, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return pid, errors.New("invalid project id")
+	}
+
+	_, err = s.access.authorizeProject(pid, userID, minRole)
+	return pid, err
+}
+
+// snapshotTargets picks the snapshot collections to restore, by name or id;
+// no names means all of them
+func snapshotTargets(snapshot *models.Snapshot, names []string) ([]models.Collection, error) {
+	if len(names) == 0 {
+		return snapshot.Collections, nil
+	}
+
+	var targets []models.Collection
+	for _, name := range names {
+		i := slices.IndexFunc(snapshot.Collections, func(c models.Collection) bool {
+			return c.Name == name || c.ID.Hex() == name
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("collection %q is not part of this snapshot", name)
+		}
+		if !slices.ContainsFunc(targets, func(c models.Collection) bool { return c.ID == snapshot.Collections[i].ID }) {
+			targets = append(targets, snapshot.Collections[i])
+		}
+	}
+	return targets, nil
+}
+
+// replacedCollections returns the current collections a restore overwrites:
+// those with the id or the name of a restored one, and on full restores
+// every collection created after the snapshot
+func replacedCollections(current, targets []models.Collection, full bool) []models.Collection {
+	var replaced []models.Collection
+	for _, collection := range current {
+		if full || slices.ContainsFunc(targets, func(c models.Collection) bool {
+			return c.ID == collection.ID || c.Name == collection.Name
+		}) {
+			replaced = append(replaced, collection)
+		}
+	}
+	return replaced
+}
+
+func newCollectionDiff(collection *models.Collection) dtos.CollectionDiff {
+	return dtos.CollectionDiff{
+		CollectionID:   collection.ID.Hex(),
+		Name:           collection.Name,
+		Status:         diffUnchanged,
+		RecordsCreated: []string{},
+		RecordsUpdated: []string{},
+		RecordsDeleted: []string{},
+	}
+}
+
+// diffRecords fills the record changes of a collection since the snapshot
+func diffRecords(entry *dtos.CollectionDiff, saved, current []models.Record) {
+	before := map[primitive.ObjectID]*models.Record{}
+	for i := range saved {
+		before[saved[i].ID] = &saved[i]
+	}
+
+	for i := range current {
+		record := &current[i]
+		old, existed := before[record.ID]
+		switch {
+		case !existed:
+			entry.RecordsCreated = append(entry.RecordsCreated, record.ID.Hex())
+		case len(diffFields(old.Data, record.Data)) > 0:
+			entry.RecordsUpdated = append(entry.RecordsUpdated, record.ID.Hex())
+		}
+		delete(before, record.ID)
+	}
+
+	for _, record := range saved {
+		if _, deleted := before[record.ID]; deleted {
+			entry.RecordsDeleted = append(entry.RecordsDeleted, record.ID.Hex())
+		}
+	}
+}
+
+func recordsOf(records []models.Record, collectionID primitive.ObjectID) []models.Record {
+	var matched []models.Record
+	for _, record := range records {
+		if record.CollectionID == collectionID {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+func recordIDs(records []models.Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID.Hex())
+	}
+	return ids
+}
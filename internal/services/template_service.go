@@ -0,0 +1,610 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxUserTemplates = 50
+
+// objectIDPattern finds ids inside strings, e.g. a record id stored in
+// another record or a mock URL in a response body
+var objectIDPattern = regexp.MustCompile(`\b[0-9a-f]{24}\b`)
+
+// blueprint is the content a project is built from: its environments,
+// collections, custom endpoints and, when data is copied, records. ownerID is
+// the project or template the ids belong to.
+type blueprint struct {
+	ownerID      primitive.ObjectID
+	environments []string
+	collections  []models.Collection
+	endpoints    []models.Endpoint
+	records      []models.Record
+}
+
+// remap gives every collection, endpoint and record a new id under owner and
+// rewrites the references to the old ids, wherever they appear. Collections
+// and records listed in existing take the id they are mapped to instead.
+func (b *blueprint) remap(owner primitive.ObjectID, existing map[primitive.ObjectID]primitive.ObjectID) *blueprint {
+	ids := map[string]string{b.ownerID.Hex(): owner.Hex()}
+	for _, c := range b.collections {
+		if id, ok := existing[c.ID]; ok {
+			ids[c.ID.Hex()] = id.Hex()
+			continue
+		}
+		ids[c.ID.Hex()] = primitive.NewObjectID().Hex()
+	}
+	for _, e := range b.endpoints {
+		ids[e.ID.Hex()] = primitive.NewObjectID().Hex()
+	}
+	for _, r := range b.records {
+		if id, ok := existing[r.ID]; ok {
+			ids[r.ID.Hex()] = id.Hex()
+			continue
+		}
+		ids[r.ID.Hex()] = primitive.NewObjectID().Hex()
+	}
+	newID := func(old primitive.ObjectID) primitive.ObjectID {
+		id, _ := primitive.ObjectIDFromHex(ids[old.Hex()])
+		return id
+	}
+	now := time.Now()
+
+	out := &blueprint{ownerID: owner, environments: slices.Clone(b.environments)}
+	for _, c := range b.collections {
+		fields := slices.Clone(c.Fields)
+		for i := range fields {
+			fields[i].Default = remapIDs(utils.NormalizeBSON(fields[i].Default), ids)
+		}
+		out.collections = append(out.collections, models.Collection{
+			ID:            newID(c.ID),
+			ProjectID:     owner,
+			Name:          c.Name,
+			Fields:        fields,
+			Rules:         remapRules(c.Rules, ids),
+			RevisionLimit: c.RevisionLimit,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	for _, e := range b.endpoints {
+		out.endpoints = append(out.endpoints, models.Endpoint{
+			ID:          newID(e.ID),
+			ProjectID:   owner,
+			Method:      e.Method,
+			Path:        remapIDs(e.Path, ids).(string),
+			Description: e.Description,
+			Rules:       remapRules(e.Rules, ids),
+			Response:    remapResponse(e.Response, ids),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	for _, r := range b.records {
+		data, _ := remapIDs(utils.NormalizeBSON(r.Data), ids).(map[string]any)
+		// Records keep their timestamps, so copies list in the same order
+		record := models.Record{
+			ID:           newID(r.ID),
+			CollectionID: newID(r.CollectionID),
+			Data:         data,
+			Version:      1,
+			Environment:  r.Environment,
+			CreatedAt:    r.CreatedAt,
+			UpdatedAt:    r.UpdatedAt,
+		}
+		if r.PromotedFrom != nil {
+			if _, copied := ids[r.PromotedFrom.Hex()]; copied {
+				promotedFrom := newID(*r.PromotedFrom)
+				record.PromotedFrom = &promotedFrom
+			}
+		}
+		if record.CreatedAt.IsZero() {
+			record.CreatedAt, record.UpdatedAt = now, now
+		}
+		out.records = append(out.records, record)
+	}
+
+	return out
+}
+
+// remapIDs replaces the ids found in a JSON value through ids
+func remapIDs(v any, ids map[string]string) any {
+	switch val := v.(type) {
+	case string:
+		return objectIDPattern.ReplaceAllStringFunc(val, func(id string) string {
+			if mapped, ok := ids[id]; ok {
+				return mapped
+			}
+			return id
+		})
+	case primitive.ObjectID:
+		if mapped, ok := ids[val.Hex()]; ok {
+			id, _ := primitive.ObjectIDFromHex(mapped)
+			return id
+		}
+		return val
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = remapIDs(item, ids)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = remapIDs(item, ids)
+		}
+		return out
+	}
+	return v
+}
+
+func remapRules(rules []models.ResponseRule, ids map[string]string) []models.ResponseRule {
+	if rules == nil {
+		return nil
+	}
+	out := make([]models.ResponseRule, len(rules))
+	for i, rule := range rules {
+		conditions := slices.Clone(rule.Conditions)
+		for j := range conditions {
+			conditions[j].Value = remapIDs(utils.NormalizeBSON(conditions[j].Value), ids)
+		}
+		out[i] = models.ResponseRule{Name: rule.Name, Conditions: conditions, Response: remapResponse(rule.Response, ids)}
+	}
+	return out
+}
+
+func remapResponse(response models.MockResponse, ids map[string]string) models.MockResponse {
+	var headers map[string]string
+	if response.Headers != nil {
+		headers = make(map[string]string, len(response.Headers))
+		for k, v := range response.Headers {
+			headers[k] = remapIDs(v, ids).(string)
+		}
+	}
+	return models.MockResponse{
+		Status:  response.Status,
+		Headers: headers,
+		Body:    remapIDs(utils.NormalizeBSON(response.Body), ids),
+		DelayMs: response.DelayMs,
+	}
+}
+
+// TemplateService duplicates projects and publishes them as templates that
+// new projects can be created from.
+type TemplateService struct {
+	coll           store.Repository
+	recordCopyColl store.Repository
+	projectColl    store.Repository
+	collectionColl store.Repository
+	recordColl     store.Repository
+	endpointColl   store.Repository
+	orgColl        store.Repository
+	userColl       store.Repository
+	projects       *ProjectService
+	access         *projectAccess
+	audit          *auditLog
+	ctx            context.Context
+	cfg            *config.Config
+}
+
+func NewTemplateService(db store.Store, cfg *config.Config, projects *ProjectService) *TemplateService {
+	return &TemplateService{
+		coll:           db.Repository(database.Collections.Templates),
+		recordCopyColl: db.Repository(database.Collections.TplRecords),
+		projectColl:    db.Repository(database.Collections.Projects),
+		collectionColl: db.Repository(database.Collections.Collection),
+		recordColl:     db.Repository(database.Collections.Records),
+		endpointColl:   db.Repository(database.Collections.Endpoints),
+		orgColl:        db.Repository(database.Collections.Organizations),
+		userColl:       db.Repository(database.Collections.Users),
+		projects:       projects,
+		access:         newProjectAccess(db),
+		audit:          newAuditLog(db),
+		ctx:            context.Background(),
+		cfg:            cfg,
+	}
+}
+
+// DuplicateProject creates a project with the collections and custom
+// endpoints of another one, and its records when req.IncludeData is set. The
+// copy goes to the caller's personal space, or to an organization when orgID
+// is set.
+func (s *TemplateService) DuplicateProject(projectID string, actor dtos.Actor, orgID string, req *dtos.DuplicateProjectRequest) (*models.Project, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	source, err := s.access.authorizeProject(pid, actor.UserID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := s.projectBlueprint(source, req.IncludeData)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = source.Name + " (copy)"
+	}
+	return s.instantiate(actor, orgID, name, req.Description, content)
+}
+
+// PublishTemplate copies a project into a new template. Org templates must be
+// published from a project of that organization.
+func (s *TemplateService) PublishTemplate(projectID string, actor dtos.Actor, req *dtos.PublishTemplateRequest) (*models.Template, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	project, err := s.access.authorizeProject(pid, actor.UserID, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.TemplatePrivate
+	}
+	if !slices.Contains(models.TemplateVisibilities, visibility) {
+		return nil, fmt.Errorf("visibility must be one of %v", models.TemplateVisibilities)
+	}
+	if visibility == models.TemplateOrg && project.OrgID == nil {
+		return nil, errors.New("org templates must be published from an organization project")
+	}
+
+	count, err := s.coll.CountDocuments(s.ctx, bson.M{"createdBy": uid})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxUserTemplates {
+		return nil, fmt.Errorf("you can keep at most %d templates, delete an old one first", maxUserTemplates)
+	}
+
+	content, err := s.projectBlueprint(project, req.IncludeData)
+	if err != nil {
+		return nil, err
+	}
+
+	// The template gets ids of its own, so it does not expose the project's
+	template := &models.Template{
+		ID:              primitive.NewObjectID(),
+		Name:            req.Name,
+		Description:     req.Description,
+		Visibility:      visibility,
+		SourceProjectID: pid,
+		CreatedBy:       uid,
+		CreatedAt:       time.Now(),
+	}
+	if visibility == models.TemplateOrg {
+		template.OrgID = project.OrgID
+	}
+	content = content.remap(template.ID, nil)
+	template.Environments = content.environments
+	template.Collections = content.collections
+	template.Endpoints = content.endpoints
+	template.RecordCount = len(content.records)
+	if template.Collections == nil {
+		template.Collections = []models.Collection{}
+	}
+	if template.Endpoints == nil {
+		template.Endpoints = []models.Endpoint{}
+	}
+
+	copies := make([]any, 0, len(content.records))
+	for _, record := range content.records {
+		copies = append(copies, models.TemplateRecord{
+			ID:         primitive.NewObjectID(),
+			TemplateID: template.ID,
+			Record:     record,
+		})
+	}
+	if len(copies) > 0 {
+		if err := s.recordCopyColl.InsertMany(s.ctx, copies); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.coll.InsertOne(s.ctx, template); err != nil {
+		_, _ = s.recordCopyColl.DeleteMany(s.ctx, bson.M{"templateId": template.ID})
+		return nil, err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditTemplatePublish,
+		TargetType: "template",
+		TargetID:   template.ID.Hex(),
+		ProjectID:  &pid,
+		Changes:    diffFields(nil, map[string]any{"name": template.Name, "visibility": template.Visibility, "records": template.RecordCount}),
+	})
+
+	return template, nil
+}
+
+// ListTemplates returns the templates the user can use: their own, those of
+// their organizations and the public ones, newest first
+func (s *TemplateService) ListTemplates(userID string) ([]models.Template, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	var orgs []models.Organization
+	if err := s.orgColl.Find(s.ctx, bson.M{"members.userId": uid}, &orgs); err != nil {
+		return nil, err
+	}
+	orgIDs := bson.A{}
+	for _, org := range orgs {
+		orgIDs = append(orgIDs, org.ID)
+	}
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"createdBy": uid},
+		bson.M{"visibility": models.TemplatePublic},
+		bson.M{"visibility": models.TemplateOrg, "orgId": bson.M{"$in": orgIDs}},
+	}}
+	templates := []models.Template{}
+	if err := s.coll.Find(s.ctx, filter, &templates, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}); err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		normalizeTemplate(&templates[i])
+	}
+
+	return templates, nil
+}
+
+// GetTemplate returns a template the user can use
+func (s *TemplateService) GetTemplate(templateID, userID string) (*models.Template, error) {
+	return s.findTemplate(templateID, userID)
+}
+
+// DeleteTemplate removes a template and its records. Only its author can
+// delete it; projects created from it are not affected.
+func (s *TemplateService) DeleteTemplate(templateID string, actor dtos.Actor) error {
+	template, err := s.findTemplate(templateID, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if template.CreatedBy.Hex() != actor.UserID {
+		return forbiddenError("only the author of a template can delete it")
+	}
+
+	deleted, err := s.coll.DeleteOne(s.ctx, bson.M{"_id": template.ID})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return notFoundError("template not found")
+	}
+	if _, err := s.recordCopyColl.DeleteMany(s.ctx, bson.M{"templateId": template.ID}); err != nil {
+		return err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditTemplateDelete,
+		TargetType: "template",
+		TargetID:   template.ID.Hex(),
+		ProjectID:  &template.SourceProjectID,
+		Changes:    diffFields(map[string]any{"name": template.Name, "visibility": template.Visibility}, nil),
+	})
+
+	return nil
+}
+
+// InstantiateTemplate creates a project from a template, in the caller's
+// personal space or in an organization when orgID is set
+func (s *TemplateService) InstantiateTemplate(templateID string, actor dtos.Actor, orgID string, req *dtos.InstantiateTemplateRequest) (*models.Project, error) {
+	template, err := s.findTemplate(templateID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var copies []models.TemplateRecord
+	if err := s.recordCopyColl.Find(s.ctx, bson.M{"templateId": template.ID}, &copies); err != nil {
+		return nil, err
+	}
+	content := &blueprint{ownerID: template.ID, environments: template.Environments, collections: template.Collections, endpoints: template.Endpoints}
+	for _, saved := range copies {
+		content.records = append(content.records, saved.Record)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = template.Name
+	}
+	description := req.Description
+	if description == "" {
+		description = template.Description
+	}
+	return s.instantiate(actor, orgID, name, description, content)
+}
+
+// instantiate creates a project through ProjectService, so the plan's project
+// limit applies, and fills it with a remapped copy of content
+func (s *TemplateService) instantiate(actor dtos.Actor, orgID, name, description string, content *blueprint) (*models.Project, error) {
+	plan, err := s.targetPlan(actor.UserID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if plan != PlanPro && len(content.collections) > freeCollectionLimit {
+		return nil, fmt.Errorf("free plans can only have %d collections per project and this one has %d — upgrade to copy it", freeCollectionLimit, len(content.collections))
+	}
+
+	project, err := s.projects.CreateProject(actor, orgID, name, description)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fill(content.remap(project.ID, nil)); err != nil {
+		s.discard(project.ID)
+		return nil, err
+	}
+	if len(content.environments) > 0 {
+		if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, bson.M{"$set": bson.M{"environments": content.environments}}); err != nil {
+			s.discard(project.ID)
+			return nil, err
+		}
+		project.Environments = content.environments
+	}
+
+	return project, nil
+}
+
+// fill inserts the content of a new project
+func (s *TemplateService) fill(content *blueprint) error {
+	inserts := []struct {
+		repo store.Repository
+		docs []any
+	}{
+		{s.collectionColl, toDocs(content.collections)},
+		{s.endpointColl, toDocs(content.endpoints)},
+		{s.recordColl, toDocs(content.records)},
+	}
+	for _, insert := range inserts {
+		if len(insert.docs) == 0 {
+			continue
+		}
+		if err := insert.repo.InsertMany(s.ctx, insert.docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discard removes a project whose copy failed halfway
+func (s *TemplateService) discard(pid primitive.ObjectID) {
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, bson.M{"projectId": pid}, &collections); err == nil {
+		for _, collection := range collections {
+			_, _ = s.recordColl.DeleteMany(s.ctx, bson.M{"collectionId": collection.ID})
+		}
+	}
+	_, _ = s.collectionColl.DeleteMany(s.ctx, bson.M{"projectId": pid})
+	_, _ = s.endpointColl.DeleteMany(s.ctx, bson.M{"projectId": pid})
+	_, _ = s.projectColl.DeleteOne(s.ctx, bson.M{"_id": pid})
+}
+
+// targetPlan resolves the plan the new project will be limited by, the way
+// ProjectService.CreateProject does
+func (s *TemplateService) targetPlan(userID, orgID string) (string, error) {
+	if orgID != "" {
+		org, _, err := authorizeOrg(s.orgColl, orgID, userID, models.OrgRoleMember)
+		if err != nil {
+			return "", err
+		}
+		return org.Plan, nil
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", errors.New("invalid user id")
+	}
+	var user models.User
+	if err := s.userColl.FindOne(s.ctx, bson.M{"_id": uid}, &user); err != nil {
+		return "", errors.New("user not found")
+	}
+	if user.IsUpgraded {
+		return PlanPro, nil
+	}
+	return PlanFree, nil
+}
+
+// projectBlueprint loads the environments, live collections and custom
+// endpoints of a project, and its live records in every environment when
+// withData is set
+func (s *TemplateService) projectBlueprint(project *models.Project, withData bool) (*blueprint, error) {
+	pid := project.ID
+	content := &blueprint{ownerID: pid, environments: slices.Clone(project.Environments)}
+	if err := s.collectionColl.Find(s.ctx, notDeleted(bson.M{"projectId": pid}), &content.collections, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+		return nil, err
+	}
+	if err := s.endpointColl.Find(s.ctx, bson.M{"projectId": pid}, &content.endpoints, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+		return nil, err
+	}
+	if !withData || len(content.collections) == 0 {
+		return content, nil
+	}
+
+	ids := bson.A{}
+	for _, collection := range content.collections {
+		ids = append(ids, collection.ID)
+	}
+	if err := s.recordColl.Find(s.ctx, notDeleted(bson.M{"collectionId": bson.M{"$in": ids}}), &content.records, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// findTemplate loads a template the user can use. Others' private templates
+// and templates of other organizations are not found.
+func (s *TemplateService) findTemplate(templateID, userID string) (*models.Template, error) {
+	tid, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, errors.New("invalid template id")
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	var template models.Template
+	if err := s.coll.FindOne(s.ctx, bson.M{"_id": tid}, &template); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("template not found")
+		}
+		return nil, err
+	}
+
+	visible := template.CreatedBy == uid || template.Visibility == models.TemplatePublic
+	if !visible && template.Visibility == models.TemplateOrg && template.OrgID != nil {
+		var org models.Organization
+		if err := s.orgColl.FindOne(s.ctx, bson.M{"_id": *template.OrgID}, &org); err == nil {
+			visible = orgRole(&org, uid) != ""
+		}
+	}
+	if !visible {
+		return nil, notFoundError("template not found")
+	}
+	normalizeTemplate(&template)
+
+	return &template, nil
+}
+
+// normalizeTemplate turns BSON-decoded values of a template back into plain JSON types
+func normalizeTemplate(template *models.Template) {
+	for i := range template.Collections {
+		normalizeRules(template.Collections[i].Rules)
+	}
+	for i := range template.Endpoints {
+		normalizeEndpoint(&template.Endpoints[i])
+	}
+}
+
+func toDocs[T any](items []T) []any {
+	docs := make([]any, 0, len(items))
+	for _, item := range items {
+		docs = append(docs, item)
+	}
+	return docs
+}
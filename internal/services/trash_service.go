@@ -0,0 +1,452 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// inTrash matches soft-deleted documents
+var inTrash = bson.M{"$exists": true}
+
+// restoreUpdate takes a document out of the trash
+var restoreUpdate = bson.M{"$unset": bson.M{"deletedAt": "", "deletedWith": ""}}
+
+// TrashService lists and restores deleted projects, collections and records,
+// and purges them for good once the trash retention has passed.
+type TrashService struct {
+	projectColl    store.Repository
+	collectionColl store.Repository
+	recordColl     store.Repository
+	revisionColl   store.Repository
+	orgColl        store.Repository
+	// projectData holds what else belongs to a project, by projectId
+	projectData []store.Repository
+	access      *projectAccess
+	audit       *auditLog
+	webhooks    *webhookDispatcher
+	ctx         context.Context
+	cfg         *config.Config
+}
+
+func NewTrashService(db store.Store, cfg *config.Config) *TrashService {
+	return &TrashService{
+		projectColl:    db.Repository(database.Collections.Projects),
+		collectionColl: db.Repository(database.Collections.Collection),
+		recordColl:     db.Repository(database.Collections.Records),
+		revisionColl:   db.Repository(database.Collections.Revisions),
+		orgColl:        db.Repository(database.Collections.Organizations),
+		projectData: []store.Repository{
+			db.Repository(database.Collections.Endpoints),
+			db.Repository(database.Collections.APIKeys),
+			db.Repository(database.Collections.Invitations),
+			db.Repository(database.Collections.Webhooks),
+			db.Repository(database.Collections.Deliveries),
+			db.Repository(database.Collections.Snapshots),
+			db.Repository(database.Collections.SnapRecords),
+			db.Repository(database.Collections.Revisions),
+			db.Repository(database.Collections.Violations),
+		},
+		access:   newProjectAccess(db),
+		audit:    newAuditLog(db),
+		webhooks: newWebhookDispatcher(db),
+		ctx:      context.Background(),
+		cfg:      cfg,
+	}
+}
+
+// ListProjects returns the deleted projects the user owns, or the deleted
+// projects of an organization when orgID is set
+func (s *TrashService) ListProjects(userID, orgID string) ([]models.Project, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	filter := memberFilter(uid)
+	if orgID != "" {
+		org, _, err := authorizeOrg(s.orgColl, orgID, userID, models.OrgRoleMember)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"orgId": org.ID}
+	}
+	filter["deletedAt"] = inTrash
+
+	var candidates []models.Project
+	if err := s.projectColl.Find(s.ctx, filter, &candidates, store.FindOptions{Sort: bson.D{{Key: "deletedAt", Value: -1}}}); err != nil {
+		return nil, err
+	}
+
+	projects := []models.Project{}
+	for _, project := range candidates {
+		if s.access.role(&project, uid) == models.RoleOwner {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects, nil
+}
+
+// RestoreProject brings a deleted project back with the collections and
+// records that were deleted with it. Only owners can restore a project.
+func (s *TrashService) RestoreProject(projectID string, actor dtos.Actor) (*models.Project, error) {
+	project, err := s.trashedProject(projectID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectLimit(project); err != nil {
+		return nil, err
+	}
+
+	matched, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID, "deletedAt": inTrash}, bson.M{"$unset": bson.M{"deletedAt": ""}})
+	if err != nil {
+		return nil, err
+	}
+	if matched == 0 {
+		return nil, notFoundError("project not found in the trash")
+	}
+	project.DeletedAt = nil
+
+	if _, err := s.recordColl.UpdateMany(s.ctx, bson.M{"deletedWith": project.ID}, restoreUpdate); err != nil {
+		return nil, err
+	}
+	if _, err := s.collectionColl.UpdateMany(s.ctx, bson.M{"deletedWith": project.ID}, restoreUpdate); err != nil {
+		return nil, err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditProjectRestore,
+		TargetType: "project",
+		TargetID:   project.ID.Hex(),
+		ProjectID:  &project.ID,
+	})
+
+	return project, nil
+}
+
+// ProjectTrash lists the deleted collections of a project and the records
+// deleted on their own from its live collections
+func (s *TrashService) ProjectTrash(projectID, userID string) (*dtos.ProjectTrash, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	if _, err := s.access.authorizeProject(pid, userID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	trash := &dtos.ProjectTrash{Collections: []models.Collection{}, Records: []models.Record{}}
+	byDeletion := store.FindOptions{Sort: bson.D{{Key: "deletedAt", Value: -1}}}
+	if err := s.collectionColl.Find(s.ctx, bson.M{"projectId": pid, "deletedAt": inTrash}, &trash.Collections, byDeletion); err != nil {
+		return nil, err
+	}
+
+	var live []models.Collection
+	if err := s.collectionColl.Find(s.ctx, notDeleted(bson.M{"projectId": pid}), &live); err != nil {
+		return nil, err
+	}
+	if len(live) == 0 {
+		return trash, nil
+	}
+	ids := bson.A{}
+	for _, collection := range live {
+		ids = append(ids, collection.ID)
+	}
+	filter := bson.M{"collectionId": bson.M{"$in": ids}, "deletedAt": inTrash, "deletedWith": bson.M{"$exists": false}}
+	if err := s.recordColl.Find(s.ctx, filter, &trash.Records, byDeletion); err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+// RestoreCollection brings a deleted collection back with the records that
+// were deleted with it
+func (s *TrashService) RestoreCollection(projectID, collectionID string, actor dtos.Actor) (*models.Collection, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	cid, err := primitive.ObjectIDFromHex(collectionID)
+	if err != nil {
+		return nil, errors.New("invalid collection id")
+	}
+	project, err := s.access.authorizeProject(pid, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	var collection models.Collection
+	if err := s.collectionColl.FindOne(s.ctx, bson.M{"_id": cid, "projectId": pid, "deletedAt": inTrash}, &collection); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("collection not found in the trash")
+		}
+		return nil, err
+	}
+
+	// Mock routes address collections by name
+	taken, err := s.collectionColl.CountDocuments(s.ctx, notDeleted(bson.M{"projectId": pid, "name": collection.Name}))
+	if err != nil {
+		return nil, err
+	}
+	if taken > 0 {
+		return nil, conflictError(fmt.Sprintf("a collection named %q already exists in this project", collection.Name))
+	}
+	count, err := s.collectionColl.CountDocuments(s.ctx, notDeleted(bson.M{"projectId": pid}))
+	if err != nil {
+		return nil, err
+	}
+	if s.access.plan(project) != PlanPro && count >= freeCollectionLimit {
+		return nil, fmt.Errorf("free plans can only have %d collections per project — delete one or upgrade to restore this one", freeCollectionLimit)
+	}
+
+	matched, err := s.collectionColl.UpdateOne(s.ctx, bson.M{"_id": cid, "deletedAt": inTrash}, restoreUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if matched == 0 {
+		return nil, notFoundError("collection not found in the trash")
+	}
+	collection.DeletedAt, collection.DeletedWith = nil, nil
+
+	if _, err := s.recordColl.UpdateMany(s.ctx, bson.M{"deletedWith": cid}, restoreUpdate); err != nil {
+		return nil, err
+	}
+	normalizeRules(collection.Rules)
+	invalidateCollectionCache(&collection)
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditCollectionRestore,
+		TargetType: "collection",
+		TargetID:   collection.ID.Hex(),
+		ProjectID:  &collection.ProjectID,
+	})
+
+	return &collection, nil
+}
+
+// RestoreRecord brings back a record deleted on its own. A record deleted
+// with its collection comes back by restoring the collection.
+func (s *TrashService) RestoreRecord(projectID, recordID string, actor dtos.Actor) (*models.Record, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	rid, err := primitive.ObjectIDFromHex(recordID)
+	if err != nil {
+		return nil, errors.New("invalid record id")
+	}
+	if _, err := s.access.authorizeProject(pid, actor.UserID, models.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var record models.Record
+	if err := s.recordColl.FindOne(s.ctx, bson.M{"_id": rid, "deletedAt": inTrash}, &record); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("record not found in the trash")
+		}
+		return nil, err
+	}
+
+	var collection models.Collection
+	if err := s.collectionColl.FindOne(s.ctx, bson.M{"_id": record.CollectionID, "projectId": pid}, &collection); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("record not found in the trash")
+		}
+		return nil, err
+	}
+	if collection.DeletedAt != nil {
+		return nil, conflictError("the collection of this record is in the trash, restore the collection instead")
+	}
+
+	matched, err := s.recordColl.UpdateOne(s.ctx, bson.M{"_id": rid, "deletedAt": inTrash}, restoreUpdate)
+	if err != nil {
+		return nil, err
+	}
+	if matched == 0 {
+		return nil, notFoundError("record not found in the trash")
+	}
+	record.DeletedAt, record.DeletedWith = nil, nil
+	invalidateRecordCache(&collection)
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditRecordRestore,
+		TargetType: "record",
+		TargetID:   record.ID.Hex(),
+		ProjectID:  &collection.ProjectID,
+	})
+	s.webhooks.emit(collection.ProjectID, models.WebhookRecordCreated, recordEventData(&collection, &record, nil))
+	publishRecordChange(models.WebhookRecordCreated, &collection, &record)
+
+	return &record, nil
+}
+
+// trashedProject loads a deleted project the user owns
+func (s *TrashService) trashedProject(projectID, userID string) (*models.Project, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	var project models.Project
+	if err := s.projectColl.FindOne(s.ctx, bson.M{"_id": pid, "deletedAt": inTrash}, &project); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("project not found in the trash")
+		}
+		return nil, err
+	}
+
+	role := s.access.role(&project, uid)
+	if role == "" {
+		return nil, notFoundError("project not found in the trash")
+	}
+	if role != models.RoleOwner {
+		return nil, errInsufficientRole
+	}
+
+	return &project, nil
+}
+
+// checkProjectLimit keeps a restore within the project limit of free plans
+func (s *TrashService) checkProjectLimit(project *models.Project) error {
+	if s.access.plan(project) == PlanPro {
+		return nil
+	}
+
+	filter := bson.M{"userId": project.UserID, "orgId": bson.M{"$exists": false}}
+	if project.OrgID != nil {
+		filter = bson.M{"orgId": *project.OrgID}
+	}
+	count, err := s.projectColl.CountDocuments(s.ctx, notDeleted(filter))
+	if err != nil {
+		return err
+	}
+	if count >= freeProjectLimit {
+		return fmt.Errorf("upgrade required — free plans can only have %d projects, delete one to restore this one", freeProjectLimit)
+	}
+	return nil
+}
+
+// StartPurge periodically deletes for good what has been in the trash
+// longer than the retention, until ctx is cancelled
+func (s *TrashService) StartPurge(ctx context.Context, interval time.Duration) {
+	if s.cfg.TrashRetention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeExpired()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *TrashService) purgeExpired() {
+	expired := bson.M{"deletedAt": bson.M{"$lt": time.Now().Add(-s.cfg.TrashRetention)}}
+
+	var projects []models.Project
+	if err := s.projectColl.Find(s.ctx, expired, &projects); err != nil {
+		log.Printf("[TRASH] Failed to find expired projects: %v", err)
+		return
+	}
+	for _, project := range projects {
+		if err := s.purgeProject(project.ID); err != nil {
+			log.Printf("[TRASH] Failed to purge project %s: %v", project.ID.Hex(), err)
+		}
+	}
+
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, expired, &collections); err != nil {
+		log.Printf("[TRASH] Failed to find expired collections: %v", err)
+		return
+	}
+	for _, collection := range collections {
+		if err := s.purgeCollection(collection.ID); err != nil {
+			log.Printf("[TRASH] Failed to purge collection %s: %v", collection.ID.Hex(), err)
+		}
+	}
+
+	var records []models.Record
+	if err := s.recordColl.Find(s.ctx, expired, &records); err != nil {
+		log.Printf("[TRASH] Failed to find expired records: %v", err)
+		return
+	}
+	ids := bson.A{}
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	if len(ids) > 0 {
+		if _, err := s.revisionColl.DeleteMany(s.ctx, bson.M{"recordId": bson.M{"$in": ids}}); err != nil {
+			log.Printf("[TRASH] Failed to purge record revisions: %v", err)
+			return
+		}
+		if _, err := s.recordColl.DeleteMany(s.ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+			log.Printf("[TRASH] Failed to purge records: %v", err)
+			return
+		}
+	}
+
+	if total := len(projects) + len(collections) + len(records); total > 0 {
+		log.Printf("[TRASH] Purged %d projects, %d collections and %d records older than %v",
+			len(projects), len(collections), len(records), s.cfg.TrashRetention)
+	}
+}
+
+// purgeProject deletes a project with everything that belongs to it. The
+// project goes last so a failed purge is retried on the next run.
+func (s *TrashService) purgeProject(pid primitive.ObjectID) error {
+	var collections []models.Collection
+	if err := s.collectionColl.Find(s.ctx, bson.M{"projectId": pid}, &collections); err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		if err := s.purgeCollection(collection.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, repo := range s.projectData {
+		if _, err := repo.DeleteMany(s.ctx, bson.M{"projectId": pid}); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.projectColl.DeleteOne(s.ctx, bson.M{"_id": pid})
+	return err
+}
+
+// purgeCollection deletes a collection with its records and their revisions
+func (s *TrashService) purgeCollection(cid primitive.ObjectID) error {
+	if _, err := s.revisionColl.DeleteMany(s.ctx, bson.M{"collectionId": cid}); err != nil {
+		return err
+	}
+	if _, err := s.recordColl.DeleteMany(s.ctx, bson.M{"collectionId": cid}); err != nil {
+		return err
+	}
+	_, err := s.collectionColl.DeleteOne(s.ctx, bson.M{"_id": cid})
+	return err
+}
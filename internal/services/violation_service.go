@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultViolationLimit = 50
+	maxViolationLimit     = 500
+	maxViolationScan      = 10000 // violations a summary reads, newest first
+	maxViolationBuckets   = 366
+)
+
+// violationLog keeps the record writes accepted in observe mode despite
+// breaking their schema. Logging is best effort, like the audit log.
+type violationLog struct {
+	coll        store.Repository
+	projectColl store.Repository
+}
+
+func newViolationLog(db store.Store) *violationLog {
+	return &violationLog{
+		coll:        db.Repository(database.Collections.Violations),
+		projectColl: db.Repository(database.Collections.Projects),
+	}
+}
+
+// observing tells whether a project accepts records that break their schema
+func (v *violationLog) observing(projectID primitive.ObjectID) bool {
+	var project models.Project
+	if err := v.projectColl.FindOne(context.Background(), bson.M{"_id": projectID}, &project); err != nil {
+		return false
+	}
+	return project.Validation == models.ValidationObserve
+}
+
+// record stores the violations of a record write, if it had any
+func (v *violationLog) record(actor dtos.Actor, collection *models.Collection, record *models.Record, operation string, violations []models.FieldViolation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	violation := &models.Violation{
+		ID:           primitive.NewObjectID(),
+		ProjectID:    collection.ProjectID,
+		CollectionID: collection.ID,
+		Collection:   collection.Name,
+		RecordID:     record.ID,
+		Environment:  record.Environment,
+		Operation:    operation,
+		Violations:   violations,
+		Payload:      record.Data,
+		APIKeyID:     actor.APIKeyID,
+		IP:           actor.IP,
+		UserAgent:    actor.UserAgent,
+		CreatedAt:    time.Now(),
+	}
+	if uid, err := primitive.ObjectIDFromHex(actor.UserID); err == nil {
+		violation.ActorID = &uid
+	}
+
+	if err := v.coll.InsertOne(context.Background(), violation); err != nil {
+		log.Printf("[VALIDATION] Failed to log %d violations of record %s: %v", len(violations), record.ID.Hex(), err)
+	}
+}
+
+// ValidationService switches projects between enforcing and observing their
+// collection schemas, and reports the violations observed.
+type ValidationService struct {
+	coll        store.Repository
+	projectColl store.Repository
+	access      *projectAccess
+	audit       *auditLog
+	ctx         context.Context
+	cfg         *config.Config
+}
+
+func NewValidationService(db store.Store, cfg *config.Config) *ValidationService {
+	return &ValidationService{
+		coll:        db.Repository(database.Collections.Violations),
+		projectColl: db.Repository(database.Collections.Projects),
+		access:      newProjectAccess(db),
+		audit:       newAuditLog(db),
+		ctx:         context.Background(),
+		cfg:         cfg,
+	}
+}
+
+// GetValidation returns the validation mode of a project
+func (s *ValidationService) GetValidation(projectID, userID string) (*dtos.ValidationSettings, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.ValidationSettings{Mode: validationMode(project)}, nil
+}
+
+// SetValidation switches a project between enforce and observe mode
+func (s *ValidationService) SetValidation(projectID string, actor dtos.Actor, input *dtos.ValidationRequest) (*dtos.ValidationSettings, error) {
+	project, err := s.authorize(projectID, actor.UserID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	before := validationMode(project)
+	update := bson.M{"$set": bson.M{"validation": input.Mode, "updatedAt": time.Now()}}
+	if input.Mode == models.ValidationEnforce {
+		// Enforce is the default and is not stored
+		update = bson.M{"$unset": bson.M{"validation": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	if _, err := s.projectColl.UpdateOne(s.ctx, bson.M{"_id": project.ID}, update); err != nil {
+		return nil, err
+	}
+
+	s.audit.record(actor, &models.AuditEvent{
+		Action:     models.AuditValidationUpdate,
+		TargetType: "project",
+		TargetID:   project.ID.Hex(),
+		ProjectID:  &project.ID,
+		Changes:    diffFields(map[string]any{"validation": before}, map[string]any{"validation": input.Mode}),
+	})
+
+	return &dtos.ValidationSettings{Mode: input.Mode}, nil
+}
+
+// ListViolations returns the violations logged for a project, newest first
+func (s *ValidationService) ListViolations(projectID, userID string, query *dtos.ViolationQuery) ([]models.Violation, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := violationFilter(project.ID, query.Collection, query.From, query.To)
+	if query.Field != "" {
+		filter["violations.field"] = query.Field
+	}
+	if query.Rule != "" {
+		filter["violations.rule"] = query.Rule
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultViolationLimit
+	}
+	limit = min(limit, maxViolationLimit)
+
+	violations := []models.Violation{}
+	err = s.coll.Find(s.ctx, filter, &violations, store.FindOptions{
+		Sort:  bson.D{{Key: "createdAt", Value: -1}},
+		Skip:  int64(max(query.Skip, 0)),
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range violations {
+		if payload, ok := utils.NormalizeBSON(violations[i].Payload).(map[string]any); ok {
+			violations[i].Payload = payload
+		}
+	}
+
+	return violations, nil
+}
+
+// SummarizeViolations counts the violations of a period per collection field,
+// with a series of counts per hour or day to show how the drift evolves
+func (s *ValidationService) SummarizeViolations(projectID, userID string, query *dtos.ViolationSummaryQuery) (*dtos.ViolationSummary, error) {
+	project, err := s.authorize(projectID, userID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🕰️ Step 1: Resolve the period, 30 days or 48 hours back by default
+	interval, step := "day", 24*time.Hour
+	if query.Interval == "hour" {
+		interval, step = "hour", time.Hour
+	}
+	to := time.Now().UTC()
+	if !query.To.IsZero() {
+		to = query.To.UTC()
+	}
+	from := to.Add(-30 * step)
+	if interval == "hour" {
+		from = to.Add(-48 * step)
+	}
+	if !query.From.IsZero() {
+		from = query.From.UTC()
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+	start := from.Truncate(step)
+	buckets := int(to.Sub(start)/step) + 1
+	if buckets > maxViolationBuckets {
+		return nil, fmt.Errorf("the period spans more than %d %ss, narrow it or use a larger interval", maxViolationBuckets, interval)
+	}
+
+	// 📥 Step 2: Read the violations of the period
+	var violations []models.Violation
+	err = s.coll.Find(s.ctx, violationFilter(project.ID, query.Collection, from, to), &violations, store.FindOptions{
+		Sort:  bson.D{{Key: "createdAt", Value: -1}},
+		Limit: maxViolationScan,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 🧮 Step 3: Count them per field and interval
+	summary := &dtos.ViolationSummary{From: from, To: to, Interval: interval, Writes: len(violations), Fields: []dtos.ViolationFieldSummary{}}
+	byField := map[string]*dtos.ViolationFieldSummary{}
+	for _, violation := range violations {
+		at := violation.CreatedAt.UTC()
+		bucket := int(at.Truncate(step).Sub(start) / step)
+		for _, broken := range violation.Violations {
+			key := violation.Collection + "\x00" + broken.Field
+			field, ok := byField[key]
+			if !ok {
+				field = &dtos.ViolationFieldSummary{
+					Collection: violation.Collection,
+					Field:      broken.Field,
+					Rules:      map[string]int{},
+					FirstSeen:  at,
+					LastSeen:   at,
+					Series:     make([]dtos.ViolationBucket, buckets),
+				}
+				for i := range field.Series {
+					field.Series[i].Start = start.Add(time.Duration(i) * step)
+				}
+				byField[key] = field
+			}
+			field.Total++
+			field.Rules[broken.Rule]++
+			if at.Before(field.FirstSeen) {
+				field.FirstSeen = at
+			}
+			if at.After(field.LastSeen) {
+				field.LastSeen = at
+			}
+			if bucket >= 0 && bucket < buckets {
+				field.Series[bucket].Count++
+			}
+		}
+	}
+	for _, field := range byField {
+		summary.Fields = append(summary.Fields, *field)
+	}
+	sort.Slice(summary.Fields, func(i, j int) bool {
+		a, b := summary.Fields[i], summary.Fields[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.Collection != b.Collection {
+			return a.Collection < b.Collection
+		}
+		return a.Field < b.Field
+	})
+
+	return summary, nil
+}
+
+// ClearViolations deletes the violations logged for a project, or for one of its collections
+func (s *ValidationService) ClearViolations(projectID, collection string, userID string) (int64, error) {
+	project, err := s.authorize(projectID, userID, models.RoleEditor)
+	if err != nil {
+		return 0, err
+	}
+	return s.coll.DeleteMany(s.ctx, violationFilter(project.ID, collection, time.Time{}, time.Time{}))
+}
+
+// StartRetention periodically deletes violations older than the configured
+// retention period
+func (s *ValidationService) StartRetention(ctx context.Context, interval time.Duration) {
+	if s.cfg.ViolationRetention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.purgeExpired()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *ValidationService) purgeExpired() {
+	cutoff := time.Now().Add(-s.cfg.ViolationRetention)
+	deleted, err := s.coll.DeleteMany(s.ctx, bson.M{"createdAt": bson.M{"$lt": cutoff}})
+	if err != nil {
+		log.Printf("[VALIDATION] Failed to purge violations older than %v: %v", cutoff, err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[VALIDATION] Purged %d violations older than %v", deleted, s.cfg.ViolationRetention)
+	}
+}
+
+func (s *ValidationService) authorize(projectID, userID, minRole string) (*models.Project, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, errors.New("invalid project id")
+	}
+	return s.access.authorizeProject(pid, userID, minRole)
+}
+
+// validationMode returns the validation mode of a project, enforce unless set
+func validationMode(project *models.Project) string {
+	if project.Validation == "" {
+		return models.ValidationEnforce
+	}
+	return project.Validation
+}
+
+func violationFilter(projectID primitive.ObjectID, collection string, from, to time.Time) bson.M {
+	filter := bson.M{"projectId": projectID}
+	if collection != "" {
+		filter["collection"] = collection
+	}
+	createdAt := bson.M{}
+	if !from.IsZero() {
+		createdAt["$gte"] = from
+	}
+	if !to.IsZero() {
+		createdAt["$lte"] = to
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+	return filter
+}
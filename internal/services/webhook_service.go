@@ -0,0 +1,529 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"github.com/saifwork/mock-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookTimeout      = 10 * time.Second
+	// webhookLease hides a claimed delivery from other workers while it is sent
+	webhookLease      = time.Minute
+	webhookMaxBackoff = time.Hour
+	webhookBatchSize  = 50
+	deliveryListLimit = 100
+	// webhookMaxRedirects bounds the redirects a delivery follows
+	webhookMaxRedirects = 5
+)
+
+// errPrivateWebhookURL keeps webhooks from reaching the server's own network
+var errPrivateWebhookURL = errors.New("url must not point to a loopback, link-local or private address")
+
+// Headers sent with every delivery
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookPayload is the JSON body posted to receivers
+type webhookPayload struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	ProjectID string    `json:"projectId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// webhookDispatcher queues deliveries for the services that change data. Like
+// the audit log it is best effort: a failure is logged and never fails the
+// change itself. The worker in WebhookService sends the queued deliveries.
+type webhookDispatcher struct {
+	hookColl     store.Repository
+	deliveryColl store.Repository
+}
+
+func newWebhookDispatcher(db store.Store) *webhookDispatcher {
+	return &webhookDispatcher{
+		hookColl:     db.Repository(database.Collections.Webhooks),
+		deliveryColl: db.Repository(database.Collections.Deliveries),
+	}
+}
+
+// emit queues one delivery per active webhook of the project subscribed to event
+func (d *webhookDispatcher) emit(projectID primitive.ObjectID, event string, data any) {
+	var hooks []models.Webhook
+	err := d.hookColl.Find(context.Background(), bson.M{"projectId": projectID, "active": true, "events": event}, &hooks)
+	if err != nil {
+		log.Printf("[WEBHOOK] Failed to look up webhooks for %s in project %s: %v", event, projectID.Hex(), err)
+		return
+	}
+
+	for _, hook := range hooks {
+		now := time.Now()
+		delivery := &models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     hook.ID,
+			ProjectID:     projectID,
+			Event:         event,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+
+		payload, err := json.Marshal(webhookPayload{
+			ID:        delivery.ID.Hex(),
+			Event:     event,
+			ProjectID: projectID.Hex(),
+			CreatedAt: now,
+			Data:      data,
+		})
+		if err != nil {
+			log.Printf("[WEBHOOK] Failed to encode %s payload: %v", event, err)
+			return
+		}
+		delivery.Payload = string(payload)
+
+		if err := d.deliveryColl.InsertOne(context.Background(), delivery); err != nil {
+			log.Printf("[WEBHOOK] Failed to queue %s for webhook %s: %v", event, hook.ID.Hex(), err)
+		}
+	}
+}
+
+// WebhookService lets project owners manage webhooks and runs the delivery worker.
+type WebhookService struct {
+	coll         store.Repository
+	deliveryColl store.Repository
+	access       *projectAccess
+	client       *http.Client
+	ctx          context.Context
+	cfg          *config.Config
+}
+
+func NewWebhookService(db store.Store, cfg *config.Config) *WebhookService {
+	return &WebhookService{
+		coll:         db.Repository(database.Collections.Webhooks),
+		deliveryColl: db.Repository(database.Collections.Deliveries),
+		access:       newProjectAccess(db),
+		client: &http.Client{
+			Transport: outboundTransport(webhookTimeout, cfg.WebhookAllowPrivate),
+			Timeout:   webhookTimeout,
+			// Every hop is checked like the webhook URL itself
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= webhookMaxRedirects {
+					return fmt.Errorf("stopped after %d redirects", webhookMaxRedirects)
+				}
+				if !cfg.WebhookAllowPrivate && privateURL(req.URL.String()) {
+					return errPrivateWebhookURL
+				}
+				return nil
+			},
+		},
+		ctx: context.Background(),
+		cfg: cfg,
+	}
+}
+
+// CreateWebhook registers a webhook. The signing secret is only part of this response.
+func (s *WebhookService) CreateWebhook(projectID, userID string, req *dtos.CreateWebhookRequest) (*dtos.CreateWebhookResponse, error) {
+	pid, err := s.ownedProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user id")
+	}
+
+	if err := validateWebhookURL(req.URL, s.cfg.WebhookAllowPrivate); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	hook, err := newWebhook(pid, uid, req.URL, req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.coll.InsertOne(s.ctx, hook); err != nil {
+		return nil, err
+	}
+
+	return &dtos.CreateWebhookResponse{Secret: hook.Secret, Webhook: hook}, nil
+}
+
+// ListWebhooks returns the webhooks of a project
+func (s *WebhookService) ListWebhooks(projectID, userID string) ([]models.Webhook, error) {
+	pid, err := s.ownedProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := []models.Webhook{}
+	if err := s.coll.Find(s.ctx, bson.M{"projectId": pid}, &hooks, store.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}); err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// UpdateWebhook changes the URL, events or active flag of a webhook
+func (s *WebhookService) UpdateWebhook(projectID, webhookID, userID string, req *dtos.UpdateWebhookRequest) (*models.Webhook, error) {
+	hook, err := s.findWebhook(projectID, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL, s.cfg.WebhookAllowPrivate); err != nil {
+			return nil, err
+		}
+		set["url"] = *req.URL
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+		set["events"] = slices.Compact(slices.Sorted(slices.Values(req.Events)))
+	}
+	if req.Active != nil {
+		set["active"] = *req.Active
+	}
+
+	var updated models.Webhook
+	if err := s.coll.FindOneAndUpdate(s.ctx, bson.M{"_id": hook.ID}, bson.M{"$set": set}, &updated); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("webhook not found")
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteWebhook removes a webhook together with its delivery log
+func (s *WebhookService) DeleteWebhook(projectID, webhookID, userID string) error {
+	hook, err := s.findWebhook(projectID, webhookID, userID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.coll.DeleteOne(s.ctx, bson.M{"_id": hook.ID})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return notFoundError("webhook not found")
+	}
+
+	_, err = s.deliveryColl.DeleteMany(s.ctx, bson.M{"webhookId": hook.ID})
+	return err
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first
+func (s *WebhookService) ListDeliveries(projectID, webhookID, userID string) ([]models.WebhookDelivery, error) {
+	hook, err := s.findWebhook(projectID, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err = s.deliveryColl.Find(s.ctx, bson.M{"webhookId": hook.ID}, &deliveries, store.FindOptions{
+		Sort:  bson.D{{Key: "createdAt", Value: -1}},
+		Limit: deliveryListLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues a new delivery with the payload of an earlier one
+func (s *WebhookService) Redeliver(projectID, webhookID, deliveryID, userID string) (*models.WebhookDelivery, error) {
+	hook, err := s.findWebhook(projectID, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+	did, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, errors.New("invalid delivery id")
+	}
+
+	var original models.WebhookDelivery
+	if err := s.deliveryColl.FindOne(s.ctx, bson.M{"_id": did, "webhookId": hook.ID}, &original); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("delivery not found")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     hook.ID,
+		ProjectID:     hook.ProjectID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+		CreatedAt:     now,
+	}
+	if err := s.deliveryColl.InsertOne(s.ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// StartWorker periodically sends the deliveries that are due
+func (s *WebhookService) StartWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.processDue()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// processDue claims due deliveries one at a time, so several instances can
+// run the worker without sending a delivery twice
+func (s *WebhookService) processDue() {
+	for range webhookBatchSize {
+		now := time.Now()
+		var delivery models.WebhookDelivery
+		err := s.deliveryColl.FindOneAndUpdate(s.ctx,
+			bson.M{"status": models.DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"nextAttemptAt": now.Add(webhookLease)}},
+			&delivery,
+		)
+		if err != nil {
+			if err != store.ErrNotFound {
+				log.Printf("[WEBHOOK] Failed to claim a delivery: %v", err)
+			}
+			return
+		}
+
+		s.attempt(&delivery)
+	}
+}
+
+// attempt sends a delivery once and schedules a retry with exponential
+// backoff when the receiver did not answer 2xx
+func (s *WebhookService) attempt(delivery *models.WebhookDelivery) {
+	var hook models.Webhook
+	err := s.coll.FindOne(s.ctx, bson.M{"_id": delivery.WebhookID}, &hook)
+	if err != nil || !hook.Active {
+		s.finishDelivery(delivery.ID, bson.M{
+			"status":    models.DeliveryFailed,
+			"lastError": "webhook was deleted or disabled",
+		}, nil)
+		return
+	}
+
+	start := time.Now()
+	statusCode, sendErr := s.send(&hook, delivery)
+	attempts := delivery.Attempts + 1
+	now := time.Now()
+
+	entry := &models.DeliveryAttempt{At: start, StatusCode: statusCode, DurationMs: now.Sub(start).Milliseconds()}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+	}
+
+	set := bson.M{"attempts": attempts, "lastStatusCode": statusCode}
+	switch {
+	case sendErr == nil:
+		set["status"] = models.DeliverySucceeded
+		set["deliveredAt"] = now
+		set["lastError"] = ""
+	case attempts >= s.cfg.WebhookMaxAttempts:
+		set["status"] = models.DeliveryFailed
+		set["lastError"] = sendErr.Error()
+	default:
+		set["lastError"] = sendErr.Error()
+		set["nextAttemptAt"] = now.Add(webhookBackoff(s.cfg.WebhookRetryBase, attempts))
+		update := bson.M{"$set": set, "$push": bson.M{"history": entry}}
+		if _, err := s.deliveryColl.UpdateOne(s.ctx, bson.M{"_id": delivery.ID}, update); err != nil {
+			log.Printf("[WEBHOOK] Failed to schedule retry of delivery %s: %v", delivery.ID.Hex(), err)
+		}
+		return
+	}
+
+	s.finishDelivery(delivery.ID, set, entry)
+}
+
+// finishDelivery records the final state and the last attempt, if one was
+// made; a finished delivery has no next attempt
+func (s *WebhookService) finishDelivery(id primitive.ObjectID, set bson.M, attempt *models.DeliveryAttempt) {
+	update := bson.M{"$set": set, "$unset": bson.M{"nextAttemptAt": ""}}
+	if attempt != nil {
+		update["$push"] = bson.M{"history": attempt}
+	}
+	if _, err := s.deliveryColl.UpdateOne(s.ctx, bson.M{"_id": id}, update); err != nil {
+		log.Printf("[WEBHOOK] Failed to update delivery %s: %v", id.Hex(), err)
+	}
+}
+
+// send posts the payload signed with the webhook secret
+func (s *WebhookService) send(hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.cfg.AppName+" Webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The receiver's body is not kept: the delivery log would hand it to
+	// project owners
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value: the hex HMAC-SHA256
+// of "<timestamp>.<payload>" keyed with the webhook secret
+func SignWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff doubles the delay after each failed attempt, up to an hour
+func webhookBackoff(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxBackoff)
+}
+
+func (s *WebhookService) findWebhook(projectID, webhookID, userID string) (*models.Webhook, error) {
+	pid, err := s.ownedProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	wid, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, errors.New("invalid webhook id")
+	}
+
+	var hook models.Webhook
+	if err := s.coll.FindOne(s.ctx, bson.M{"_id": wid, "projectId": pid}, &hook); err != nil {
+		if err == store.ErrNotFound {
+			return nil, notFoundError("webhook not found")
+		}
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+// ownedProject checks that the user is an owner of the project
+func (s *WebhookService) ownedProject(projectID, userID string) (primitive.ObjectID, error) {
+	pid, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return pid, errors.New("invalid project id")
+	}
+
+	if _, err := s.access.authorizeProject(pid, userID, models.RoleOwner); err != nil {
+		return pid, err
+	}
+
+	return pid, nil
+}
+
+// newWebhook builds an active webhook with a new signing secret
+func newWebhook(pid, createdBy primitive.ObjectID, url string, events []string) (*models.Webhook, error) {
+	random, err := utils.GenerateRandomID(24)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.Webhook{
+		ID:        primitive.NewObjectID(),
+		ProjectID: pid,
+		URL:       url,
+		Events:    slices.Compact(slices.Sorted(slices.Values(events))),
+		Secret:    webhookSecretPrefix + random,
+		Active:    true,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// validateWebhookURL accepts absolute http(s) URLs, outside the server's own
+// network unless allowPrivate is set
+func validateWebhookURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if !allowPrivate && privateURL(raw) {
+		return errPrivateWebhookURL
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for _, event := range events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("unknown event %q, expected one of %v", event, models.WebhookEvents)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saifwork/mock-service/internal/core/config"
+	database "github.com/saifwork/mock-service/internal/core/mongo"
+	"github.com/saifwork/mock-service/internal/core/store"
+	"github.com/saifwork/mock-service/internal/dtos"
+	"github.com/saifwork/mock-service/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// receivedWebhook is a request seen by the test receiver
+type receivedWebhook struct {
+	header http.Header
+	body   string
+}
+
+// webhookReceiver answers with the given status codes in turn, then 200
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	r := &webhookReceiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received = append(r.received, receivedWebhook{header: req.Header.Clone(), body: string(body)})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "internal receiver details")
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *webhookReceiver) requests() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.received...)
+}
+
+type webhookTest struct {
+	t       *testing.T
+	svc     *WebhookService
+	events  *webhookDispatcher
+	project primitive.ObjectID
+	owner   string
+}
+
+// newWebhookTest runs the webhook service on the memory store, with a
+// project owned by the test user
+func newWebhookTest(t *testing.T, allowPrivate bool) *webhookTest {
+	t.Helper()
+	db := store.NewMemoryStore()
+	cfg := &config.Config{AppName: "test", WebhookMaxAttempts: 3, WebhookRetryBase: time.Minute, WebhookAllowPrivate: allowPrivate}
+
+	owner := primitive.NewObjectID()
+	project := models.Project{ID: primitive.NewObjectID(), UserID: owner, Name: "Shop", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := db.Repository(database.Collections.Projects).InsertOne(context.Background(), project); err != nil {
+		t.Fatal(err)
+	}
+
+	return &webhookTest{t: t, svc: NewWebhookService(db, cfg), events: newWebhookDispatcher(db), project: project.ID, owner: owner.Hex()}
+}
+
+func (w *webhookTest) create(url string) *dtos.CreateWebhookResponse {
+	w.t.Helper()
+	created, err := w.svc.CreateWebhook(w.project.Hex(), w.owner, &dtos.CreateWebhookRequest{URL: url, Events: []string{models.WebhookRecordCreated}})
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	return created
+}
+
+func (w *webhookTest) deliveries(hook *models.Webhook) []models.WebhookDelivery {
+	w.t.Helper()
+	deliveries, err := w.svc.ListDeliveries(w.project.Hex(), hook.ID.Hex(), w.owner)
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	return deliveries
+}
+
+// makeDue moves the retry of a delivery to now, as if its backoff had passed
+func (w *webhookTest) makeDue(delivery *models.WebhookDelivery) {
+	w.t.Helper()
+	if _, err := w.svc.deliveryColl.UpdateOne(context.Background(), bson.M{"_id": delivery.ID}, bson.M{"$set": bson.M{"nextAttemptAt": time.Now()}}); err != nil {
+		w.t.Fatal(err)
+	}
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	w := newWebhookTest(t, true)
+	receiver := newWebhookReceiver(t)
+	created := w.create(receiver.URL + "/hooks")
+
+	w.events.emit(w.project, models.WebhookRecordCreated, map[string]any{"id": "r1"})
+	w.svc.processDue()
+
+	received := receiver.requests()
+	if len(received) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(received))
+	}
+	got := received[0]
+	if got.header.Get(WebhookEventHeader) != models.WebhookRecordCreated {
+		t.Errorf("%s = %q", WebhookEventHeader, got.header.Get(WebhookEventHeader))
+	}
+
+	// What a receiver does with the secret it was given
+	mac := hmac.New(sha256.New, []byte(created.Secret))
+	mac.Write([]byte(got.header.Get(WebhookTimestampHeader) + "." + got.body))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if signature := got.header.Get(WebhookSignatureHeader); !hmac.Equal([]byte(signature), []byte(want)) {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+	if !strings.Contains(got.body, `"id":"r1"`) {
+		t.Errorf("payload = %s", got.body)
+	}
+
+	deliveries := w.deliveries(created.Webhook)
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliverySucceeded || deliveries[0].ID.Hex() != got.header.Get(WebhookDeliveryHeader) {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	w := newWebhookTest(t, true)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	created := w.create(receiver.URL)
+
+	w.events.emit(w.project, models.WebhookRecordCreated, map[string]any{"id": "r1"})
+
+	// 🔁 Each failure pushes the next attempt back twice as far
+	for attempt, backoff := range []time.Duration{time.Minute, 2 * time.Minute} {
+		before := time.Now()
+		w.svc.processDue()
+
+		delivery := w.deliveries(created.Webhook)[0]
+		if delivery.Status != models.DeliveryPending || delivery.Attempts != attempt+1 || delivery.NextAttemptAt == nil {
+			t.Fatalf("after attempt %d: %+v", attempt+1, delivery)
+		}
+		// Stored times are rounded to the millisecond
+		if wait := delivery.NextAttemptAt.Sub(before); wait < backoff-time.Millisecond || wait > backoff+time.Second {
+			t.Errorf("attempt %d: next attempt in %v, want %v", attempt+1, wait, backoff)
+		}
+		if strings.Contains(delivery.LastError, "receiver details") {
+			t.Errorf("attempt %d: receiver body kept in the log: %q", attempt+1, delivery.LastError)
+		}
+
+		// Not due yet
+		w.svc.processDue()
+		if n := len(receiver.requests()); n != attempt+1 {
+			t.Fatalf("receiver got %d requests before the backoff passed, want %d", n, attempt+1)
+		}
+		w.makeDue(&delivery)
+	}
+
+	w.svc.processDue()
+
+	// 📜 One history entry per attempt
+	delivery := w.deliveries(created.Webhook)[0]
+	if delivery.Status != models.DeliverySucceeded || delivery.Attempts != 3 || delivery.NextAttemptAt != nil {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	wantCodes := []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}
+	if len(delivery.History) != len(wantCodes) {
+		t.Fatalf("history = %+v, want %d attempts", delivery.History, len(wantCodes))
+	}
+	for i, code := range wantCodes {
+		if entry := delivery.History[i]; entry.StatusCode != code || (code == http.StatusOK) != (entry.Error == "") {
+			t.Errorf("history[%d] = %+v, want status %d", i, entry, code)
+		}
+	}
+}
+
+func TestWebhookGivesUpAfterMaxAttempts(t *testing.T) {
+	w := newWebhookTest(t, true)
+	receiver := newWebhookReceiver(t, 500, 500, 500, 500)
+	created := w.create(receiver.URL)
+
+	w.events.emit(w.project, models.WebhookRecordCreated, nil)
+	for range 2 {
+		w.svc.processDue()
+		w.makeDue(&w.deliveries(created.Webhook)[0])
+	}
+	w.svc.processDue()
+	w.svc.processDue()
+
+	delivery := w.deliveries(created.Webhook)[0]
+	if delivery.Status != models.DeliveryFailed || delivery.Attempts != 3 || len(delivery.History) != 3 || delivery.NextAttemptAt != nil {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	if n := len(receiver.requests()); n != 3 {
+		t.Errorf("receiver got %d requests, want 3", n)
+	}
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	w := newWebhookTest(t, true)
+	receiver := newWebhookReceiver(t)
+	created := w.create(receiver.URL)
+
+	w.events.emit(w.project, models.WebhookRecordCreated, map[string]any{"id": "r1"})
+	w.svc.processDue()
+	original := w.deliveries(created.Webhook)[0]
+
+	redelivery, err := w.svc.Redeliver(w.project.Hex(), created.Webhook.ID.Hex(), original.ID.Hex(), w.owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.Status != models.DeliveryPending || redelivery.RedeliveryOf == nil || *redelivery.RedeliveryOf != original.ID {
+		t.Fatalf("redelivery = %+v", redelivery)
+	}
+	w.svc.processDue()
+
+	received := receiver.requests()
+	if len(received) != 2 || received[1].body != original.Payload || received[1].header.Get(WebhookDeliveryHeader) != redelivery.ID.Hex() {
+		t.Fatalf("receiver got %+v", received)
+	}
+	if deliveries := w.deliveries(created.Webhook); len(deliveries) != 2 || deliveries[0].Status != models.DeliverySucceeded {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+
+	var accessErr *AccessError
+	if _, err := w.svc.Redeliver(w.project.Hex(), created.Webhook.ID.Hex(), primitive.NewObjectID().Hex(), w.owner); !errors.As(err, &accessErr) || accessErr.Status != http.StatusNotFound {
+		t.Errorf("redeliver of an unknown delivery: %v, want 404", err)
+	}
+}
+
+func TestWebhookRejectsPrivateURLs(t *testing.T) {
+	w := newWebhookTest(t, false)
+
+	for _, url := range []string{
+		"http://localhost:3000/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.8/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+	} {
+		_, err := w.svc.CreateWebhook(w.project.Hex(), w.owner, &dtos.CreateWebhookRequest{URL: url, Events: []string{models.WebhookRecordCreated}})
+		if !errors.Is(err, errPrivateWebhookURL) {
+			t.Errorf("create %s: %v, want %v", url, err, errPrivateWebhookURL)
+		}
+	}
+
+	created := w.create("https://hooks.example.com/in")
+	private := "http://127.0.0.1:8080/hook"
+	if _, err := w.svc.UpdateWebhook(w.project.Hex(), created.Webhook.ID.Hex(), w.owner, &dtos.UpdateWebhookRequest{URL: &private}); !errors.Is(err, errPrivateWebhookURL) {
+		t.Errorf("update: %v, want %v", err, errPrivateWebhookURL)
+	}
+
+	// 🛡️ Addresses are checked again on every connection and redirect
+	receiver := newWebhookReceiver(t)
+	if _, err := w.svc.coll.UpdateOne(context.Background(), bson.M{"_id": created.Webhook.ID}, bson.M{"$set": bson.M{"url": receiver.URL}}); err != nil {
+		t.Fatal(err)
+	}
+	w.events.emit(w.project, models.WebhookRecordCreated, nil)
+	w.svc.processDue()
+	if n := len(receiver.requests()); n != 0 {
+		t.Fatalf("receiver on a loopback address got %d requests", n)
+	}
+	if delivery := w.deliveries(created.Webhook)[0]; !strings.Contains(delivery.LastError, "not allowed") {
+		t.Errorf("delivery error = %q", delivery.LastError)
+	}
+
+	hop, _ := http.NewRequest(http.MethodPost, "http://169.254.169.254/latest/meta-data", nil)
+	if err := w.svc.client.CheckRedirect(hop, []*http.Request{{}}); !errors.Is(err, errPrivateWebhookURL) {
+		t.Errorf("redirect to a link-local address: %v, want %v", err, errPrivateWebhookURL)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	base := 30 * time.Second
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, webhookMaxBackoff}, // 64 minutes, capped
+		{20, webhookMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(base, tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%v, %d) = %v, want %v", base, tt.attempts, got, tt.want)
+		}
+	}
+}
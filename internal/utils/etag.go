@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionETag formats a record version as a strong ETag, e.g. "3"
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ETagMatches tells whether an If-Match or If-None-Match header lists the
+// given ETag or is "*". Weak comparison (If-None-Match) ignores the W/ prefix;
+// strong comparison (If-Match) never matches a weak ETag.
+func ETagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
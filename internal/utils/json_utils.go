@@ -3,6 +3,8 @@ package utils
 import (
 	"encoding/json"
 	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ValidateJSON checks if a string is valid JSON
@@ -22,3 +24,40 @@ func PrettyPrintJSON(data any) (string, error) {
 	}
 	return string(pretty), nil
 }
+
+// NormalizeBSON converts values decoded by the Mongo driver into plain JSON types.
+// Documents stored in `any` fields come back as primitive.D / primitive.A, which
+// do not serialise as regular JSON objects and arrays.
+func NormalizeBSON(v any) any {
+	switch val := v.(type) {
+	case primitive.D:
+		m := make(map[string]any, len(val))
+		for _, e := range val {
+			m[e.Key] = NormalizeBSON(e.Value)
+		}
+		return m
+	case primitive.M:
+		m := make(map[string]any, len(val))
+		for k, item := range val {
+			m[k] = NormalizeBSON(item)
+		}
+		return m
+	case map[string]any:
+		for k, item := range val {
+			val[k] = NormalizeBSON(item)
+		}
+		return val
+	case primitive.A:
+		list := make([]any, len(val))
+		for i, item := range val {
+			list[i] = NormalizeBSON(item)
+		}
+		return list
+	case []any:
+		for i, item := range val {
+			val[i] = NormalizeBSON(item)
+		}
+		return val
+	}
+	return v
+}
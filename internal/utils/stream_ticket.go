@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// streamTicketPurpose tells tickets apart from other tokens
+const streamTicketPurpose = "change-stream"
+
+// streamTicketKey derives the signing key of stream tickets from the access
+// secret, so a ticket (which travels in URLs) is never accepted as an access
+// token, and an access token never as a ticket.
+func streamTicketKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(streamTicketPurpose))
+	return mac.Sum(nil)
+}
+
+// GenerateStreamTicket signs a short-lived ticket letting userID open the
+// change streams of one project.
+func GenerateStreamTicket(userID, projectID, secret string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"userId":    userID,
+		"projectId": projectID,
+		"purpose":   streamTicketPurpose,
+		"exp":       time.Now().Add(ttl).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(streamTicketKey(secret))
+}
+
+// ParseStreamTicket returns the user and project a valid ticket was issued for
+func ParseStreamTicket(ticket, secret string) (userID, projectID string, err error) {
+	token, err := jwt.Parse(ticket, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return streamTicketKey(secret), nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", errors.New("invalid or expired ticket")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != streamTicketPurpose {
+		return "", "", errors.New("invalid or expired ticket")
+	}
+	userID, _ = claims["userId"].(string)
+	projectID, _ = claims["projectId"].(string)
+	if userID == "" || projectID == "" {
+		return "", "", errors.New("invalid or expired ticket")
+	}
+	return userID, projectID, nil
+}
@@ -4,15 +4,15 @@ import (
 	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/saifwork/mock-service/internal/api"
 	"github.com/saifwork/mock-service/internal/api/handlers"
 	"github.com/saifwork/mock-service/internal/core/config"
-	"github.com/saifwork/mock-service/internal/core/mongo"
-
-	// redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	redisClient "github.com/saifwork/mock-service/internal/core/redis"
+	"github.com/saifwork/mock-service/internal/core/store"
 	"github.com/saifwork/mock-service/internal/middlewares"
 	"github.com/saifwork/mock-service/internal/services"
 )
@@ -35,44 +35,92 @@ func main() {
 	cfg := config.LoadConfig()
 
 	// --- Init Redis ---
-	// redisClient.InitRedis(cfg)
-	// defer redisClient.CloseRedis()
+	if err := redisClient.InitRedis(cfg); err != nil {
+		log.Printf("[REDIS] %v — continuing without Redis-backed features", err)
+	}
+	defer redisClient.CloseRedis()
 
-	// --- Initialize Mongo ---
-	mongoClient, err := mongo.InitMongo(cfg)
+	// --- Initialize storage backend ---
+	db, err := store.Open(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect MongoDB: %v", err)
+		log.Fatalf("Failed to open %s store: %v", cfg.StorageDriver, err)
 	}
 	defer func() {
-		if err := mongoClient.Disconnect(ctx); err != nil {
-			log.Printf("Error disconnecting MongoDB: %v", err)
+		if err := db.Close(ctx); err != nil {
+			log.Printf("Error closing %s store: %v", db.Driver(), err)
 		}
 	}()
+	log.Printf("[STORE] Using %s storage driver", db.Driver())
 
-	authSvc := services.NewAuthService(mongoClient, cfg)
-	projectSvc := services.NewProjectService(mongoClient, cfg)
-	collectionSvc := services.NewCollectionService(mongoClient, cfg)
-	recordSvc := services.NewRecordService(mongoClient, cfg)
+	authSvc := services.NewAuthService(db, cfg)
+	projectSvc := services.NewProjectService(db, cfg)
+	collectionSvc := services.NewCollectionService(db, cfg)
+	recordSvc := services.NewRecordService(db, cfg)
 	configSvc := services.NewConfigService()
+	endpointSvc := services.NewEndpointService(db, cfg)
+	sessionSvc := services.NewSessionService(db, cfg)
+	sessionSvc.StartCleanup(ctx, time.Minute)
+	proxySvc := services.NewProxyService(db, cfg, collectionSvc, recordSvc)
+	mockSvc := services.NewMockService(db, cfg, endpointSvc, collectionSvc, recordSvc, sessionSvc, proxySvc)
+	inspectorSvc := services.NewInspectorService(db, cfg)
+	rateLimitSvc := services.NewRateLimitService(db, cfg)
+	apiKeySvc := services.NewAPIKeyService(db, cfg)
+	memberSvc := services.NewMemberService(db, cfg)
+	organizationSvc := services.NewOrganizationService(db, cfg)
+	auditSvc := services.NewAuditService(db, cfg)
+	auditSvc.StartRetention(ctx, time.Hour)
+	webhookSvc := services.NewWebhookService(db, cfg)
+	webhookSvc.StartWorker(ctx, 5*time.Second)
+	changeStreamSvc := services.NewChangeStreamService(db, cfg)
+	snapshotSvc := services.NewSnapshotService(db, cfg)
+	trashSvc := services.NewTrashService(db, cfg)
+	trashSvc.StartPurge(ctx, time.Hour)
+	templateSvc := services.NewTemplateService(db, cfg, projectSvc)
+	bundleSvc := services.NewBundleService(db, cfg, templateSvc)
+	environmentSvc := services.NewEnvironmentService(db, cfg)
+	openAPISvc := services.NewOpenAPIService(db, cfg, templateSvc)
+	harSvc := services.NewHARService(db, cfg)
+	validationSvc := services.NewValidationService(db, cfg)
+	validationSvc.StartRetention(ctx, time.Hour)
 
 	// init handlers
 	authHandler := handlers.NewAuthHandler(authSvc, cfg)
 	projectHandler := handlers.NewProjectHandler(projectSvc, cfg)
-	collectionHandler := handlers.NewCollectionHandler(collectionSvc, cfg)
-	recordHandler := handlers.NewRecordHandler(recordSvc, cfg)
+	collectionHandler := handlers.NewCollectionHandler(collectionSvc, apiKeySvc, cfg)
+	recordHandler := handlers.NewRecordHandler(recordSvc, apiKeySvc, cfg)
 	configHandler := handlers.NewConfigHandler(configSvc, cfg)
-	healthHandler := handlers.NewHealthHandler(mongoClient)
+	healthHandler := handlers.NewHealthHandler(db)
+	endpointHandler := handlers.NewEndpointHandler(endpointSvc, apiKeySvc, cfg)
+	mockHandler := handlers.NewMockHandler(mockSvc, inspectorSvc, cfg)
+	inspectorHandler := handlers.NewInspectorHandler(inspectorSvc, cfg)
+	sessionHandler := handlers.NewSessionHandler(sessionSvc, collectionSvc, recordSvc, cfg)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc, cfg)
+	memberHandler := handlers.NewMemberHandler(memberSvc, cfg)
+	organizationHandler := handlers.NewOrganizationHandler(organizationSvc, cfg)
+	auditHandler := handlers.NewAuditHandler(auditSvc, cfg)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc, cfg)
+	changeStreamHandler := handlers.NewChangeStreamHandler(changeStreamSvc, apiKeySvc, cfg)
+	snapshotHandler := handlers.NewSnapshotHandler(snapshotSvc, apiKeySvc, cfg)
+	trashHandler := handlers.NewTrashHandler(trashSvc, cfg)
+	templateHandler := handlers.NewTemplateHandler(templateSvc, cfg)
+	bundleHandler := handlers.NewBundleHandler(bundleSvc, cfg)
+	environmentHandler := handlers.NewEnvironmentHandler(environmentSvc, apiKeySvc, cfg)
+	proxyHandler := handlers.NewProxyHandler(proxySvc, apiKeySvc, cfg)
+	openAPIHandler := handlers.NewOpenAPIHandler(openAPISvc, cfg)
+	harHandler := handlers.NewHARHandler(harSvc, apiKeySvc, cfg)
+	validationHandler := handlers.NewValidationHandler(validationSvc, apiKeySvc, cfg)
 
 	// --- Initialize Gin ---
 	r := gin.New() // Use New() instead of Default() to control middleware order
 	r.Use(
-		gin.Recovery(),       // Gin recovery for panics
-		middlewares.Logger(), // Custom request logger
-		middlewares.CORS(),   // Handle cross-origin requests
+		gin.Recovery(),                           // Gin recovery for panics
+		middlewares.Logger(),                     // Custom request logger
+		middlewares.CORS(),                       // Handle cross-origin requests
+		middlewares.RateLimit(cfg, rateLimitSvc), // Per-client request limits (Redis)
 	)
 
 	// --- Register routes ---
-	api.RegisterRoutes(r, cfg, authHandler, projectHandler, collectionHandler, recordHandler, healthHandler, configHandler)
+	api.RegisterRoutes(r, cfg, authHandler, projectHandler, collectionHandler, recordHandler, healthHandler, configHandler, endpointHandler, mockHandler, inspectorHandler, sessionHandler, apiKeyHandler, memberHandler, organizationHandler, auditHandler, webhookHandler, changeStreamHandler, snapshotHandler, trashHandler, templateHandler, bundleHandler, environmentHandler, proxyHandler, openAPIHandler, harHandler, validationHandler)
 
 	// --- Start server ---
 	log.Printf("Starting %s on port %s...", cfg.AppName, cfg.AppPort)